@@ -3,8 +3,31 @@ package command
 import (
 	"context"
 	"github.com/segmentio/kafka-go"
+	"reflect"
 )
 
 type Command interface {
 	Execute(ctx context.Context, message kafka.Message, result chan TypedResult)
 }
+
+// Name identifies the command among the commands run for a message by its type, e.g. `order.PackItemCommand`.
+func Name(c Command) string {
+	commandType := reflect.TypeOf(c)
+	if commandType.Kind() == reflect.Pointer {
+		commandType = commandType.Elem()
+	}
+	return commandType.String()
+}
+
+type succeededKey struct{}
+
+// ContextWithSucceeded carries the names of the commands that already succeeded for an earlier delivery of the
+// message, they are not run again.
+func ContextWithSucceeded(ctx context.Context, succeeded map[string]bool) context.Context {
+	return context.WithValue(ctx, succeededKey{}, succeeded)
+}
+
+func succeededBefore(ctx context.Context, name string) bool {
+	succeeded, _ := ctx.Value(succeededKey{}).(map[string]bool)
+	return succeeded[name]
+}
@@ -9,14 +9,15 @@ import (
 )
 
 type Dispatcher interface {
-	Execute(c Command, message kafka.Message, result chan TypedResult)
+	Execute(ctx context.Context, c Command, message kafka.Message, result chan TypedResult)
 }
 
 type DefaultDispatcher struct{}
 
-func (r *DefaultDispatcher) Execute(c Command, message kafka.Message, result chan TypedResult) {
+// Execute runs the command with a timeout, values of ctx like the envelope of the message are passed on to it.
+func (r *DefaultDispatcher) Execute(ctx context.Context, c Command, message kafka.Message, result chan TypedResult) {
 	log.Info.Println("About to execute following command", reflect.TypeOf(c))
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 	c.Execute(ctx, message, result)
 }
@@ -1,16 +1,19 @@
 package command
 
 import (
+	"context"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/log"
-	"mc-burger-orders/utils"
+	"sync"
 )
 
 type Handler interface {
 	AddCommands(event string, commands ...Command)
 	GetHandledEvents() []string
-	GetCommands(message kafka.Message) ([]Command, error)
-	Handle(message kafka.Message, typedResult chan TypedResult)
+	GetCommands(eventType string, message kafka.Message) ([]Command, error)
+	// Handle returns the results of the commands of the message once all of them finished. The event type is read
+	// from the envelope of the message by the event bus.
+	Handle(ctx context.Context, eventType string, message kafka.Message) Results
 }
 
 type DefaultCommandHandler struct {
@@ -42,34 +45,66 @@ func (o *DefaultCommandHandler) AddCommands(event string, commands ...Command) {
 	o.eventHandlers[event] = commands
 }
 
-func (o *DefaultCommandHandler) GetCommands(message kafka.Message) ([]Command, error) {
-	eventType, err := utils.GetEventType(message)
-	if err != nil {
-		log.Error.Println(err.Error())
-		return nil, err
-	}
-
+func (o *DefaultCommandHandler) GetCommands(eventType string, message kafka.Message) ([]Command, error) {
 	if commands, ok := o.eventHandlers[eventType]; ok {
 		return commands, nil
 	}
 	log.Warning.Printf("failed to find command handler in `%v` for messages of topic: %v", "DefaultCommandHandler", message.Topic)
-	return make([]Command, 0), err
+	return make([]Command, 0), nil
 }
 
-func (o *DefaultCommandHandler) Handle(message kafka.Message, commandResults chan TypedResult) {
-	commands, err := o.GetCommands(message)
+func (o *DefaultCommandHandler) Handle(ctx context.Context, eventType string, message kafka.Message) Results {
+	commands, err := o.GetCommands(eventType, message)
 	if err != nil {
-		commandResults <- NewErrorResult("GetCommandForMessage", err)
-		close(commandResults)
-		return
+		return Results{NewErrorResult("GetCommandForMessage", err)}
 	}
 
-	o.HandleCommands(message, commandResults, commands...)
+	return o.HandleCommands(ctx, message, commands...)
 }
 
-func (o *DefaultCommandHandler) HandleCommands(message kafka.Message, commandResults chan TypedResult, commands ...Command) {
+// HandleCommands runs the commands concurrently and returns their results once all of them finished. The channel the
+// commands send results to is closed right after the last command returned, commands must not send results later.
+// Commands that already succeeded for an earlier delivery of the message, see ContextWithSucceeded, are skipped.
+func (o *DefaultCommandHandler) HandleCommands(ctx context.Context, message kafka.Message, commands ...Command) Results {
 	log.Info.Printf("Message will be executed on %d command(s)\n", len(commands))
+	commandResults := make(chan TypedResult)
+	waitGroup := &sync.WaitGroup{}
 	for _, command := range commands {
-		go o.Execute(command, message, commandResults)
+		name := Name(command)
+		if succeededBefore(ctx, name) {
+			log.Warning.Printf("Command %v already succeeded for message of topic %v, skipping it", name, message.Topic)
+			continue
+		}
+
+		waitGroup.Add(1)
+		go func(command Command) {
+			defer waitGroup.Done()
+			o.execute(ctx, command, name, message, commandResults)
+		}(command)
+	}
+
+	go func() {
+		waitGroup.Wait()
+		close(commandResults)
+	}()
+
+	results := make(Results, 0)
+	for result := range commandResults {
+		results = append(results, result)
+	}
+	return results
+}
+
+// execute passes the results of the command on with its name, so outcomes are recorded per command.
+func (o *DefaultCommandHandler) execute(ctx context.Context, command Command, name string, message kafka.Message, commandResults chan TypedResult) {
+	results := make(chan TypedResult)
+	go func() {
+		defer close(results)
+		o.Execute(ctx, command, message, results)
+	}()
+
+	for result := range results {
+		result.Command = name
+		commandResults <- result
 	}
 }
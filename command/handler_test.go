@@ -0,0 +1,124 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const testEvent = "test-event"
+
+type ResultCommand struct {
+	results []TypedResult
+}
+
+func (c *ResultCommand) Execute(ctx context.Context, message kafka.Message, result chan TypedResult) {
+	time.Sleep(time.Millisecond)
+	for _, typedResult := range c.results {
+		result <- typedResult
+	}
+}
+
+// OtherResultCommand is a command of another type than ResultCommand.
+type OtherResultCommand struct {
+	ResultCommand
+}
+
+func givenMessage() kafka.Message {
+	return kafka.Message{Topic: "test-topic", Value: []byte("{}")}
+}
+
+func TestDefaultCommandHandler_Handle(t *testing.T) {
+	t.Run("should return results of all commands", shouldReturnResultsOfAllCommands)
+	t.Run("should return when command sends no result", shouldReturnWhenCommandSendsNoResult)
+	t.Run("should return no results for event without commands", shouldReturnNoResultsForEventWithoutCommands)
+	t.Run("should not leak goroutines once commands finished", shouldNotLeakGoroutinesOnceCommandsFinished)
+	t.Run("should skip commands that already succeeded for message", shouldSkipCommandsThatAlreadySucceededForMessage)
+}
+
+func shouldReturnResultsOfAllCommands(t *testing.T) {
+	// given
+	sut := NewCommandHandler()
+	sut.AddCommands(testEvent,
+		&ResultCommand{results: []TypedResult{NewSuccessfulResult("First")}},
+		&ResultCommand{results: []TypedResult{NewErrorResult("Second", fmt.Errorf("second failed"))}},
+	)
+
+	// when
+	results := sut.Handle(context.Background(), testEvent, givenMessage())
+
+	// then
+	assert.Len(t, results, 2)
+	assert.EqualError(t, results.Err(), "second failed")
+}
+
+func shouldReturnWhenCommandSendsNoResult(t *testing.T) {
+	// given
+	sut := NewCommandHandler()
+	sut.AddCommands(testEvent, &ResultCommand{}, &ResultCommand{results: []TypedResult{NewSuccessfulResult("Second")}})
+
+	// when
+	results := sut.Handle(context.Background(), testEvent, givenMessage())
+
+	// then
+	assert.Len(t, results, 1)
+	assert.NoError(t, results.Err())
+}
+
+func shouldReturnNoResultsForEventWithoutCommands(t *testing.T) {
+	// given
+	sut := NewCommandHandler()
+	sut.AddCommands(testEvent, &ResultCommand{results: []TypedResult{NewSuccessfulResult("First")}})
+
+	// when
+	results := sut.Handle(context.Background(), "other-event", givenMessage())
+
+	// then
+	assert.Empty(t, results)
+	assert.NoError(t, results.Err())
+}
+
+func shouldNotLeakGoroutinesOnceCommandsFinished(t *testing.T) {
+	// given
+	sut := NewCommandHandler()
+	sut.AddCommands(testEvent,
+		&ResultCommand{results: []TypedResult{NewSuccessfulResult("First"), NewSuccessfulResult("First")}},
+		&ResultCommand{},
+		&ResultCommand{results: []TypedResult{NewErrorResult("Third", fmt.Errorf("third failed"))}},
+	)
+	before := runtime.NumGoroutine()
+
+	// when
+	for range make([]int, 100) {
+		sut.Handle(context.Background(), testEvent, givenMessage())
+	}
+
+	// then
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "goroutines left running after all messages were handled")
+}
+
+func shouldSkipCommandsThatAlreadySucceededForMessage(t *testing.T) {
+	// given
+	sut := NewCommandHandler()
+	sut.AddCommands(testEvent,
+		&ResultCommand{results: []TypedResult{NewSuccessfulResult("First")}},
+		&OtherResultCommand{ResultCommand{results: []TypedResult{NewSuccessfulResult("Second")}}},
+	)
+	ctx := ContextWithSucceeded(context.Background(), map[string]bool{"command.ResultCommand": true})
+
+	// when
+	results := sut.Handle(ctx, testEvent, givenMessage())
+
+	// then
+	assert.Len(t, results, 1)
+	assert.Equal(t, "Second", results[0].Type)
+	assert.Equal(t, "command.OtherResultCommand", results[0].Command)
+}
@@ -1,6 +1,7 @@
 package command
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -9,6 +10,22 @@ type TypedResult struct {
 	Result bool
 	Type   string
 	Error  *HttpError
+	// Command is the name of the command that returned the result, it is set for commands run by HandleCommands
+	Command string
+}
+
+// Results are the results of all commands run for one message.
+type Results []TypedResult
+
+// Err joins the errors of the failed commands, it is nil when all of them succeeded.
+func (r Results) Err() error {
+	errs := make([]error, 0)
+	for _, result := range r {
+		if result.Error != nil {
+			errs = append(errs, result.Error.Error())
+		}
+	}
+	return errors.Join(errs...)
 }
 
 type HttpError struct {
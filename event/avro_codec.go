@@ -0,0 +1,111 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/hamba/avro/v2"
+	"sync"
+)
+
+const AvroContentType = "application/vnd.apache.avro+binary"
+
+// AvroCodec writes payloads with the Avro schema of their event type, registered in the schema registry under the
+// event type as subject. Payloads are read with the schema they were written with, fetched from the registry by id.
+type AvroCodec struct {
+	registry    SchemaRegistry
+	mu          sync.RWMutex
+	writers     map[string]avro.Schema
+	definitions map[string]string
+	readers     map[int]avro.Schema
+}
+
+func NewAvroCodec(registry SchemaRegistry) *AvroCodec {
+	return &AvroCodec{
+		registry:    registry,
+		writers:     make(map[string]avro.Schema),
+		definitions: make(map[string]string),
+		readers:     make(map[int]avro.Schema),
+	}
+}
+
+// WithSchema sets the schema payloads of the event type are written with, it panics on an invalid schema.
+func (c *AvroCodec) WithSchema(eventType string, definition string) *AvroCodec {
+	schema, err := avro.Parse(definition)
+	if err != nil {
+		panic(fmt.Sprintf("invalid Avro schema of %v event: %v", eventType, err))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.writers[eventType] = schema
+	c.definitions[eventType] = definition
+	return c
+}
+
+func (c *AvroCodec) ContentType() string {
+	return AvroContentType
+}
+
+func (c *AvroCodec) Encode(ctx context.Context, eventType string, payload any) ([]byte, error) {
+	c.mu.RLock()
+	schema, ok := c.writers[eventType]
+	definition := c.definitions[eventType]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no Avro schema configured for %v event", eventType)
+	}
+
+	data, err := avro.Marshal(schema, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaId, err := c.registry.Register(ctx, eventType, Schema{Type: AvroSchema, Definition: definition})
+	if err != nil {
+		return nil, err
+	}
+	return toWireFormat(schemaId, data), nil
+}
+
+func (c *AvroCodec) Decode(ctx context.Context, data []byte, target any) error {
+	schemaId, payload, err := fromWireFormat(data)
+	if err != nil {
+		return err
+	}
+
+	schema, err := c.readerSchema(ctx, schemaId)
+	if err != nil {
+		return err
+	}
+	if err = avro.Unmarshal(schema, payload, target); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedMessage, err)
+	}
+	return nil
+}
+
+func (c *AvroCodec) readerSchema(ctx context.Context, schemaId int) (avro.Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.readers[schemaId]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	registered, err := c.registry.SchemaById(ctx, schemaId)
+	if err != nil {
+		return nil, err
+	}
+	if registered.Type != AvroSchema {
+		return nil, fmt.Errorf("schema %d is not an Avro schema but %v", schemaId, registered.Type)
+	}
+
+	schema, err = avro.Parse(registered.Definition)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.readers[schemaId] = schema
+	return schema, nil
+}
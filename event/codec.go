@@ -0,0 +1,126 @@
+package event
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"sync"
+)
+
+// Codec turns payloads into message values and back. Codecs backed by the schema registry check the payload against
+// the schema of its event type when encoding, so a payload consumers could not read fails to be published.
+type Codec interface {
+	ContentType() string
+	Encode(ctx context.Context, eventType string, payload any) ([]byte, error)
+	Decode(ctx context.Context, data []byte, target any) error
+}
+
+// ErrMalformedMessage is wrapped by errors of messages which never decode, unlike errors of e.g. reaching the schema
+// registry, which may decode on the next attempt.
+var ErrMalformedMessage = errors.New("message is malformed")
+
+var (
+	codecsMu sync.RWMutex
+	// codecs used to encode payloads of an event type, payloads of other event types are encoded as JSON
+	eventCodecs = make(map[string]Codec)
+	// codecs used to decode payloads by the content type of the message
+	contentCodecs = map[string]Codec{JsonContentType: JsonCodec{}}
+)
+
+// UseCodec encodes payloads of the event types with the codec from now on, it is meant to be called on start up.
+func UseCodec(codec Codec, eventTypes ...string) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+
+	for _, eventType := range eventTypes {
+		eventCodecs[eventType] = codec
+	}
+	contentCodecs[codec.ContentType()] = codec
+}
+
+// EncodeMessage creates a keyed message with the payload encoded by the codec of the event type of the envelope.
+func EncodeMessage(ctx context.Context, key []byte, envelope Envelope, payload any) (kafka.Message, error) {
+	if payload == nil {
+		return NewMessage(key, nil, envelope), nil
+	}
+
+	codec := codecOfEvent(envelope.Type)
+	value, err := codec.Encode(ctx, envelope.Type, payload)
+	if err != nil {
+		return kafka.Message{}, fmt.Errorf("failed to encode payload of %v event. Reason: %w", envelope.Type, err)
+	}
+
+	envelope.ContentType = codec.ContentType()
+	return NewMessage(key, value, envelope), nil
+}
+
+// DecodePayload decodes the payload of the message by the codec of its content type, messages without content type
+// were sent as JSON.
+func DecodePayload(ctx context.Context, message kafka.Message, target any) error {
+	contentType := GetHeader(message, ContentTypeHeader)
+	if len(contentType) == 0 {
+		contentType = JsonContentType
+	}
+
+	codecsMu.RLock()
+	codec, ok := contentCodecs[contentType]
+	codecsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("no codec configured for content type %v", contentType)
+	}
+
+	if err := codec.Decode(ctx, message.Value, target); err != nil {
+		return fmt.Errorf("failed to decode payload of %v message. Reason: %w", contentType, err)
+	}
+	return nil
+}
+
+func codecOfEvent(eventType string) Codec {
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+
+	if codec, ok := eventCodecs[eventType]; ok {
+		return codec
+	}
+	return JsonCodec{}
+}
+
+type JsonCodec struct{}
+
+func (c JsonCodec) ContentType() string {
+	return JsonContentType
+}
+
+func (c JsonCodec) Encode(_ context.Context, _ string, payload any) ([]byte, error) {
+	return json.Marshal(payload)
+}
+
+func (c JsonCodec) Decode(_ context.Context, data []byte, target any) error {
+	if err := json.Unmarshal(data, target); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedMessage, err)
+	}
+	return nil
+}
+
+// Payloads of codecs backed by the schema registry use its wire format: a zero magic byte, the big endian id of the
+// schema the payload was written with, and the encoded payload.
+const wireFormatMagicByte = byte(0)
+
+var ErrInvalidWireFormat = fmt.Errorf("%w: payload is not in schema registry wire format", ErrMalformedMessage)
+
+func toWireFormat(schemaId int, payload []byte) []byte {
+	data := make([]byte, 5, 5+len(payload))
+	data[0] = wireFormatMagicByte
+	binary.BigEndian.PutUint32(data[1:5], uint32(schemaId))
+	return append(data, payload...)
+}
+
+func fromWireFormat(data []byte) (int, []byte, error) {
+	if len(data) < 5 || data[0] != wireFormatMagicByte {
+		return 0, nil, ErrInvalidWireFormat
+	}
+	return int(binary.BigEndian.Uint32(data[1:5])), data[5:], nil
+}
@@ -0,0 +1,213 @@
+package event
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	"mc-burger-orders/testing/utils"
+	"testing"
+)
+
+const (
+	itemAddedSchema = `{"type": "array", "items": {"type": "record", "name": "ItemAdded", "fields": [
+		{"name": "itemName", "type": "string"}, {"name": "quantity", "type": "int"}]}}`
+	changedItemAddedSchema = `{"type": "array", "items": {"type": "record", "name": "ItemAdded", "fields": [
+		{"name": "name", "type": "string"}, {"name": "quantity", "type": "int"}]}}`
+	stringValueDefinition = `syntax = "proto3"; package google.protobuf; message StringValue { string value = 1; }`
+)
+
+type itemAdded struct {
+	ItemName string `json:"itemName" avro:"itemName"`
+	Quantity int    `json:"quantity" avro:"quantity"`
+}
+
+func TestCodecs(t *testing.T) {
+	t.Run("should encode payload as JSON by default", shouldEncodePayloadAsJsonByDefault)
+	t.Run("should encode and decode payload as Avro", shouldEncodeAndDecodePayloadAsAvro)
+	t.Run("should register schema of event type once", shouldRegisterSchemaOfEventTypeOnce)
+	t.Run("should fail to publish payload with incompatible schema", shouldFailToPublishPayloadWithIncompatibleSchema)
+	t.Run("should fail to encode payload not matching schema", shouldFailToEncodePayloadNotMatchingSchema)
+	t.Run("should encode and decode payload as Protobuf", shouldEncodeAndDecodePayloadAsProtobuf)
+	t.Run("should fail to decode payload not in wire format", shouldFailToDecodePayloadNotInWireFormat)
+}
+
+func shouldEncodePayloadAsJsonByDefault(t *testing.T) {
+	// given
+	ctx := context.Background()
+	envelope := NewEnvelope(ctx, "item-added-on-shelf", Source("shelf"))
+
+	// when
+	message, err := EncodeMessage(ctx, ItemKey("hamburger"), envelope, []itemAdded{{ItemName: "hamburger", Quantity: 2}})
+
+	// then
+	assert.NoError(t, err)
+	assert.JSONEq(t, `[{"itemName": "hamburger", "quantity": 2}]`, string(message.Value))
+	assert.Equal(t, JsonContentType, GetHeader(message, ContentTypeHeader))
+
+	// and
+	decoded := make([]itemAdded, 0)
+	assert.NoError(t, DecodePayload(ctx, message, &decoded))
+	assert.Equal(t, []itemAdded{{ItemName: "hamburger", Quantity: 2}}, decoded)
+}
+
+func shouldEncodeAndDecodePayloadAsAvro(t *testing.T) {
+	// given
+	ctx := context.Background()
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+
+	givenCodecInUse(t, NewAvroCodec(NewSchemaRegistryClient(registry.URL)).WithSchema("item-added-on-shelf", itemAddedSchema), "item-added-on-shelf")
+	envelope := NewEnvelope(ctx, "item-added-on-shelf", Source("shelf"))
+
+	// when
+	message, err := EncodeMessage(ctx, ItemKey("hamburger"), envelope, []itemAdded{{ItemName: "hamburger", Quantity: 2}})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, AvroContentType, GetHeader(message, ContentTypeHeader))
+
+	// and a consumer knowing only the registry reads it
+	givenCodecInUse(t, NewAvroCodec(NewSchemaRegistryClient(registry.URL)))
+	decoded := make([]itemAdded, 0)
+	assert.NoError(t, DecodePayload(ctx, message, &decoded))
+	assert.Equal(t, []itemAdded{{ItemName: "hamburger", Quantity: 2}}, decoded)
+}
+
+func shouldRegisterSchemaOfEventTypeOnce(t *testing.T) {
+	// given
+	ctx := context.Background()
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+
+	client := NewSchemaRegistryClient(registry.URL)
+	codec := NewAvroCodec(client).WithSchema("item-added-on-shelf", itemAddedSchema)
+
+	// when
+	first, err := codec.Encode(ctx, "item-added-on-shelf", []itemAdded{{ItemName: "hamburger", Quantity: 1}})
+	assert.NoError(t, err)
+	second, err := codec.Encode(ctx, "item-added-on-shelf", []itemAdded{{ItemName: "cheeseburger", Quantity: 3}})
+	assert.NoError(t, err)
+
+	// then
+	assert.Equal(t, 1, registry.Registered())
+	assert.Equal(t, first[:5], second[:5])
+
+	// and
+	schema, err := client.SchemaById(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, Schema{Type: AvroSchema, Definition: itemAddedSchema}, schema)
+}
+
+func shouldFailToPublishPayloadWithIncompatibleSchema(t *testing.T) {
+	// given
+	ctx := context.Background()
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+
+	_, err := NewAvroCodec(NewSchemaRegistryClient(registry.URL)).WithSchema("item-added-on-shelf", itemAddedSchema).
+		Encode(ctx, "item-added-on-shelf", []itemAdded{{ItemName: "hamburger", Quantity: 1}})
+	assert.NoError(t, err)
+
+	type renamedItemAdded struct {
+		Name     string `avro:"name"`
+		Quantity int    `avro:"quantity"`
+	}
+	client := NewSchemaRegistryClient(registry.URL)
+	givenCodecInUse(t, NewAvroCodec(client).WithSchema("item-added-on-shelf", changedItemAddedSchema), "item-added-on-shelf")
+	envelope := NewEnvelope(ctx, "item-added-on-shelf", Source("shelf"))
+
+	// when
+	_, err = EncodeMessage(ctx, ItemKey("hamburger"), envelope, []renamedItemAdded{{Name: "hamburger", Quantity: 1}})
+
+	// then
+	assert.ErrorIs(t, err, ErrIncompatibleSchema)
+	assert.Equal(t, 1, registry.Registered())
+
+	// and
+	compatible, err := client.IsCompatible(ctx, "item-added-on-shelf", Schema{Type: AvroSchema, Definition: changedItemAddedSchema})
+	assert.NoError(t, err)
+	assert.False(t, compatible)
+}
+
+func shouldFailToEncodePayloadNotMatchingSchema(t *testing.T) {
+	// given
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+
+	codec := NewAvroCodec(NewSchemaRegistryClient(registry.URL)).WithSchema("item-added-on-shelf", itemAddedSchema)
+
+	// when
+	_, err := codec.Encode(context.Background(), "item-added-on-shelf", map[string]string{"status": "READY"})
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 0, registry.Registered())
+}
+
+func shouldEncodeAndDecodePayloadAsProtobuf(t *testing.T) {
+	// given
+	ctx := context.Background()
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+
+	codec := NewProtobufCodec(NewSchemaRegistryClient(registry.URL)).WithSchema("order-status-updated", stringValueDefinition)
+	givenCodecInUse(t, codec, "order-status-updated")
+	envelope := NewEnvelope(ctx, "order-status-updated", Source("order")).ForOrder(1010)
+
+	// when
+	message, err := EncodeMessage(ctx, OrderKey(1010), envelope, wrapperspb.String("READY"))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, ProtobufContentType, GetHeader(message, ContentTypeHeader))
+	assert.Equal(t, 1, registry.Registered())
+
+	// and
+	decoded := &wrapperspb.StringValue{}
+	assert.NoError(t, DecodePayload(ctx, message, decoded))
+	assert.Equal(t, "READY", decoded.GetValue())
+}
+
+func shouldFailToDecodePayloadNotInWireFormat(t *testing.T) {
+	// given
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+
+	givenCodecInUse(t, NewAvroCodec(NewSchemaRegistryClient(registry.URL)))
+	message := kafka.Message{
+		Value:   []byte(`[{"itemName": "hamburger", "quantity": 2}]`),
+		Headers: []kafka.Header{{Key: ContentTypeHeader, Value: []byte(AvroContentType)}},
+	}
+
+	// when
+	err := DecodePayload(context.Background(), message, &[]itemAdded{})
+
+	// then
+	assert.ErrorIs(t, err, ErrInvalidWireFormat)
+	assert.ErrorIs(t, err, ErrMalformedMessage)
+}
+
+// givenCodecInUse configures the codec for the test, restoring the codecs used before once the test finished.
+func givenCodecInUse(t *testing.T, codec Codec, eventTypes ...string) {
+	codecsMu.Lock()
+	previousEventCodecs := copyCodecs(eventCodecs)
+	previousContentCodecs := copyCodecs(contentCodecs)
+	codecsMu.Unlock()
+
+	t.Cleanup(func() {
+		codecsMu.Lock()
+		defer codecsMu.Unlock()
+		eventCodecs = previousEventCodecs
+		contentCodecs = previousContentCodecs
+	})
+	UseCodec(codec, eventTypes...)
+}
+
+func copyCodecs(codecs map[string]Codec) map[string]Codec {
+	copied := make(map[string]Codec, len(codecs))
+	for key, codec := range codecs {
+		copied[key] = codec
+	}
+	return copied
+}
@@ -0,0 +1,96 @@
+package event
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mc-burger-orders/log"
+	"mc-burger-orders/middleware"
+	"mc-burger-orders/testing/utils"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultDeadLettersLimit = 100
+	maxDeadLettersLimit     = 1000
+)
+
+type DeadLetterEndpoints struct {
+	queues map[string]DeadLetters
+}
+
+func NewDeadLetterEndpoints(queues ...DeadLetters) middleware.EndpointsSetup {
+	byTopic := make(map[string]DeadLetters)
+	for _, queue := range queues {
+		byTopic[queue.SourceTopic()] = queue
+	}
+	return &DeadLetterEndpoints{queues: byTopic}
+}
+
+func (e *DeadLetterEndpoints) Setup(r *gin.Engine) {
+	r.GET("/admin/dlq/:topic", e.listDeadLettersHandler)
+	r.POST("/admin/dlq/:topic/:partition/:offset/redrive", e.redriveDeadLetterHandler)
+}
+
+func (e *DeadLetterEndpoints) listDeadLettersHandler(c *gin.Context) {
+	queue, ok := e.queue(c)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeadLettersLimit
+	if value := c.Query("limit"); len(value) > 0 {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 || parsed > maxDeadLettersLimit {
+			errMessage := fmt.Sprintf("limit needs to be a number between 1 and %d", maxDeadLettersLimit)
+			c.JSON(http.StatusBadRequest, utils.ErrorPayload(errMessage))
+			return
+		}
+		limit = parsed
+	}
+
+	deadLetters, err := queue.List(c, limit)
+	if err != nil {
+		log.Error.Println("failed to list dead letters of topic", queue.SourceTopic(), err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorPayload(err.Error()))
+		return
+	}
+	c.JSON(http.StatusOK, deadLetters)
+}
+
+func (e *DeadLetterEndpoints) redriveDeadLetterHandler(c *gin.Context) {
+	queue, ok := e.queue(c)
+	if !ok {
+		return
+	}
+
+	partition, partitionErr := strconv.Atoi(c.Param("partition"))
+	offset, offsetErr := strconv.ParseInt(c.Param("offset"), 10, 64)
+	if partitionErr != nil || offsetErr != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload("partition and offset need to be numbers"))
+		return
+	}
+
+	err := queue.Redrive(c, partition, offset)
+	if errors.Is(err, ErrDeadLetterNotFound) {
+		errMessage := fmt.Sprintf("dead letter %d/%d of topic %v does not exist", partition, offset, queue.SourceTopic())
+		c.JSON(http.StatusNotFound, utils.ErrorPayload(errMessage))
+		return
+	}
+	if err != nil {
+		log.Error.Println("failed to re-drive dead letter of topic", queue.SourceTopic(), err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorPayload(err.Error()))
+		return
+	}
+	c.Status(http.StatusAccepted)
+}
+
+func (e *DeadLetterEndpoints) queue(c *gin.Context) (DeadLetters, bool) {
+	topic := c.Param("topic")
+	queue, ok := e.queues[topic]
+	if !ok {
+		c.JSON(http.StatusNotFound, utils.ErrorPayload(fmt.Sprintf("topic %v has no dead-letter queue", topic)))
+	}
+	return queue, ok
+}
@@ -0,0 +1,115 @@
+package event
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type StubDeadLetters struct {
+	deadLetters []DeadLetter
+	redriven    []int64
+	listLimit   int
+}
+
+func (s *StubDeadLetters) SourceTopic() string {
+	return "shelf-events"
+}
+
+func (s *StubDeadLetters) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	s.listLimit = limit
+	return s.deadLetters, nil
+}
+
+func (s *StubDeadLetters) Redrive(ctx context.Context, partition int, offset int64) error {
+	for _, deadLetter := range s.deadLetters {
+		if deadLetter.Partition == partition && deadLetter.Offset == offset {
+			s.redriven = append(s.redriven, offset)
+			return nil
+		}
+	}
+	return ErrDeadLetterNotFound
+}
+
+func givenDeadLetterEndpoints(queue DeadLetters) *gin.Engine {
+	engine := gin.Default()
+	NewDeadLetterEndpoints(queue).Setup(engine)
+	return engine
+}
+
+func TestDeadLetterEndpoints(t *testing.T) {
+	t.Run("should list dead letters of topic", shouldListDeadLettersOfTopic)
+	t.Run("should re-drive dead letter to source topic", shouldRedriveDeadLetterToSourceTopic)
+	t.Run("should return NOT FOUND when dead letter does not exist", shouldReturnNotFoundWhenDeadLetterDoesNotExist)
+	t.Run("should return NOT FOUND when topic has no dead-letter queue", shouldReturnNotFoundWhenTopicHasNoDeadLetterQueue)
+}
+
+func shouldListDeadLettersOfTopic(t *testing.T) {
+	// given
+	queue := &StubDeadLetters{deadLetters: []DeadLetter{{Partition: 0, Offset: 4, Key: "1010", FailureReason: "failed", Attempts: 3}}}
+	engine := givenDeadLetterEndpoints(queue)
+
+	req, _ := http.NewRequest("GET", "/admin/dlq/shelf-events?limit=10", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, 10, queue.listLimit)
+
+	var deadLetters []DeadLetter
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &deadLetters))
+	assert.Equal(t, queue.deadLetters, deadLetters)
+}
+
+func shouldRedriveDeadLetterToSourceTopic(t *testing.T) {
+	// given
+	queue := &StubDeadLetters{deadLetters: []DeadLetter{{Partition: 0, Offset: 4}}}
+	engine := givenDeadLetterEndpoints(queue)
+
+	req, _ := http.NewRequest("POST", "/admin/dlq/shelf-events/0/4/redrive", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Equal(t, []int64{4}, queue.redriven)
+}
+
+func shouldReturnNotFoundWhenDeadLetterDoesNotExist(t *testing.T) {
+	// given
+	queue := &StubDeadLetters{}
+	engine := givenDeadLetterEndpoints(queue)
+
+	req, _ := http.NewRequest("POST", "/admin/dlq/shelf-events/0/4/redrive", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+	assert.Empty(t, queue.redriven)
+}
+
+func shouldReturnNotFoundWhenTopicHasNoDeadLetterQueue(t *testing.T) {
+	// given
+	engine := givenDeadLetterEndpoints(&StubDeadLetters{})
+
+	req, _ := http.NewRequest("GET", "/admin/dlq/kitchen-requests", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
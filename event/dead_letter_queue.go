@@ -0,0 +1,110 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/log"
+	"strconv"
+)
+
+var ErrDeadLetterNotFound = errors.New("dead letter does not exist")
+
+type DeadLetter struct {
+	Partition     int               `json:"partition"`
+	Offset        int64             `json:"offset"`
+	Key           string            `json:"key"`
+	Value         string            `json:"value"`
+	SourceTopic   string            `json:"sourceTopic"`
+	FailureReason string            `json:"failureReason"`
+	Attempts      int               `json:"attempts"`
+	FailedAt      string            `json:"failedAt"`
+	Headers       map[string]string `json:"headers"`
+}
+
+type DeadLetters interface {
+	SourceTopic() string
+	List(ctx context.Context, limit int) ([]DeadLetter, error)
+	Redrive(ctx context.Context, partition int, offset int64) error
+}
+
+// DeadLetterQueue reads the DLQ of a topic, messages are re-driven by sending them back to the source topic with
+// the retry attempts reset. Kafka does not delete re-driven messages, they stay listed in the DLQ.
+type DeadLetterQueue struct {
+	source       *TopicConfigs
+	deadLetters  *TopicConfigs
+	sourceWriter Writer
+}
+
+func NewDeadLetterQueue(source *TopicConfigs) *DeadLetterQueue {
+	deadLetters := source.DeadLetterTopic()
+	deadLetters.transport().CreateTopic(deadLetters)
+
+	return &DeadLetterQueue{source: source, deadLetters: deadLetters, sourceWriter: NewTopicWriter(source)}
+}
+
+func (q *DeadLetterQueue) SourceTopic() string {
+	return q.source.Topic
+}
+
+func (q *DeadLetterQueue) List(ctx context.Context, limit int) ([]DeadLetter, error) {
+	deadLetters := make([]DeadLetter, 0)
+	for partition := 0; partition < q.deadLetters.NumPartitions && len(deadLetters) < limit; partition++ {
+		messages, err := q.readPartition(ctx, partition, -1, limit-len(deadLetters))
+		if err != nil {
+			return nil, err
+		}
+		for _, message := range messages {
+			deadLetters = append(deadLetters, toDeadLetter(message))
+		}
+	}
+	return deadLetters, nil
+}
+
+func (q *DeadLetterQueue) Redrive(ctx context.Context, partition int, offset int64) error {
+	if partition < 0 || partition >= q.deadLetters.NumPartitions {
+		return ErrDeadLetterNotFound
+	}
+
+	messages, err := q.readPartition(ctx, partition, offset, 1)
+	if err != nil {
+		return err
+	}
+	if len(messages) == 0 || messages[0].Offset != offset {
+		return ErrDeadLetterNotFound
+	}
+
+	message := messages[0]
+	log.Warning.Printf("Re-driving dead letter [%v] %d/%d back to topic %v", string(message.Key), partition, offset, q.source.Topic)
+	redriven := kafka.Message{Key: message.Key, Value: message.Value, Headers: withoutFailureHeaders(message.Headers)}
+	return q.sourceWriter.SendMessage(ctx, redriven)
+}
+
+func (q *DeadLetterQueue) readPartition(ctx context.Context, partition int, offset int64, limit int) ([]kafka.Message, error) {
+	messages, err := q.deadLetters.transport().ReadPartition(ctx, q.deadLetters, partition, offset, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read dead-letter queue %v. Reason: %w", q.deadLetters.Topic, err)
+	}
+	return messages, nil
+}
+
+func toDeadLetter(message kafka.Message) DeadLetter {
+	headers := make(map[string]string)
+	for _, header := range message.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+
+	attempts, _ := strconv.Atoi(headers[AttemptHeader])
+	return DeadLetter{
+		Partition:     message.Partition,
+		Offset:        message.Offset,
+		Key:           string(message.Key),
+		Value:         string(message.Value),
+		SourceTopic:   headers[SourceTopicHeader],
+		FailureReason: headers[FailureReasonHeader],
+		Attempts:      attempts,
+		FailedAt:      headers[FailedAtHeader],
+		Headers:       headers,
+	}
+}
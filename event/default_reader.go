@@ -2,44 +2,48 @@ package event
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/segmentio/kafka-go"
-	"mc-burger-orders/command"
+	"io"
 	"mc-burger-orders/log"
-	"mc-burger-orders/utils"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
-type NewMessageHandler interface {
-	OnNewMessage(message kafka.Message) error
-	HandleError(err error, message kafka.Message)
+type FailureHandler interface {
+	HandleError(ctx context.Context, err error, message kafka.Message) error
 }
 
+var ErrReaderClosed = errors.New("kafka reader is closed")
+
 type DefaultReader struct {
-	*kafka.Reader
+	consumer      TopicConsumer
+	closed        atomic.Bool
 	configuration *TopicConfigs
 	eventBus      EventBus
-	processRepeat map[string]int
+	failures      FailureHandler
+	retryReaders  []*DelayedRetryReader
+	// offsets commits messages processed out of order, it is set when messages are processed InFlight
+	offsets *offsetTracker
 }
 
 func NewTopicReader(configuration *TopicConfigs, eventBus EventBus) *DefaultReader {
 	log.Warning.Printf("Creating a new topicReader for topic: %v", configuration.Topic)
-	if len(configuration.Brokers) == 0 {
-		log.Error.Panicln("missing at least one Kafka Address")
-	}
+	consumer := configuration.transport().Consumer(configuration, groupID(configuration))
+	return &DefaultReader{consumer: consumer, configuration: configuration, eventBus: eventBus}
+}
 
-	conn := configuration.ConnectToBroker()
-	configuration.CreateTopic(conn)
+// EnableRetries sends messages that failed processing through delayed retry topics and finally to the
+// dead-letter queue of the topic, instead of only logging the failure.
+func (r *DefaultReader) EnableRetries(retryConfigs RetryConfigs) *DefaultReader {
+	r.failures = NewFailedMessageHandler(r.configuration, retryConfigs)
 
-	reader := kafka.NewReader(kafka.ReaderConfig{
-		Brokers:  configuration.Brokers,
-		Topic:    configuration.Topic,
-		MinBytes: 10e3, // 10KB
-		MaxBytes: 10e6, // 10MB
-		MaxWait:  configuration.WaitMaxTime,
-		GroupID:  groupID(configuration),
-	})
-	return &DefaultReader{reader, configuration, eventBus, make(map[string]int)}
+	for attempt := range retryConfigs.Delays {
+		r.retryReaders = append(r.retryReaders, NewDelayedRetryReader(r.configuration.RetryTopic(attempt+1), r))
+	}
+	return r
 }
 
 func (r *DefaultReader) GroupId() string {
@@ -47,91 +51,221 @@ func (r *DefaultReader) GroupId() string {
 }
 
 func groupID(configuration *TopicConfigs) string {
-	return fmt.Sprintf("%v-%d", configuration.Topic, configuration.Partition)
+	consumerGroup := configuration.ConsumerGroup
+	if len(consumerGroup) == 0 {
+		consumerGroup = defaultConsumerGroup
+	}
+	return fmt.Sprintf("%v-%v", consumerGroup, configuration.Topic)
 }
 
 func (r *DefaultReader) TopicName() string {
 	return r.configuration.Topic
 }
 
+// SubscribeToTopic starts processing messages of the topic on the event bus of the reader. A reader without an event
+// bus passes the messages to msgChan instead. Offsets are committed only once a message was processed, or handed
+// over to msgChan, so messages in flight when the service stops are read again.
 func (r *DefaultReader) SubscribeToTopic(msgChan chan kafka.Message) {
-	go func() {
-		log.Info.Println("Subscribing to topic", r.configuration.Topic)
+	log.Info.Println("Subscribing to topic", r.configuration.Topic)
 
-		for {
-			r.ReadMessageFromTopic(context.Background(), msgChan)
-			time.Sleep(r.configuration.AwaitBetweenReadsTime)
+	for _, retryReader := range r.retryReaders {
+		go retryReader.SubscribeToTopic()
+	}
+
+	if r.eventBus == nil {
+		go r.ForwardMessages(context.Background(), msgChan)
+		return
+	}
+	go r.ProcessMessages(context.Background())
+}
+
+// ProcessMessages fetches messages until the reader is closed. Messages are spread among a limited number of workers
+// by their partition, so messages of a partition are processed and committed in the order they were written in.
+// Fetching waits while the worker of the partition is busy. Readers configured with InFlight process messages
+// without waiting for the messages before them instead.
+func (r *DefaultReader) ProcessMessages(ctx context.Context) {
+	if inFlight := r.configuration.Reader.InFlight; inFlight > 0 {
+		r.processInFlight(ctx, inFlight)
+		return
+	}
+
+	workers := make([]chan kafka.Message, r.configuration.Reader.withDefaults().Workers)
+	waitGroup := &sync.WaitGroup{}
+	for i := range workers {
+		workers[i] = make(chan kafka.Message)
+		waitGroup.Add(1)
+		go r.processPartitions(workers[i], waitGroup)
+	}
+
+	defer func() {
+		for _, worker := range workers {
+			close(worker)
 		}
+		waitGroup.Wait()
 	}()
 
-	go func() {
-		if r.eventBus != nil {
-			for newMessage := range msgChan {
-				go r.PublishEvent(newMessage)
-			}
+	for {
+		msg, ok := r.FetchMessageFromTopic(ctx)
+		if r.isClosed(ctx) {
+			return
 		}
-	}()
+		if ok {
+			workers[msg.Partition%len(workers)] <- msg
+		}
+	}
+}
+
+// processInFlight processes every message as soon as it is fetched, so a message taking long holds back neither the
+// messages after it in its partition nor other partitions. Fetching waits while inFlight messages are processed.
+func (r *DefaultReader) processInFlight(ctx context.Context, inFlight int) {
+	r.offsets = newOffsetTracker()
+	slots := make(chan struct{}, inFlight)
+	waitGroup := &sync.WaitGroup{}
+	defer waitGroup.Wait()
+
+	for {
+		slots <- struct{}{}
+		msg, ok := r.FetchMessageFromTopic(ctx)
+		if r.isClosed(ctx) {
+			return
+		}
+		if !ok {
+			<-slots
+			continue
+		}
+
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			r.process(msg)
+			<-slots
+		}()
+	}
+}
+
+// ForwardMessages fetches messages until the reader is closed and passes them to msgChan.
+func (r *DefaultReader) ForwardMessages(ctx context.Context, msgChan chan kafka.Message) {
+	for {
+		msg, ok := r.FetchMessageFromTopic(ctx)
+		if r.isClosed(ctx) {
+			return
+		}
+		if ok {
+			msgChan <- msg
+			r.commit(msg)
+		}
+	}
+}
+
+func (r *DefaultReader) processPartitions(messages chan kafka.Message, waitGroup *sync.WaitGroup) {
+	defer waitGroup.Done()
+
+	for msg := range messages {
+		r.process(msg)
+	}
+}
+
+func (r *DefaultReader) process(msg kafka.Message) {
+	if err := r.PublishEvent(msg); err != nil {
+		if err = r.HandleError(err, msg); err != nil {
+			return
+		}
+	}
+	r.commit(msg)
+}
+
+// commit does not use the context messages are fetched with, messages in flight are still committed on shut down.
+// Messages processed InFlight are committed once every message before them was processed.
+func (r *DefaultReader) commit(message kafka.Message) {
+	if r.offsets != nil {
+		var committable bool
+		if message, committable = r.offsets.processed(message); !committable {
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := r.consumer.CommitMessages(ctx, message); err != nil {
+		log.Error.Printf("failed to commit message [%v] from topic: %v. Reason: %v", string(message.Key), message.Topic, err)
+	}
+}
+
+func (r *DefaultReader) isClosed(ctx context.Context) bool {
+	return r.closed.Load() || ctx.Err() != nil
 }
 
 func (r *DefaultReader) Close() error {
-	return r.Reader.Close()
+	r.closed.Store(true)
+	for _, retryReader := range r.retryReaders {
+		if err := retryReader.Close(); err != nil {
+			log.Error.Println("failed to close retry reader", retryReader.configuration.Topic, err)
+		}
+	}
+	return r.consumer.Close()
 }
 
-func (r *DefaultReader) PublishEvent(message kafka.Message) {
-	// TODO: is Message Already Ran
-	commandResults := make(chan command.TypedResult)
-	r.eventBus.PublishEvent(message, commandResults)
+// PublishEvent returns once all commands of the message finished, with the errors of the failed ones.
+func (r *DefaultReader) PublishEvent(message kafka.Message) error {
+	results := r.eventBus.PublishEvent(context.Background(), message)
 
-	for commandResult := range commandResults {
+	for _, commandResult := range results {
 		if commandResult.Error != nil {
 			log.Error.Println("While executing command", commandResult.Type, "following error occurred", commandResult.Error.Error())
-			r.HandleError(commandResult.Error.Error(), message)
 		} else {
 			log.Info.Println("Command", commandResult.Type, "finished successfully, with result -", commandResult.Result)
 		}
 	}
+	return results.Err()
 }
 
-func (r *DefaultReader) ReadMessageFromTopic(ctx context.Context, msgChan chan kafka.Message) {
-	msg, err := r.ReadMessage(ctx)
+// FetchMessageFromTopic returns false for messages that are not processed, those are committed right away.
+func (r *DefaultReader) FetchMessageFromTopic(ctx context.Context) (kafka.Message, bool) {
+	msg, err := r.consumer.FetchMessage(ctx)
 	if err != nil {
-		log.Error.Println("failed to read message from topic:", r.configuration.Topic, "GroupID", r.GroupId(), err)
-		return
+		if !errors.Is(err, io.EOF) {
+			log.Error.Println("failed to read message from topic:", r.configuration.Topic, "GroupID", r.GroupId(), err)
+			time.Sleep(r.configuration.AwaitBetweenReadsTime)
+		}
+		return msg, false
+	}
+	if r.offsets != nil {
+		r.offsets.fetched(msg)
 	}
-	eventType, err := utils.GetEventType(msg)
+
+	eventType, err := GetEventType(msg)
 	if err != nil {
 		log.Error.Println("failed to read event type from message:", err)
-		return
+		r.commit(msg)
+		return msg, false
 	}
 
 	log.Warning.Printf("Received messaged for topic: %v, event: %v", msg.Topic, eventType)
-	if msg.Topic == r.configuration.Topic {
-		msgChan <- msg
-	}
+	return msg, true
 }
 
-func (r *DefaultReader) HandleError(err error, message kafka.Message) {
+// HandleError returns an error when the reader was closed before the failed message could be sent to the retry
+// topics, such message must not be committed.
+func (r *DefaultReader) HandleError(err error, message kafka.Message) error {
 	key := string(message.Key)
 	topic := message.Topic
 	log.Error.Printf("failed to process message [%v] from topic: %v on event bus: %v\n", key, topic, err.Error())
 
-	repeatCnt, ok := r.processRepeat[key]
-	if !ok {
-		repeatCnt = 0
-	}
-
-	if repeatCnt < 4 {
-		// TODO: Error handling -> repeat message with delay
-		log.Error.Printf("Message [%v] is going to be send back to topic to be repeater in processing\n", key)
-		r.processRepeat[key] = repeatCnt + 1
-		return
+	if r.failures == nil {
+		log.Error.Printf("Retries are not enabled for topic %v, message [%v] is dropped\n", topic, key)
+		return nil
 	}
 
-	if repeatCnt >= 4 {
-		log.Error.Printf("Message [%v] was already repeated %d. Going to be send message to dead-letter queue\n", key, repeatCnt)
-		return
+	// the offset of the message is committed after it was handed over, so it is retried until that succeeds
+	for !r.closed.Load() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		sendErr := r.failures.HandleError(ctx, err, message)
+		cancel()
+		if sendErr == nil {
+			return nil
+		}
+		log.Error.Printf("Message [%v] could not be sent to retry topic nor dead-letter queue, trying again: %v\n", key, sendErr)
+		time.Sleep(r.configuration.AwaitBetweenReadsTime)
 	}
-
-	// default error handling
-	log.Error.Println("default error handling when event could not be processed", err.Error())
+	return ErrReaderClosed
 }
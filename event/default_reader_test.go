@@ -103,3 +103,278 @@ func sendMessages(t *testing.T, msgId int) {
 		assert.Fail(t, "failed to send message to topic", kafkaConfig.Topic)
 	}
 }
+
+type StubFetcher struct {
+	mu        sync.Mutex
+	messages  []kafka.Message
+	committed []kafka.Message
+	cancel    context.CancelFunc
+	onCommit  func(message kafka.Message)
+}
+
+func (s *StubFetcher) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.messages) == 0 {
+		s.cancel()
+		return kafka.Message{}, ctx.Err()
+	}
+	message := s.messages[0]
+	s.messages = s.messages[1:]
+	return message, nil
+}
+
+func (s *StubFetcher) CommitMessages(ctx context.Context, messages ...kafka.Message) error {
+	for _, message := range messages {
+		if s.onCommit != nil {
+			s.onCommit(message)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.committed = append(s.committed, messages...)
+	return nil
+}
+
+func (s *StubFetcher) Close() error {
+	s.cancel()
+	return nil
+}
+
+func (s *StubFetcher) GetCommitted() []kafka.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]kafka.Message{}, s.committed...)
+}
+
+type RecordingCommand struct {
+	mu         sync.Mutex
+	running    int
+	maxRunning int
+	processed  map[int][]int64
+	failing    map[int64]bool
+	delays     map[int64]time.Duration
+}
+
+func (c *RecordingCommand) Execute(ctx context.Context, message kafka.Message, result chan command.TypedResult) {
+	c.mu.Lock()
+	c.running++
+	c.maxRunning = max(c.maxRunning, c.running)
+	delay := c.delays[message.Offset]
+	c.mu.Unlock()
+
+	time.Sleep(5*time.Millisecond + delay)
+
+	c.mu.Lock()
+	c.running--
+	c.processed[message.Partition] = append(c.processed[message.Partition], message.Offset)
+	fail := c.failing[message.Offset]
+	c.mu.Unlock()
+
+	if fail {
+		result <- command.NewErrorResult("RecordingCommand", fmt.Errorf("command failed"))
+		return
+	}
+	result <- command.NewSuccessfulResult("RecordingCommand")
+}
+
+func (c *RecordingCommand) WasProcessed(message kafka.Message) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, offset := range c.processed[message.Partition] {
+		if offset == message.Offset {
+			return true
+		}
+	}
+	return false
+}
+
+type StubFailureHandler struct {
+	mu       sync.Mutex
+	failures int
+	handled  []kafka.Message
+	onError  func()
+}
+
+func (s *StubFailureHandler) HandleError(ctx context.Context, err error, message kafka.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.onError != nil {
+		s.onError()
+	}
+	if s.failures > 0 {
+		s.failures--
+		return fmt.Errorf("retry topic unavailable")
+	}
+	s.handled = append(s.handled, message)
+	return nil
+}
+
+func givenReader(workers int, messages ...kafka.Message) (*DefaultReader, *StubFetcher, *RecordingCommand, context.Context) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fetcher := &StubFetcher{messages: messages, cancel: cancel}
+	recordingCommand := &RecordingCommand{processed: make(map[int][]int64), failing: make(map[int64]bool), delays: make(map[int64]time.Duration)}
+
+	commandHandler := command.NewCommandHandler()
+	commandHandler.AddCommands(eventType, recordingCommand)
+	eventBus := NewInternalEventBus()
+	eventBus.AddHandler(commandHandler)
+
+	sut := &DefaultReader{
+		consumer:      fetcher,
+		configuration: &TopicConfigs{Topic: topic, Reader: ReaderConfigs{Workers: workers}},
+		eventBus:      eventBus,
+	}
+	return sut, fetcher, recordingCommand, ctx
+}
+
+func givenPartitionMessage(partition int, offset int64) kafka.Message {
+	return kafka.Message{
+		Topic:     topic,
+		Partition: partition,
+		Offset:    offset,
+		Headers:   []kafka.Header{{Key: "event", Value: []byte(eventType)}},
+	}
+}
+
+func TestDefaultReader_ProcessMessages(t *testing.T) {
+	t.Run("should commit messages after their commands finished", shouldCommitMessagesAfterTheirCommandsFinished)
+	t.Run("should process messages of partition in order", shouldProcessMessagesOfPartitionInOrder)
+	t.Run("should not process more messages at once than workers", shouldNotProcessMoreMessagesAtOnceThanWorkers)
+	t.Run("should commit failed message once it was sent to retry topic", shouldCommitFailedMessageOnceItWasSentToRetryTopic)
+	t.Run("should not commit failed message when reader closed before it was sent to retry topic", shouldNotCommitFailedMessageWhenReaderClosedBeforeItWasSentToRetryTopic)
+	t.Run("should process messages in flight without waiting for messages before them", shouldProcessMessagesInFlightWithoutWaitingForMessagesBeforeThem)
+	t.Run("should not commit message in flight before messages before it were processed", shouldNotCommitMessageInFlightBeforeMessagesBeforeItWereProcessed)
+}
+
+func shouldCommitMessagesAfterTheirCommandsFinished(t *testing.T) {
+	// given
+	sut, fetcher, recordingCommand, ctx := givenReader(2, givenPartitionMessage(0, 1), givenPartitionMessage(1, 1), givenPartitionMessage(0, 2))
+	fetcher.onCommit = func(message kafka.Message) {
+		assert.True(t, recordingCommand.WasProcessed(message), "message committed before it was processed")
+	}
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.Len(t, fetcher.GetCommitted(), 3)
+}
+
+func shouldProcessMessagesOfPartitionInOrder(t *testing.T) {
+	// given
+	messages := make([]kafka.Message, 0)
+	for offset := int64(1); offset <= 5; offset++ {
+		messages = append(messages, givenPartitionMessage(0, offset), givenPartitionMessage(1, offset))
+	}
+	sut, fetcher, recordingCommand, ctx := givenReader(4, messages...)
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, recordingCommand.processed[0])
+	assert.Equal(t, []int64{1, 2, 3, 4, 5}, recordingCommand.processed[1])
+
+	// and
+	committedOffsets := map[int][]int64{}
+	for _, message := range fetcher.GetCommitted() {
+		committedOffsets[message.Partition] = append(committedOffsets[message.Partition], message.Offset)
+	}
+	assert.Equal(t, recordingCommand.processed, committedOffsets)
+}
+
+func shouldNotProcessMoreMessagesAtOnceThanWorkers(t *testing.T) {
+	// given
+	messages := make([]kafka.Message, 0)
+	for partition := 0; partition < 6; partition++ {
+		messages = append(messages, givenPartitionMessage(partition, 1), givenPartitionMessage(partition, 2))
+	}
+	sut, fetcher, recordingCommand, ctx := givenReader(2, messages...)
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.LessOrEqual(t, recordingCommand.maxRunning, 2)
+	assert.Len(t, fetcher.GetCommitted(), 12)
+}
+
+func shouldCommitFailedMessageOnceItWasSentToRetryTopic(t *testing.T) {
+	// given
+	sut, fetcher, recordingCommand, ctx := givenReader(1, givenPartitionMessage(0, 1))
+	recordingCommand.failing[1] = true
+	failures := &StubFailureHandler{failures: 1}
+	sut.failures = failures
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.Len(t, failures.handled, 1)
+	assert.Len(t, fetcher.GetCommitted(), 1)
+}
+
+func shouldNotCommitFailedMessageWhenReaderClosedBeforeItWasSentToRetryTopic(t *testing.T) {
+	// given
+	sut, fetcher, recordingCommand, ctx := givenReader(1, givenPartitionMessage(0, 1))
+	recordingCommand.failing[1] = true
+	sut.failures = &StubFailureHandler{failures: 10, onError: func() { sut.closed.Store(true) }}
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.Empty(t, fetcher.GetCommitted())
+}
+
+func shouldProcessMessagesInFlightWithoutWaitingForMessagesBeforeThem(t *testing.T) {
+	// given
+	sut, fetcher, recordingCommand, ctx := givenReader(1, givenPartitionMessage(0, 1), givenPartitionMessage(0, 2), givenPartitionMessage(0, 3))
+	sut.configuration.Reader.InFlight = 3
+	recordingCommand.delays[1] = 50 * time.Millisecond
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.Equal(t, []int64{2, 3, 1}, recordingCommand.processed[0])
+	assert.Equal(t, 3, recordingCommand.maxRunning)
+
+	// and
+	committed := fetcher.GetCommitted()
+	assert.Len(t, committed, 1)
+	assert.Equal(t, int64(3), committed[0].Offset)
+}
+
+func shouldNotCommitMessageInFlightBeforeMessagesBeforeItWereProcessed(t *testing.T) {
+	// given
+	messages := make([]kafka.Message, 0)
+	for offset := int64(1); offset <= 10; offset++ {
+		messages = append(messages, givenPartitionMessage(0, offset), givenPartitionMessage(1, offset))
+	}
+	sut, fetcher, recordingCommand, ctx := givenReader(1, messages...)
+	sut.configuration.Reader.InFlight = 4
+	for offset := int64(1); offset <= 10; offset += 3 {
+		recordingCommand.delays[offset] = 20 * time.Millisecond
+	}
+	fetcher.onCommit = func(message kafka.Message) {
+		for offset := int64(1); offset <= message.Offset; offset++ {
+			assert.True(t, recordingCommand.WasProcessed(givenPartitionMessage(message.Partition, offset)), "message committed before messages before it were processed")
+		}
+	}
+
+	// when
+	sut.ProcessMessages(ctx)
+
+	// then
+	assert.LessOrEqual(t, recordingCommand.maxRunning, 4)
+
+	// and
+	lastCommitted := map[int]int64{}
+	for _, message := range fetcher.GetCommitted() {
+		lastCommitted[message.Partition] = max(lastCommitted[message.Partition], message.Offset)
+	}
+	assert.Equal(t, map[int]int64{0: 10, 1: 10}, lastCommitted)
+}
@@ -5,73 +5,182 @@ import (
 	"errors"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/log"
+	"strings"
+	"sync"
 	"time"
 )
 
+const writeAttempts = 3
+
+var ErrWriterClosed = errors.New("kafka writer is closed")
+
 type Writer interface {
 	SendMessage(rootCtx context.Context, messages ...kafka.Message) error
 }
 
+// DeliveryReport is called with the messages of every send once the broker acknowledged them or sending failed.
+type DeliveryReport func(messages []kafka.Message, err error)
+
+// DefaultWriter is a long-lived producer shared by everyone writing to the topic. Messages sent concurrently are
+// written in batches, a batch is written as soon as it is full or once it waited for the configured linger time.
 type DefaultWriter struct {
-	conn          *kafka.Conn
+	key           writerKey
+	writer        TopicProducer
 	configuration *TopicConfigs
+	mu            sync.RWMutex
+	closed        bool
+	inFlight      sync.WaitGroup
+	reports       []DeliveryReport
 }
 
+// writerKey tells writers of topics with the same name apart when they are carried by different brokers
+type writerKey struct {
+	transport Transport
+	brokers   string
+	topic     string
+}
+
+var (
+	writersMu sync.Mutex
+	writers   = make(map[writerKey]*DefaultWriter)
+)
+
+// NewTopicWriter returns the writer of the topic. The broker is dialled and the topic created only for the first
+// writer of a topic, later calls get the same writer.
 func NewTopicWriter(configuration *TopicConfigs) *DefaultWriter {
-	if len(configuration.Brokers) == 0 {
-		log.Error.Panicln("missing at least one Kafka Address")
+	writersMu.Lock()
+	defer writersMu.Unlock()
+
+	transport := configuration.transport()
+	key := writerKey{transport: transport, brokers: strings.Join(configuration.Brokers, ","), topic: configuration.Topic}
+	if writer, exists := writers[key]; exists {
+		return writer
+	}
+
+	writer := newDefaultWriter(key, configuration, transport.Producer(configuration))
+	writers[key] = writer
+	return writer
+}
+
+func newDefaultWriter(key writerKey, configuration *TopicConfigs, writer TopicProducer) *DefaultWriter {
+	return &DefaultWriter{key: key, writer: writer, configuration: configuration, reports: make([]DeliveryReport, 0)}
+}
+
+// CloseWriters flushes and closes all topic writers, it is meant to be called when the service shuts down.
+func CloseWriters() {
+	writersMu.Lock()
+	toClose := make([]*DefaultWriter, 0)
+	for _, writer := range writers {
+		toClose = append(toClose, writer)
+	}
+	writersMu.Unlock()
+
+	for _, writer := range toClose {
+		if err := writer.Close(); err != nil {
+			log.Error.Println("failed to close writer of topic", writer.TopicName(), err)
+		}
 	}
-	conn := configuration.ConnectToBroker()
-	configuration.CreateTopic(conn)
-	return &DefaultWriter{conn: conn, configuration: configuration}
 }
 
 func (d *DefaultWriter) TopicName() string {
 	return d.configuration.Topic
 }
 
-func (d *DefaultWriter) initializeWriter() *kafka.Writer {
-	return &kafka.Writer{
-		Addr:                   kafka.TCP(d.configuration.Controller),
-		Topic:                  d.configuration.Topic,
-		Balancer:               &kafka.LeastBytes{},
-		RequiredAcks:           kafka.RequireOne,
-		AllowAutoTopicCreation: true,
-		ReadTimeout:            5 * time.Second,
-		WriteTimeout:           5 * time.Second,
-		MaxAttempts:            5,
-	}
+// OnDelivery registers a report called after every send of the writer, whether synchronous or not.
+func (d *DefaultWriter) OnDelivery(report DeliveryReport) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.reports = append(d.reports, report)
 }
 
+// SendMessage returns once the messages were acknowledged by the broker or could not be sent.
 func (d *DefaultWriter) SendMessage(rootCtx context.Context, messages ...kafka.Message) error {
-	writer := d.initializeWriter()
-	var err error
-	const retries = 4
-	for i := 1; i < retries; i++ {
-		ctx, cancel := context.WithTimeout(rootCtx, 10*time.Second)
-		defer cancel()
+	if !d.begin() {
+		d.report(messages, ErrWriterClosed)
+		return ErrWriterClosed
+	}
+	defer d.inFlight.Done()
 
-		// attempt to create Topic prior to publishing the message
-		err = writer.WriteMessages(ctx, messages...)
-		if errors.Is(err, kafka.LeaderNotAvailable) || errors.Is(err, context.DeadlineExceeded) {
-			log.Error.Println("Message(s) where not send successfully", err, ". Waiting 250 milliseconds and will attempt for the", i, "time")
+	err := d.write(rootCtx, messages)
+	d.report(messages, err)
+	return err
+}
 
-			time.Sleep(time.Millisecond * 250)
-			continue
-		}
+// SendMessageAsync returns right away, the outcome of sending the messages is passed to the delivery reports.
+func (d *DefaultWriter) SendMessageAsync(messages ...kafka.Message) {
+	if !d.begin() {
+		log.Error.Printf("failed to send %d message(s) to topic %v. Reason: %v", len(messages), d.TopicName(), ErrWriterClosed)
+		d.report(messages, ErrWriterClosed)
+		return
+	}
 
+	go func() {
+		defer d.inFlight.Done()
+
+		err := d.write(context.Background(), messages)
 		if err != nil {
-			log.Error.Println("unexpected error", err)
-			return err
+			log.Error.Printf("failed to send %d message(s) to topic %v. Reason: %v", len(messages), d.TopicName(), err)
 		}
+		d.report(messages, err)
+	}()
+}
+
+// Close waits for the messages being sent, flushes batches still buffered and releases the broker connections.
+// The next NewTopicWriter call for the topic creates a new writer.
+func (d *DefaultWriter) Close() error {
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return nil
+	}
+	d.closed = true
+	d.mu.Unlock()
+
+	d.inFlight.Wait()
 
-		log.Warning.Printf("Message(s) send successfully on attempt %d to topic: %v", i, d.configuration.Topic)
-		break
+	writersMu.Lock()
+	if writers[d.key] == d {
+		delete(writers, d.key)
 	}
+	writersMu.Unlock()
+
+	return d.writer.Close()
+}
 
-	if err := writer.Close(); err != nil {
-		log.Error.Println("failed to close writer:", err)
+func (d *DefaultWriter) begin() bool {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	if d.closed {
+		return false
+	}
+	d.inFlight.Add(1)
+	return true
+}
+
+func (d *DefaultWriter) write(rootCtx context.Context, messages []kafka.Message) error {
+	var err error
+	for attempt := 1; attempt <= writeAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(rootCtx, 10*time.Second)
+		err = d.writer.WriteMessages(ctx, messages...)
+		cancel()
+
+		// the topic may have just been created, its partitions have no leader elected yet
+		if (errors.Is(err, kafka.LeaderNotAvailable) || errors.Is(err, context.DeadlineExceeded)) && rootCtx.Err() == nil {
+			log.Error.Println("Message(s) where not send successfully", err, ". Waiting 250 milliseconds and will attempt for the", attempt+1, "time")
+			time.Sleep(time.Millisecond * 250)
+			continue
+		}
 		return err
 	}
-	return nil
+	return err
+}
+
+func (d *DefaultWriter) report(messages []kafka.Message, err error) {
+	d.mu.RLock()
+	reports := d.reports
+	d.mu.RUnlock()
+
+	for _, report := range reports {
+		report(messages, err)
+	}
 }
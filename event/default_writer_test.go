@@ -0,0 +1,146 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"sync"
+	"testing"
+	"time"
+)
+
+type StubMessageWriter struct {
+	mu       sync.Mutex
+	written  []kafka.Message
+	delay    time.Duration
+	failures []error
+	closed   bool
+}
+
+func (s *StubMessageWriter) WriteMessages(ctx context.Context, messages ...kafka.Message) error {
+	time.Sleep(s.delay)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.failures) > 0 {
+		err := s.failures[0]
+		s.failures = s.failures[1:]
+		return err
+	}
+	s.written = append(s.written, messages...)
+	return nil
+}
+
+func (s *StubMessageWriter) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closed = true
+	return nil
+}
+
+func (s *StubMessageWriter) GetWritten() []kafka.Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]kafka.Message{}, s.written...)
+}
+
+func givenWriter(messageWriter *StubMessageWriter) *DefaultWriter {
+	return newDefaultWriter(writerKey{topic: "shelf-events"}, &TopicConfigs{Topic: "shelf-events"}, messageWriter)
+}
+
+func TestDefaultWriter(t *testing.T) {
+	t.Run("should report delivery of sent messages", shouldReportDeliveryOfSentMessages)
+	t.Run("should retry when partition leader is not available yet", shouldRetryWhenPartitionLeaderIsNotAvailableYet)
+	t.Run("should report failure of asynchronously sent messages", shouldReportFailureOfAsynchronouslySentMessages)
+	t.Run("should flush messages being sent when closed", shouldFlushMessagesBeingSentWhenClosed)
+	t.Run("should not send messages when closed", shouldNotSendMessagesWhenClosed)
+}
+
+func shouldReportDeliveryOfSentMessages(t *testing.T) {
+	// given
+	messageWriter := &StubMessageWriter{}
+	sut := givenWriter(messageWriter)
+
+	reported := make([]kafka.Message, 0)
+	sut.OnDelivery(func(messages []kafka.Message, err error) {
+		assert.NoError(t, err)
+		reported = append(reported, messages...)
+	})
+
+	// when
+	err := sut.SendMessage(context.Background(), kafka.Message{Key: []byte("1")}, kafka.Message{Key: []byte("2")})
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, messageWriter.GetWritten(), 2)
+	assert.Equal(t, messageWriter.GetWritten(), reported)
+}
+
+func shouldRetryWhenPartitionLeaderIsNotAvailableYet(t *testing.T) {
+	// given
+	messageWriter := &StubMessageWriter{failures: []error{kafka.LeaderNotAvailable}}
+	sut := givenWriter(messageWriter)
+
+	// when
+	err := sut.SendMessage(context.Background(), kafka.Message{Key: []byte("1")})
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, messageWriter.GetWritten(), 1)
+}
+
+func shouldReportFailureOfAsynchronouslySentMessages(t *testing.T) {
+	// given
+	expectedErr := fmt.Errorf("broker is not available")
+	messageWriter := &StubMessageWriter{failures: []error{expectedErr}}
+	sut := givenWriter(messageWriter)
+
+	reports := make(chan error, 1)
+	sut.OnDelivery(func(messages []kafka.Message, err error) {
+		reports <- err
+	})
+
+	// when
+	sut.SendMessageAsync(kafka.Message{Key: []byte("1")})
+
+	// then
+	select {
+	case err := <-reports:
+		assert.Equal(t, expectedErr, err)
+	case <-time.After(time.Second):
+		assert.Fail(t, "delivery of message was not reported")
+	}
+	assert.Empty(t, messageWriter.GetWritten())
+}
+
+func shouldFlushMessagesBeingSentWhenClosed(t *testing.T) {
+	// given
+	messageWriter := &StubMessageWriter{delay: 100 * time.Millisecond}
+	sut := givenWriter(messageWriter)
+
+	sut.SendMessageAsync(kafka.Message{Key: []byte("1")})
+	sut.SendMessageAsync(kafka.Message{Key: []byte("2")})
+
+	// when
+	err := sut.Close()
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, messageWriter.GetWritten(), 2)
+	assert.True(t, messageWriter.closed)
+}
+
+func shouldNotSendMessagesWhenClosed(t *testing.T) {
+	// given
+	messageWriter := &StubMessageWriter{}
+	sut := givenWriter(messageWriter)
+	assert.NoError(t, sut.Close())
+
+	// when
+	err := sut.SendMessage(context.Background(), kafka.Message{Key: []byte("1")})
+
+	// then
+	assert.ErrorIs(t, err, ErrWriterClosed)
+	assert.Empty(t, messageWriter.GetWritten())
+}
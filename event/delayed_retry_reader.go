@@ -0,0 +1,94 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"mc-burger-orders/log"
+	"sync/atomic"
+	"time"
+)
+
+// DelayedRetryReader waits until messages of a retry topic are due and processes them on the event bus of the reader
+// that failed them. Other consumer groups of the source topic already processed the message, so it is neither sent
+// back to the source topic nor processed by their retry readers. All messages of one retry topic have the same delay,
+// so they become due in the order they were written in.
+type DelayedRetryReader struct {
+	consumer      TopicConsumer
+	closed        atomic.Bool
+	configuration *TopicConfigs
+	source        *DefaultReader
+}
+
+func NewDelayedRetryReader(configuration *TopicConfigs, source *DefaultReader) *DelayedRetryReader {
+	log.Warning.Printf("Creating a new retry topicReader for topic: %v", configuration.Topic)
+	consumer := configuration.transport().Consumer(configuration, groupID(configuration))
+	return &DelayedRetryReader{consumer: consumer, configuration: configuration, source: source}
+}
+
+func (r *DelayedRetryReader) SubscribeToTopic() {
+	log.Info.Println("Subscribing to retry topic", r.configuration.Topic)
+	for !r.closed.Load() {
+		r.ProcessDueMessage(context.Background())
+	}
+}
+
+func (r *DelayedRetryReader) Close() error {
+	r.closed.Store(true)
+	return r.consumer.Close()
+}
+
+// ProcessDueMessage processes the next message failed by the consumer group of the source reader once it is due,
+// a message failing again is sent to the next retry topic. Messages failed by other consumer groups are skipped.
+func (r *DelayedRetryReader) ProcessDueMessage(ctx context.Context) {
+	msg, err := r.consumer.FetchMessage(ctx)
+	if errors.Is(err, io.EOF) {
+		return
+	}
+	if err != nil {
+		log.Error.Println("failed to read message from retry topic:", r.configuration.Topic, err)
+		time.Sleep(r.configuration.AwaitBetweenReadsTime)
+		return
+	}
+
+	if failedGroup := GetHeader(msg, ConsumerGroupHeader); failedGroup != r.source.GroupId() {
+		r.commit(ctx, msg)
+		return
+	}
+
+	waitUntilDue(msg)
+	if err = r.source.PublishEvent(retriedMessage(msg)); err != nil {
+		if err = r.source.HandleError(err, msg); err != nil {
+			return
+		}
+	}
+	r.commit(ctx, msg)
+}
+
+func (r *DelayedRetryReader) commit(ctx context.Context, message kafka.Message) {
+	if err := r.consumer.CommitMessages(ctx, message); err != nil {
+		log.Error.Println("failed to commit message from retry topic:", r.configuration.Topic, err)
+	}
+}
+
+// retriedMessage is processed as the message of the source topic, so its outcome is recorded for the original message.
+func retriedMessage(message kafka.Message) kafka.Message {
+	retried := message
+	if sourceTopic := GetHeader(message, SourceTopicHeader); len(sourceTopic) > 0 {
+		retried.Topic = sourceTopic
+	}
+	return retried
+}
+
+func waitUntilDue(message kafka.Message) {
+	retryAt, err := time.Parse(time.RFC3339Nano, GetHeader(message, RetryAtHeader))
+	if err != nil {
+		return
+	}
+
+	if delay := time.Until(retryAt); delay > 0 {
+		log.Info.Printf("Message [%v] is going to be retried in %v", string(message.Key), delay)
+		time.Sleep(delay)
+	}
+}
@@ -0,0 +1,171 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"strconv"
+	"time"
+)
+
+// The envelope of an event is carried in message headers following the Kafka binding of CloudEvents, the payload is
+// the value of the message. Correlation id, data version and order number are extension attributes.
+const (
+	EventIdHeader       = "ce_id"
+	SourceHeader        = "ce_source"
+	EventTypeHeader     = "ce_type"
+	TimeHeader          = "ce_time"
+	SpecVersionHeader   = "ce_specversion"
+	CorrelationIdHeader = "ce_correlationid"
+	DataVersionHeader   = "ce_dataversion"
+	OrderNumberHeader   = "ce_ordernumber"
+	ContentTypeHeader   = "content-type"
+
+	SpecVersion        = "1.0"
+	DefaultDataVersion = "1"
+	JsonContentType    = "application/json"
+)
+
+// headers of messages sent before the envelope was introduced, still read from retried and dead-lettered messages
+const (
+	legacyEventTypeHeader   = "event"
+	legacyEventIdHeader     = "event-id"
+	legacyOrderNumberHeader = "order"
+)
+
+const sourcePrefix = "/mc-burger-orders/"
+
+type Envelope struct {
+	Id          string
+	Type        string
+	Source      string
+	Time        time.Time
+	SpecVersion string
+	// CorrelationId is shared by all events caused by the same event, the first event of a chain uses its own id
+	CorrelationId string
+	// DataVersion is the version of the payload schema of the event type
+	DataVersion string
+	ContentType string
+	OrderNumber *int64
+}
+
+type envelopeKey struct{}
+
+// Source names the part of the service producing events, e.g. `shelf`.
+func Source(producer string) string {
+	return sourcePrefix + producer
+}
+
+// NewEnvelope creates the envelope of a new event. When ctx carries the envelope of the message being processed, the
+// new event shares its correlation id.
+func NewEnvelope(ctx context.Context, eventType string, source string) Envelope {
+	envelope := Envelope{
+		Id:          newEventId(),
+		Type:        eventType,
+		Source:      source,
+		Time:        time.Now().UTC(),
+		SpecVersion: SpecVersion,
+		DataVersion: DefaultDataVersion,
+		ContentType: JsonContentType,
+	}
+
+	envelope.CorrelationId = envelope.Id
+	if cause, ok := EnvelopeFromContext(ctx); ok && len(cause.CorrelationId) > 0 {
+		envelope.CorrelationId = cause.CorrelationId
+	}
+	return envelope
+}
+
+func (e Envelope) ForOrder(orderNumber int64) Envelope {
+	e.OrderNumber = &orderNumber
+	return e
+}
+
+func (e Envelope) WithDataVersion(dataVersion string) Envelope {
+	e.DataVersion = dataVersion
+	return e
+}
+
+func (e Envelope) Headers() []kafka.Header {
+	headers := []kafka.Header{
+		{Key: EventIdHeader, Value: []byte(e.Id)},
+		{Key: SourceHeader, Value: []byte(e.Source)},
+		{Key: EventTypeHeader, Value: []byte(e.Type)},
+		{Key: TimeHeader, Value: []byte(e.Time.Format(time.RFC3339Nano))},
+		{Key: SpecVersionHeader, Value: []byte(e.SpecVersion)},
+		{Key: CorrelationIdHeader, Value: []byte(e.CorrelationId)},
+		{Key: DataVersionHeader, Value: []byte(e.DataVersion)},
+		{Key: ContentTypeHeader, Value: []byte(e.ContentType)},
+	}
+	if e.OrderNumber != nil {
+		headers = append(headers, kafka.Header{Key: OrderNumberHeader, Value: []byte(strconv.FormatInt(*e.OrderNumber, 10))})
+	}
+	return headers
+}
+
+// ReadEnvelope reads the envelope from the headers of the message, only the event type is required.
+func ReadEnvelope(message kafka.Message) (Envelope, error) {
+	eventType, err := GetEventType(message)
+	if err != nil {
+		return Envelope{}, err
+	}
+
+	envelope := Envelope{
+		Id:            GetEventId(message),
+		Type:          eventType,
+		Source:        GetHeader(message, SourceHeader),
+		SpecVersion:   GetHeader(message, SpecVersionHeader),
+		CorrelationId: GetHeader(message, CorrelationIdHeader),
+		DataVersion:   GetHeader(message, DataVersionHeader),
+		ContentType:   GetHeader(message, ContentTypeHeader),
+	}
+	if occurredAt, err := time.Parse(time.RFC3339Nano, GetHeader(message, TimeHeader)); err == nil {
+		envelope.Time = occurredAt
+	}
+	if orderNumber, err := GetOrderNumber(message); err == nil {
+		envelope.OrderNumber = &orderNumber
+	}
+	return envelope, nil
+}
+
+func GetEventType(message kafka.Message) (string, error) {
+	if eventType := headerOrLegacy(message, EventTypeHeader, legacyEventTypeHeader); len(eventType) > 0 {
+		return eventType, nil
+	}
+	return "", fmt.Errorf("could not find event type header in message")
+}
+
+func GetEventId(message kafka.Message) string {
+	return headerOrLegacy(message, EventIdHeader, legacyEventIdHeader)
+}
+
+func GetOrderNumber(message kafka.Message) (int64, error) {
+	orderNumberStr := headerOrLegacy(message, OrderNumberHeader, legacyOrderNumberHeader)
+	if len(orderNumberStr) == 0 {
+		return -1, fmt.Errorf("cannot find order number in message headers")
+	}
+
+	orderNumber, err := strconv.ParseInt(orderNumberStr, 10, 64)
+	if err != nil {
+		return -1, fmt.Errorf("cannot parse order number '%v' to int64. %v", orderNumberStr, err)
+	}
+	return orderNumber, nil
+}
+
+// ContextWithEnvelope passes the envelope of the message being processed to the commands, so events they produce
+// are correlated with it.
+func ContextWithEnvelope(ctx context.Context, envelope Envelope) context.Context {
+	return context.WithValue(ctx, envelopeKey{}, envelope)
+}
+
+func EnvelopeFromContext(ctx context.Context) (Envelope, bool) {
+	envelope, ok := ctx.Value(envelopeKey{}).(Envelope)
+	return envelope, ok
+}
+
+func headerOrLegacy(message kafka.Message, header string, legacyHeader string) string {
+	if value := GetHeader(message, header); len(value) > 0 {
+		return value
+	}
+	return GetHeader(message, legacyHeader)
+}
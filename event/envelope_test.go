@@ -0,0 +1,89 @@
+package event
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestEnvelope(t *testing.T) {
+	t.Run("should read envelope written to message headers", shouldReadEnvelopeWrittenToMessageHeaders)
+	t.Run("should start new correlation when not caused by other event", shouldStartNewCorrelationWhenNotCausedByOtherEvent)
+	t.Run("should share correlation id of event being processed", shouldShareCorrelationIdOfEventBeingProcessed)
+	t.Run("should read envelope of message sent with legacy headers", shouldReadEnvelopeOfMessageSentWithLegacyHeaders)
+	t.Run("should fail to read envelope without event type", shouldFailToReadEnvelopeWithoutEventType)
+}
+
+func shouldReadEnvelopeWrittenToMessageHeaders(t *testing.T) {
+	// given
+	envelope := NewEnvelope(context.Background(), "order-updated", Source("order")).ForOrder(1010).WithDataVersion("2")
+
+	// when
+	read, err := ReadEnvelope(NewMessage(OrderKey(1010), []byte("{}"), envelope))
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, envelope.Id, read.Id)
+	assert.Equal(t, "order-updated", read.Type)
+	assert.Equal(t, "/mc-burger-orders/order", read.Source)
+	assert.Equal(t, SpecVersion, read.SpecVersion)
+	assert.Equal(t, "2", read.DataVersion)
+	assert.Equal(t, JsonContentType, read.ContentType)
+	assert.Equal(t, int64(1010), *read.OrderNumber)
+	assert.WithinDuration(t, time.Now(), read.Time, time.Second)
+}
+
+func shouldStartNewCorrelationWhenNotCausedByOtherEvent(t *testing.T) {
+	// when
+	envelope := NewEnvelope(context.Background(), "check-favorites-on-shelf", Source("schedule"))
+
+	// then
+	assert.NotEmpty(t, envelope.Id)
+	assert.Equal(t, envelope.Id, envelope.CorrelationId)
+	assert.Nil(t, envelope.OrderNumber)
+}
+
+func shouldShareCorrelationIdOfEventBeingProcessed(t *testing.T) {
+	// given
+	cause := NewEnvelope(context.Background(), "item-added-on-shelf", Source("shelf"))
+	ctx := ContextWithEnvelope(context.Background(), cause)
+
+	// when
+	envelope := NewEnvelope(ctx, "order-updated", Source("order"))
+
+	// then
+	assert.NotEqual(t, cause.Id, envelope.Id)
+	assert.Equal(t, cause.CorrelationId, envelope.CorrelationId)
+}
+
+func shouldReadEnvelopeOfMessageSentWithLegacyHeaders(t *testing.T) {
+	// given
+	message := kafka.Message{Headers: []kafka.Header{
+		{Key: "event", Value: []byte("order-status-updated")},
+		{Key: "event-id", Value: []byte("event-1")},
+		{Key: "order", Value: []byte("1010")},
+	}}
+
+	// when
+	envelope, err := ReadEnvelope(message)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "order-status-updated", envelope.Type)
+	assert.Equal(t, "event-1", envelope.Id)
+	assert.Equal(t, int64(1010), *envelope.OrderNumber)
+	assert.Empty(t, envelope.CorrelationId)
+}
+
+func shouldFailToReadEnvelopeWithoutEventType(t *testing.T) {
+	// given
+	message := kafka.Message{Headers: []kafka.Header{{Key: OrderNumberHeader, Value: []byte("1010")}}}
+
+	// when
+	_, err := ReadEnvelope(message)
+
+	// then
+	assert.Error(t, err)
+}
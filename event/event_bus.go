@@ -1,12 +1,15 @@
 package event
 
 import (
+	"context"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
 )
 
 type EventBus interface {
-	PublishEvent(message kafka.Message, commandsResult chan command.TypedResult)
+	// PublishEvent returns the results of all commands run for the message once every handler finished, the handlers
+	// get the values of ctx.
+	PublishEvent(ctx context.Context, message kafka.Message) command.Results
 
 	AddHandler(command.Handler)
 }
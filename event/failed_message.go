@@ -0,0 +1,113 @@
+package event
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/log"
+	"strconv"
+	"time"
+)
+
+const (
+	SourceTopicHeader   = "source-topic"
+	ConsumerGroupHeader = "consumer-group"
+	AttemptHeader       = "retry-attempt"
+	FailureReasonHeader = "failure-reason"
+	FailedAtHeader      = "failed-at"
+	RetryAtHeader       = "retry-at"
+)
+
+var failureHeaders = map[string]struct{}{
+	SourceTopicHeader:   {},
+	ConsumerGroupHeader: {},
+	AttemptHeader:       {},
+	FailureReasonHeader: {},
+	FailedAtHeader:      {},
+	RetryAtHeader:       {},
+}
+
+// FailedMessageHandler sends messages that could not be processed to the retry topic of the next attempt, once
+// all retries are used up the message goes to the dead-letter queue of the source topic. Messages are tagged with
+// the consumer group that failed them, only that group processes the retry.
+type FailedMessageHandler struct {
+	sourceTopic      string
+	consumerGroup    string
+	delays           []time.Duration
+	retryWriters     []Writer
+	deadLetterWriter Writer
+}
+
+func NewFailedMessageHandler(configuration *TopicConfigs, retryConfigs RetryConfigs) *FailedMessageHandler {
+	retryWriters := make([]Writer, 0)
+	for attempt := range retryConfigs.Delays {
+		retryWriters = append(retryWriters, NewTopicWriter(configuration.RetryTopic(attempt+1)))
+	}
+
+	return &FailedMessageHandler{
+		sourceTopic:      configuration.Topic,
+		consumerGroup:    groupID(configuration),
+		delays:           retryConfigs.Delays,
+		retryWriters:     retryWriters,
+		deadLetterWriter: NewTopicWriter(configuration.DeadLetterTopic()),
+	}
+}
+
+func (h *FailedMessageHandler) HandleError(ctx context.Context, err error, message kafka.Message) error {
+	attempt := GetAttempt(message)
+	now := time.Now()
+
+	if attempt < len(h.delays) {
+		retryAt := now.Add(h.delays[attempt])
+		log.Warning.Printf("Message [%v] from topic %v is going to be retried at %v (attempt %d)", string(message.Key), h.sourceTopic, retryAt.Format(time.RFC3339), attempt+1)
+		failed := withFailureHeaders(message, h.sourceTopic, h.consumerGroup, err, attempt+1, now, &retryAt)
+		return h.retryWriters[attempt].SendMessage(ctx, failed)
+	}
+
+	log.Error.Printf("Message [%v] from topic %v was already retried %d time(s). Sending it to dead-letter queue", string(message.Key), h.sourceTopic, attempt)
+	failed := withFailureHeaders(message, h.sourceTopic, h.consumerGroup, err, attempt, now, nil)
+	return h.deadLetterWriter.SendMessage(ctx, failed)
+}
+
+func GetAttempt(message kafka.Message) int {
+	attempt, err := strconv.Atoi(GetHeader(message, AttemptHeader))
+	if err != nil {
+		return 0
+	}
+	return attempt
+}
+
+func GetHeader(message kafka.Message, key string) string {
+	for _, header := range message.Headers {
+		if header.Key == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// withFailureHeaders copies the message, so it can be written to another topic, keeping the original headers.
+func withFailureHeaders(message kafka.Message, sourceTopic string, consumerGroup string, err error, attempt int, failedAt time.Time, retryAt *time.Time) kafka.Message {
+	headers := withoutFailureHeaders(message.Headers)
+	headers = append(headers,
+		kafka.Header{Key: SourceTopicHeader, Value: []byte(sourceTopic)},
+		kafka.Header{Key: ConsumerGroupHeader, Value: []byte(consumerGroup)},
+		kafka.Header{Key: AttemptHeader, Value: []byte(strconv.Itoa(attempt))},
+		kafka.Header{Key: FailureReasonHeader, Value: []byte(err.Error())},
+		kafka.Header{Key: FailedAtHeader, Value: []byte(failedAt.Format(time.RFC3339Nano))},
+	)
+	if retryAt != nil {
+		headers = append(headers, kafka.Header{Key: RetryAtHeader, Value: []byte(retryAt.Format(time.RFC3339Nano))})
+	}
+
+	return kafka.Message{Key: message.Key, Value: message.Value, Headers: headers}
+}
+
+func withoutFailureHeaders(headers []kafka.Header) []kafka.Header {
+	result := make([]kafka.Header, 0, len(headers))
+	for _, header := range headers {
+		if _, ok := failureHeaders[header.Key]; !ok {
+			result = append(result, header)
+		}
+	}
+	return result
+}
@@ -0,0 +1,129 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type StubWriter struct {
+	Messages []kafka.Message
+}
+
+func (s *StubWriter) SendMessage(ctx context.Context, messages ...kafka.Message) error {
+	s.Messages = append(s.Messages, messages...)
+	return nil
+}
+
+func givenFailedMessageHandler() (*FailedMessageHandler, []*StubWriter, *StubWriter) {
+	retryWriters := []*StubWriter{{}, {}}
+	deadLetterWriter := &StubWriter{}
+	sut := &FailedMessageHandler{
+		sourceTopic:      "shelf-events",
+		consumerGroup:    "mc-burger-orders-shelf-events",
+		delays:           []time.Duration{time.Second, time.Minute},
+		retryWriters:     []Writer{retryWriters[0], retryWriters[1]},
+		deadLetterWriter: deadLetterWriter,
+	}
+	return sut, retryWriters, deadLetterWriter
+}
+
+func TestFailedMessageHandler_HandleError(t *testing.T) {
+	t.Run("should send message to first retry topic when it failed for the first time", shouldSendMessageToFirstRetryTopicWhenItFailedForTheFirstTime)
+	t.Run("should send message to next retry topic when retried message failed", shouldSendMessageToNextRetryTopicWhenRetriedMessageFailed)
+	t.Run("should send message to dead-letter queue when all retries failed", shouldSendMessageToDeadLetterQueueWhenAllRetriesFailed)
+}
+
+func shouldSendMessageToFirstRetryTopicWhenItFailedForTheFirstTime(t *testing.T) {
+	// given
+	sut, retryWriters, deadLetterWriter := givenFailedMessageHandler()
+	message := kafka.Message{
+		Topic:   "shelf-events",
+		Key:     []byte("1010"),
+		Value:   []byte("[]"),
+		Headers: []kafka.Header{{Key: "event", Value: []byte("item-added-on-shelf")}},
+	}
+
+	// when
+	before := time.Now()
+	err := sut.HandleError(context.Background(), fmt.Errorf("order repository unavailable"), message)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, retryWriters[0].Messages, 1)
+	assert.Empty(t, retryWriters[1].Messages)
+	assert.Empty(t, deadLetterWriter.Messages)
+
+	retried := retryWriters[0].Messages[0]
+	assert.Empty(t, retried.Topic)
+	assert.Equal(t, message.Key, retried.Key)
+	assert.Equal(t, message.Value, retried.Value)
+	assert.Equal(t, "item-added-on-shelf", GetHeader(retried, "event"))
+	assert.Equal(t, "shelf-events", GetHeader(retried, SourceTopicHeader))
+	assert.Equal(t, "mc-burger-orders-shelf-events", GetHeader(retried, ConsumerGroupHeader))
+	assert.Equal(t, "order repository unavailable", GetHeader(retried, FailureReasonHeader))
+	assert.Equal(t, 1, GetAttempt(retried))
+
+	retryAt, err := time.Parse(time.RFC3339Nano, GetHeader(retried, RetryAtHeader))
+	assert.NoError(t, err)
+	assert.False(t, retryAt.Before(before.Add(time.Second)))
+}
+
+func shouldSendMessageToNextRetryTopicWhenRetriedMessageFailed(t *testing.T) {
+	// given
+	sut, retryWriters, deadLetterWriter := givenFailedMessageHandler()
+	message := kafka.Message{
+		Key: []byte("1010"),
+		Headers: []kafka.Header{
+			{Key: "event", Value: []byte("item-added-on-shelf")},
+			{Key: SourceTopicHeader, Value: []byte("shelf-events")},
+			{Key: AttemptHeader, Value: []byte("1")},
+			{Key: FailureReasonHeader, Value: []byte("first failure")},
+		},
+	}
+
+	// when
+	err := sut.HandleError(context.Background(), fmt.Errorf("second failure"), message)
+
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, retryWriters[0].Messages)
+	assert.Len(t, retryWriters[1].Messages, 1)
+	assert.Empty(t, deadLetterWriter.Messages)
+
+	retried := retryWriters[1].Messages[0]
+	assert.Equal(t, 2, GetAttempt(retried))
+	assert.Equal(t, "second failure", GetHeader(retried, FailureReasonHeader))
+	assert.Len(t, retried.Headers, 7, "failure headers are replaced, not repeated")
+}
+
+func shouldSendMessageToDeadLetterQueueWhenAllRetriesFailed(t *testing.T) {
+	// given
+	sut, retryWriters, deadLetterWriter := givenFailedMessageHandler()
+	message := kafka.Message{
+		Key: []byte("1010"),
+		Headers: []kafka.Header{
+			{Key: "event", Value: []byte("item-added-on-shelf")},
+			{Key: AttemptHeader, Value: []byte("2")},
+		},
+	}
+
+	// when
+	err := sut.HandleError(context.Background(), fmt.Errorf("last failure"), message)
+
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, retryWriters[0].Messages)
+	assert.Empty(t, retryWriters[1].Messages)
+	assert.Len(t, deadLetterWriter.Messages, 1)
+
+	deadLetter := deadLetterWriter.Messages[0]
+	assert.Equal(t, 2, GetAttempt(deadLetter))
+	assert.Equal(t, "last failure", GetHeader(deadLetter, FailureReasonHeader))
+	assert.Equal(t, "shelf-events", GetHeader(deadLetter, SourceTopicHeader))
+	assert.NotEmpty(t, GetHeader(deadLetter, FailedAtHeader))
+	assert.Empty(t, GetHeader(deadLetter, RetryAtHeader))
+}
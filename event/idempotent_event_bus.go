@@ -0,0 +1,85 @@
+package event
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/command"
+	"mc-burger-orders/log"
+	"time"
+)
+
+// IdempotentEventBus skips messages redelivered by Kafka after they were already processed successfully, e.g.
+// after a consumer group rebalance, and records the outcome of every command run for a message. When a message is
+// processed again after one of its commands failed, the commands that already succeeded are not run again.
+type IdempotentEventBus struct {
+	EventBus
+	store ProcessedMessageStore
+}
+
+func NewIdempotentEventBus(eventBus EventBus, store ProcessedMessageStore) *IdempotentEventBus {
+	return &IdempotentEventBus{EventBus: eventBus, store: store}
+}
+
+func (b *IdempotentEventBus) PublishEvent(ctx context.Context, message kafka.Message) command.Results {
+	messageId := MessageId(message)
+	storeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	processed, found, err := b.store.Find(storeCtx, message.Topic, messageId)
+	if err != nil {
+		log.Error.Printf("failed to check if message [%v] from topic %v was processed already, processing it. Reason: %v", messageId, message.Topic, err)
+	}
+	if found && processed.Status == Succeeded {
+		log.Warning.Printf("Message [%v] from topic %v was processed already, skipping duplicate", messageId, message.Topic)
+		return command.Results{}
+	}
+
+	eventType, _ := GetEventType(message)
+	startedMessage := ProcessedMessage{Topic: message.Topic, MessageId: messageId, EventType: eventType, StartedAt: time.Now()}
+	if err = b.store.StartProcessing(storeCtx, startedMessage); err != nil {
+		log.Error.Printf("failed to record processing of message [%v] from topic %v. Reason: %v", messageId, message.Topic, err)
+	}
+
+	results := b.EventBus.PublishEvent(command.ContextWithSucceeded(ctx, processed.SucceededCommands()), message)
+	b.recordOutcomes(message.Topic, messageId, results)
+	b.finishProcessing(message.Topic, messageId, results)
+	return results
+}
+
+func (b *IdempotentEventBus) recordOutcomes(topic string, messageId string, results command.Results) {
+	for _, result := range results {
+		outcome := CommandOutcome{Command: commandOf(result), Succeeded: result.Error == nil, ProcessedAt: time.Now()}
+		if result.Error != nil {
+			outcome.Error = result.Error.ErrorMessage
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := b.store.RecordOutcome(ctx, topic, messageId, outcome); err != nil {
+			log.Error.Printf("failed to record outcome of %v for message [%v] from topic %v. Reason: %v", outcome.Command, messageId, topic, err)
+		}
+		cancel()
+	}
+}
+
+// finishProcessing marks the message succeeded once none of its commands failed, also when it has no commands.
+func (b *IdempotentEventBus) finishProcessing(topic string, messageId string, results command.Results) {
+	status := Succeeded
+	if results.Err() != nil {
+		status = Failed
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := b.store.FinishProcessing(ctx, topic, messageId, status); err != nil {
+		log.Error.Printf("failed to record status of message [%v] from topic %v. Reason: %v", messageId, topic, err)
+	}
+}
+
+// commandOf is the name outcomes of the command are recorded with, results of handlers running no commands are
+// recorded by their type.
+func commandOf(result command.TypedResult) string {
+	if len(result.Command) > 0 {
+		return result.Command
+	}
+	return result.Type
+}
@@ -0,0 +1,216 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/command"
+	"sync"
+	"testing"
+)
+
+type CountingCommand struct {
+	mu          sync.Mutex
+	invocations int
+	failures    int
+}
+
+func (c *CountingCommand) Execute(ctx context.Context, message kafka.Message, result chan command.TypedResult) {
+	c.mu.Lock()
+	c.invocations++
+	fail := c.failures > 0
+	if fail {
+		c.failures--
+	}
+	c.mu.Unlock()
+
+	if fail {
+		result <- command.NewErrorResult("CountingCommand", fmt.Errorf("command failed"))
+		return
+	}
+	result <- command.NewSuccessfulResult("CountingCommand")
+}
+
+func (c *CountingCommand) Invocations() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.invocations
+}
+
+// OtherCountingCommand is a command of another type than CountingCommand, outcomes are recorded per command type.
+type OtherCountingCommand struct {
+	CountingCommand
+}
+
+func givenIdempotentEventBus(commands ...command.Command) (*IdempotentEventBus, *InMemoryProcessedMessageStore) {
+	store := NewInMemoryProcessedMessageStore()
+	commandHandler := command.NewCommandHandler()
+	commandHandler.AddCommands(eventType, commands...)
+
+	sut := NewIdempotentEventBus(NewInternalEventBus(), store)
+	sut.AddHandler(commandHandler)
+	return sut, store
+}
+
+func givenMessage(eventId string) kafka.Message {
+	return kafka.Message{
+		Topic:   topic,
+		Key:     OrderKey(1010),
+		Headers: []kafka.Header{{Key: "event", Value: []byte(eventType)}, {Key: EventIdHeader, Value: []byte(eventId)}},
+	}
+}
+
+func TestIdempotentEventBus_PublishEvent(t *testing.T) {
+	t.Run("should record outcome of processed message", shouldRecordOutcomeOfProcessedMessage)
+	t.Run("should skip message that was already processed", shouldSkipMessageThatWasAlreadyProcessed)
+	t.Run("should process message again when previous processing failed", shouldProcessMessageAgainWhenPreviousProcessingFailed)
+	t.Run("should prefer event id over message key", shouldPreferEventIdOverMessageKey)
+	t.Run("should process different messages sharing key", shouldProcessDifferentMessagesSharingKey)
+	t.Run("should identify message without event id by its offset", shouldIdentifyMessageWithoutEventIdByItsOffset)
+	t.Run("should not run commands again that succeeded before", shouldNotRunCommandsAgainThatSucceededBefore)
+	t.Run("should mark message without commands processed", shouldMarkMessageWithoutCommandsProcessed)
+}
+
+func shouldRecordOutcomeOfProcessedMessage(t *testing.T) {
+	// given
+	first, second := &CountingCommand{}, &OtherCountingCommand{}
+	sut, store := givenIdempotentEventBus(first, second)
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-1"))
+
+	// then
+	assert.Len(t, results, 2)
+	processed, ok := store.Get(topic, "message-1")
+	assert.True(t, ok)
+	assert.Equal(t, Succeeded, processed.Status)
+	assert.Equal(t, eventType, processed.EventType)
+	assert.Len(t, processed.Outcomes, 2)
+}
+
+func shouldSkipMessageThatWasAlreadyProcessed(t *testing.T) {
+	// given
+	stubCommand := &CountingCommand{}
+	sut, _ := givenIdempotentEventBus(stubCommand)
+	sut.PublishEvent(context.Background(), givenMessage("message-2"))
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-2"))
+
+	// then
+	assert.Empty(t, results)
+	assert.Equal(t, 1, stubCommand.Invocations())
+}
+
+func shouldProcessMessageAgainWhenPreviousProcessingFailed(t *testing.T) {
+	// given
+	stubCommand := &CountingCommand{failures: 1}
+	sut, store := givenIdempotentEventBus(stubCommand)
+	failedResults := sut.PublishEvent(context.Background(), givenMessage("message-3"))
+
+	processed, _ := store.Get(topic, "message-3")
+	assert.Equal(t, Failed, processed.Status)
+	assert.Equal(t, "command failed", processed.Outcomes[0].Error)
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-3"))
+
+	// then
+	assert.False(t, failedResults[0].Result)
+	assert.True(t, results[0].Result)
+	assert.Equal(t, 2, stubCommand.Invocations())
+
+	processed, _ = store.Get(topic, "message-3")
+	assert.Equal(t, Succeeded, processed.Status)
+	assert.Len(t, processed.Outcomes, 1)
+}
+
+func shouldPreferEventIdOverMessageKey(t *testing.T) {
+	// given
+	stubCommand := &CountingCommand{}
+	sut, _ := givenIdempotentEventBus(stubCommand)
+
+	first := givenMessage("event-1")
+	first.Key = []byte("key-1")
+	second := givenMessage("event-1")
+	second.Key = []byte("key-2")
+
+	// when
+	sut.PublishEvent(context.Background(), first)
+	results := sut.PublishEvent(context.Background(), second)
+
+	// then
+	assert.Empty(t, results)
+	assert.Equal(t, 1, stubCommand.Invocations())
+}
+
+func shouldProcessDifferentMessagesSharingKey(t *testing.T) {
+	// given
+	stubCommand := &CountingCommand{}
+	sut, _ := givenIdempotentEventBus(stubCommand)
+	sut.PublishEvent(context.Background(), givenMessage("event-2"))
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("event-3"))
+
+	// then
+	assert.Len(t, results, 1)
+	assert.Equal(t, 2, stubCommand.Invocations())
+}
+
+func shouldIdentifyMessageWithoutEventIdByItsOffset(t *testing.T) {
+	// given
+	stubCommand := &CountingCommand{}
+	sut, store := givenIdempotentEventBus(stubCommand)
+
+	message := kafka.Message{Topic: topic, Key: OrderKey(1010), Partition: 2, Offset: 15, Headers: []kafka.Header{{Key: "event", Value: []byte(eventType)}}}
+	redelivered := message
+	next := message
+	next.Offset = 16
+
+	// when
+	sut.PublishEvent(context.Background(), message)
+	sut.PublishEvent(context.Background(), redelivered)
+	sut.PublishEvent(context.Background(), next)
+
+	// then
+	assert.Equal(t, 2, stubCommand.Invocations())
+	_, ok := store.Get(topic, "2-15")
+	assert.True(t, ok)
+}
+
+func shouldNotRunCommandsAgainThatSucceededBefore(t *testing.T) {
+	// given
+	succeeding, failing := &CountingCommand{}, &OtherCountingCommand{CountingCommand{failures: 1}}
+	sut, store := givenIdempotentEventBus(succeeding, failing)
+	sut.PublishEvent(context.Background(), givenMessage("message-4"))
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-4"))
+
+	// then
+	assert.Len(t, results, 1)
+	assert.NoError(t, results.Err())
+	assert.Equal(t, 1, succeeding.Invocations())
+	assert.Equal(t, 2, failing.Invocations())
+
+	// and
+	processed, _ := store.Get(topic, "message-4")
+	assert.Equal(t, Succeeded, processed.Status)
+	assert.Equal(t, map[string]bool{"event.CountingCommand": true, "event.OtherCountingCommand": true}, processed.SucceededCommands())
+}
+
+func shouldMarkMessageWithoutCommandsProcessed(t *testing.T) {
+	// given
+	sut, store := givenIdempotentEventBus()
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-5"))
+
+	// then
+	assert.Empty(t, results)
+	processed, ok := store.Get(topic, "message-5")
+	assert.True(t, ok)
+	assert.Equal(t, Succeeded, processed.Status)
+}
@@ -1,10 +1,11 @@
 package event
 
 import (
+	"context"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
 	"mc-burger-orders/log"
-	"mc-burger-orders/utils"
+	"sync"
 )
 
 type InternalEventBus struct {
@@ -18,21 +19,38 @@ func NewInternalEventBus() *InternalEventBus {
 	}
 }
 
-func (b *InternalEventBus) PublishEvent(message kafka.Message, resultsCommands chan command.TypedResult) {
-	eventType, err := utils.GetEventType(message)
+// PublishEvent runs the handlers of the event type of the message, they get the envelope of the message in their
+// context.
+func (b *InternalEventBus) PublishEvent(ctx context.Context, message kafka.Message) command.Results {
+	envelope, err := ReadEnvelope(message)
 	if err != nil {
-		resultsCommands <- command.NewErrorResult(eventType, err)
-		close(resultsCommands)
-		return
+		return command.Results{command.NewErrorResult("ReadEnvelope", err)}
 	}
 
-	if handlers, ok := b.eventHandlers[eventType]; ok {
-		for handler := range handlers {
-			handler.Handle(message, resultsCommands)
-		}
-		return
+	handlers, ok := b.eventHandlers[envelope.Type]
+	if !ok {
+		log.Error.Printf("Event %v does not have any handled", envelope.Type)
+		return command.Results{}
+	}
+
+	ctx = ContextWithEnvelope(ctx, envelope)
+
+	mu := sync.Mutex{}
+	waitGroup := &sync.WaitGroup{}
+	results := make(command.Results, 0)
+	for handler := range handlers {
+		waitGroup.Add(1)
+		go func(handler command.Handler) {
+			defer waitGroup.Done()
+			handlerResults := handler.Handle(ctx, envelope.Type, message)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results = append(results, handlerResults...)
+		}(handler)
 	}
-	log.Error.Printf("Event %v does not have any handled", eventType)
+	waitGroup.Wait()
+	return results
 }
 
 func (b *InternalEventBus) AddHandler(handler command.Handler) {
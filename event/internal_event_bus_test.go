@@ -0,0 +1,81 @@
+package event
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/command"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func givenInternalEventBus(handlers ...map[string][]command.Command) *InternalEventBus {
+	sut := NewInternalEventBus()
+	for _, commands := range handlers {
+		commandHandler := command.NewCommandHandler()
+		for event, eventCommands := range commands {
+			commandHandler.AddCommands(event, eventCommands...)
+		}
+		sut.AddHandler(commandHandler)
+	}
+	return sut
+}
+
+func TestInternalEventBus_PublishEvent(t *testing.T) {
+	t.Run("should return results of all handlers of event", shouldReturnResultsOfAllHandlersOfEvent)
+	t.Run("should return no results for event without handlers", shouldReturnNoResultsForEventWithoutHandlers)
+	t.Run("should not leak goroutines once message was published", shouldNotLeakGoroutinesOnceMessageWasPublished)
+}
+
+func shouldReturnResultsOfAllHandlersOfEvent(t *testing.T) {
+	// given
+	first, second, other := &CountingCommand{}, &CountingCommand{failures: 1}, &CountingCommand{}
+	sut := givenInternalEventBus(
+		map[string][]command.Command{eventType: {first}},
+		map[string][]command.Command{eventType: {second}, "other-event": {other}},
+	)
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-1"))
+
+	// then
+	assert.Len(t, results, 2)
+	assert.EqualError(t, results.Err(), "command failed")
+	assert.Equal(t, 1, first.Invocations())
+	assert.Equal(t, 1, second.Invocations())
+	assert.Equal(t, 0, other.Invocations())
+}
+
+func shouldReturnNoResultsForEventWithoutHandlers(t *testing.T) {
+	// given
+	sut := givenInternalEventBus(map[string][]command.Command{"other-event": {&CountingCommand{}}})
+
+	// when
+	results := sut.PublishEvent(context.Background(), givenMessage("message-2"))
+
+	// then
+	assert.Empty(t, results)
+}
+
+func shouldNotLeakGoroutinesOnceMessageWasPublished(t *testing.T) {
+	// given
+	sut := givenInternalEventBus(
+		map[string][]command.Command{eventType: {&CountingCommand{}, &CountingCommand{failures: 50}}},
+		map[string][]command.Command{eventType: {&CountingCommand{}}},
+	)
+	before := runtime.NumGoroutine()
+
+	// when
+	for range make([]int, 100) {
+		sut.PublishEvent(context.Background(), givenMessage("message-3"))
+		sut.PublishEvent(context.Background(), kafka.Message{})
+	}
+
+	// then
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before, "goroutines left running after all messages were published")
+}
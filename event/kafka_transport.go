@@ -0,0 +1,108 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/log"
+	"time"
+)
+
+// KafkaTransport reaches the Kafka brokers of the topic configuration, it dials the brokers and panics when they
+// cannot be reached.
+type KafkaTransport struct{}
+
+func (t KafkaTransport) CreateTopic(configuration *TopicConfigs) {
+	if len(configuration.Brokers) == 0 {
+		log.Error.Panicln("missing at least one Kafka Address")
+	}
+
+	conn := configuration.ConnectToBroker()
+	configuration.CreateTopic(conn)
+	if err := conn.Close(); err != nil {
+		log.Warning.Println("failed to close broker connection", err)
+	}
+}
+
+func (t KafkaTransport) Consumer(configuration *TopicConfigs, groupId string) TopicConsumer {
+	t.CreateTopic(configuration)
+
+	return kafka.NewReader(kafka.ReaderConfig{
+		Brokers:  configuration.Brokers,
+		Topic:    configuration.Topic,
+		MinBytes: 10e3, // 10KB
+		MaxBytes: 10e6, // 10MB
+		MaxWait:  configuration.WaitMaxTime,
+		GroupID:  groupId,
+	})
+}
+
+func (t KafkaTransport) Producer(configuration *TopicConfigs) TopicProducer {
+	t.CreateTopic(configuration)
+	return newKafkaWriter(configuration)
+}
+
+func (t KafkaTransport) ReadPartition(ctx context.Context, configuration *TopicConfigs, partition int, offset int64, limit int) ([]kafka.Message, error) {
+	conn, err := kafka.DialLeader(ctx, "tcp", configuration.Brokers[0], configuration.Topic, partition)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to topic %v. Reason: %w", configuration.Topic, err)
+	}
+	defer conn.Close()
+
+	first, last, err := conn.ReadOffsets()
+	if err != nil {
+		return nil, err
+	}
+	// offsets removed by the retention of the topic continue at its first message still kept
+	if offset < first {
+		offset = first
+	}
+	if offset >= last {
+		return []kafka.Message{}, nil
+	}
+
+	messages := make([]kafka.Message, 0)
+	for offset < last && len(messages) < limit {
+		if _, err = conn.Seek(offset, kafka.SeekAbsolute); err != nil {
+			return nil, err
+		}
+		if err = conn.SetReadDeadline(time.Now().Add(10 * time.Second)); err != nil {
+			return nil, err
+		}
+
+		batch := conn.ReadBatch(1, 10e6)
+		read := len(messages)
+		for len(messages) < limit {
+			message, err := batch.ReadMessage()
+			if err != nil {
+				break
+			}
+			messages = append(messages, message)
+			offset = message.Offset + 1
+		}
+		if err = batch.Close(); err != nil {
+			return nil, err
+		}
+		if read == len(messages) {
+			break
+		}
+	}
+	return messages, nil
+}
+
+func newKafkaWriter(configuration *TopicConfigs) *kafka.Writer {
+	writerConfigs := configuration.Writer.withDefaults()
+	return &kafka.Writer{
+		Addr:                   kafka.TCP(configuration.Controller),
+		Topic:                  configuration.Topic,
+		Balancer:               &kafka.Hash{},
+		RequiredAcks:           kafka.RequireOne,
+		AllowAutoTopicCreation: true,
+		ReadTimeout:            5 * time.Second,
+		WriteTimeout:           5 * time.Second,
+		MaxAttempts:            5,
+		BatchSize:              writerConfigs.BatchSize,
+		BatchBytes:             writerConfigs.BatchBytes,
+		BatchTimeout:           writerConfigs.Linger,
+	}
+}
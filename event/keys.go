@@ -0,0 +1,38 @@
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/segmentio/kafka-go"
+	"strconv"
+)
+
+// Messages are keyed by what they are about. Writers balance messages by the hash of their key, so messages
+// sharing a key land on the same partition and are consumed in the order they were sent.
+
+// OrderKey keys events of an order by its number.
+func OrderKey(orderNumber int64) []byte {
+	return []byte(strconv.FormatInt(orderNumber, 10))
+}
+
+// ItemKey keys shelf and kitchen events by the name of the item.
+func ItemKey(itemName string) []byte {
+	return []byte(itemName)
+}
+
+// FlagKey keys feature flag events by the name of the flag.
+func FlagKey(flagName string) []byte {
+	return []byte(flagName)
+}
+
+// NewMessage creates a keyed message carrying the envelope in its headers. As many messages share a key, it is the
+// event id of the envelope which tells a redelivered message from a new one.
+func NewMessage(key []byte, value []byte, envelope Envelope) kafka.Message {
+	return kafka.Message{Key: key, Value: value, Headers: envelope.Headers()}
+}
+
+func newEventId() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
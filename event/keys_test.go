@@ -0,0 +1,31 @@
+package event
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNewMessage(t *testing.T) {
+	t.Run("should identify messages sharing key by different event ids", shouldIdentifyMessagesSharingKeyByDifferentEventIds)
+}
+
+func shouldIdentifyMessagesSharingKeyByDifferentEventIds(t *testing.T) {
+	// given
+	ctx := context.Background()
+
+	// when
+	first := NewMessage(OrderKey(1010), []byte("{}"), NewEnvelope(ctx, "order-updated", Source("order")))
+	second := NewMessage(OrderKey(1010), []byte("{}"), NewEnvelope(ctx, "order-updated", Source("order")))
+
+	// then
+	assert.Equal(t, []byte("1010"), first.Key)
+	assert.Equal(t, first.Key, second.Key)
+	assert.NotEmpty(t, GetHeader(first, EventIdHeader))
+	assert.NotEqual(t, MessageId(first), MessageId(second))
+
+	// and
+	eventType, err := GetEventType(first)
+	assert.NoError(t, err)
+	assert.Equal(t, "order-updated", eventType)
+}
@@ -0,0 +1,248 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"github.com/segmentio/kafka-go"
+	"io"
+	"sync"
+	"time"
+)
+
+var ErrConsumerClosed = errors.New("consumer is closed")
+
+// MemoryTransport keeps topics in process, so the service runs and is tested without a broker. Like Kafka it keeps
+// messages of a partition in the order they were written in, spreads partitions of a topic among the members of a
+// consumer group and redelivers messages not committed before their partition moved to another member.
+type MemoryTransport struct {
+	mu     sync.Mutex
+	topics map[string]*memoryTopic
+	groups map[string]*memoryGroup
+	// changed is closed and replaced whenever a message is written or partitions are reassigned
+	changed chan struct{}
+}
+
+type memoryTopic struct {
+	partitions [][]kafka.Message
+}
+
+type memoryGroup struct {
+	topic     *memoryTopic
+	committed []int64
+	members   []*memoryConsumer
+}
+
+type memoryConsumer struct {
+	transport *MemoryTransport
+	group     *memoryGroup
+	// positions holds the offset of the next message to fetch from every assigned partition
+	positions map[int]int64
+	assigned  []int
+	next      int
+	closed    bool
+}
+
+type memoryProducer struct {
+	transport *MemoryTransport
+	topic     string
+	balancer  kafka.Balancer
+}
+
+func NewMemoryTransport() *MemoryTransport {
+	return &MemoryTransport{
+		topics:  make(map[string]*memoryTopic),
+		groups:  make(map[string]*memoryGroup),
+		changed: make(chan struct{}),
+	}
+}
+
+func (t *MemoryTransport) CreateTopic(configuration *TopicConfigs) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.topicOf(configuration)
+}
+
+func (t *MemoryTransport) Consumer(configuration *TopicConfigs, groupId string) TopicConsumer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	topic := t.topicOf(configuration)
+	groupKey := groupId + "/" + configuration.Topic
+	group, exists := t.groups[groupKey]
+	if !exists {
+		group = &memoryGroup{topic: topic, committed: make([]int64, len(topic.partitions))}
+		t.groups[groupKey] = group
+	}
+
+	consumer := &memoryConsumer{transport: t, group: group}
+	group.members = append(group.members, consumer)
+	t.rebalance(group)
+	return consumer
+}
+
+func (t *MemoryTransport) Producer(configuration *TopicConfigs) TopicProducer {
+	t.CreateTopic(configuration)
+	return &memoryProducer{transport: t, topic: configuration.Topic, balancer: &kafka.Hash{}}
+}
+
+func (t *MemoryTransport) ReadPartition(_ context.Context, configuration *TopicConfigs, partition int, offset int64, limit int) ([]kafka.Message, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	topic := t.topicOf(configuration)
+	if partition < 0 || partition >= len(topic.partitions) {
+		return []kafka.Message{}, nil
+	}
+
+	messages := topic.partitions[partition]
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= int64(len(messages)) {
+		return []kafka.Message{}, nil
+	}
+	end := offset + int64(limit)
+	if end > int64(len(messages)) {
+		end = int64(len(messages))
+	}
+	return append([]kafka.Message{}, messages[offset:end]...), nil
+}
+
+// topicOf creates topics on first use, as brokers allowing auto creation of topics do.
+func (t *MemoryTransport) topicOf(configuration *TopicConfigs) *memoryTopic {
+	topic, exists := t.topics[configuration.Topic]
+	if !exists {
+		numPartitions := configuration.NumPartitions
+		if numPartitions < 1 {
+			numPartitions = 1
+		}
+		topic = &memoryTopic{partitions: make([][]kafka.Message, numPartitions)}
+		t.topics[configuration.Topic] = topic
+	}
+	return topic
+}
+
+// rebalance assigns partitions of the topic to the group members in turn, members continue from the committed offsets.
+func (t *MemoryTransport) rebalance(group *memoryGroup) {
+	for _, member := range group.members {
+		member.assigned = make([]int, 0)
+		member.positions = make(map[int]int64)
+		member.next = 0
+	}
+	for partition := range group.topic.partitions {
+		member := group.members[partition%len(group.members)]
+		member.assigned = append(member.assigned, partition)
+		member.positions[partition] = group.committed[partition]
+	}
+	t.notify()
+}
+
+func (t *MemoryTransport) notify() {
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+func (c *memoryConsumer) FetchMessage(ctx context.Context) (kafka.Message, error) {
+	for {
+		c.transport.mu.Lock()
+		if c.closed {
+			c.transport.mu.Unlock()
+			return kafka.Message{}, io.EOF
+		}
+		if message, ok := c.nextMessage(); ok {
+			c.transport.mu.Unlock()
+			return message, nil
+		}
+		changed := c.transport.changed
+		c.transport.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return kafka.Message{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// nextMessage takes turns among the assigned partitions, so a busy partition does not hold back the others.
+func (c *memoryConsumer) nextMessage() (kafka.Message, bool) {
+	for i := range c.assigned {
+		index := (c.next + i) % len(c.assigned)
+		partition := c.assigned[index]
+		messages := c.group.topic.partitions[partition]
+		if position := c.positions[partition]; position < int64(len(messages)) {
+			c.positions[partition] = position + 1
+			c.next = index + 1
+			return messages[position], true
+		}
+	}
+	return kafka.Message{}, false
+}
+
+func (c *memoryConsumer) CommitMessages(_ context.Context, messages ...kafka.Message) error {
+	c.transport.mu.Lock()
+	defer c.transport.mu.Unlock()
+	if c.closed {
+		return ErrConsumerClosed
+	}
+
+	for _, message := range messages {
+		if message.Partition < len(c.group.committed) && message.Offset+1 > c.group.committed[message.Partition] {
+			c.group.committed[message.Partition] = message.Offset + 1
+		}
+	}
+	return nil
+}
+
+// Close leaves the consumer group, partitions of the consumer are assigned to the remaining members.
+func (c *memoryConsumer) Close() error {
+	c.transport.mu.Lock()
+	defer c.transport.mu.Unlock()
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	members := make([]*memoryConsumer, 0)
+	for _, member := range c.group.members {
+		if member != c {
+			members = append(members, member)
+		}
+	}
+	c.group.members = members
+	if len(members) > 0 {
+		c.transport.rebalance(c.group)
+	} else {
+		c.transport.notify()
+	}
+	return nil
+}
+
+// WriteMessages partitions messages by their key the way the Kafka writer does.
+func (p *memoryProducer) WriteMessages(_ context.Context, messages ...kafka.Message) error {
+	p.transport.mu.Lock()
+	defer p.transport.mu.Unlock()
+
+	topic := p.transport.topics[p.topic]
+	partitions := make([]int, len(topic.partitions))
+	for i := range partitions {
+		partitions[i] = i
+	}
+
+	for _, message := range messages {
+		partition := p.balancer.Balance(message, partitions...)
+		message.Topic = p.topic
+		message.Partition = partition
+		message.Offset = int64(len(topic.partitions[partition]))
+		if message.Time.IsZero() {
+			message.Time = time.Now()
+		}
+		topic.partitions[partition] = append(topic.partitions[partition], message)
+	}
+	p.transport.notify()
+	return nil
+}
+
+func (p *memoryProducer) Close() error {
+	return nil
+}
@@ -0,0 +1,228 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/command"
+	"testing"
+	"time"
+)
+
+func TestMemoryTransport(t *testing.T) {
+	t.Run("should fetch messages of key in order they were written in", shouldFetchMessagesOfKeyInOrderTheyWereWrittenIn)
+	t.Run("should deliver every message to every consumer group", shouldDeliverEveryMessageToEveryConsumerGroup)
+	t.Run("should spread partitions among members of consumer group", shouldSpreadPartitionsAmongMembersOfConsumerGroup)
+	t.Run("should redeliver uncommitted messages when member leaves consumer group", shouldRedeliverUncommittedMessagesWhenMemberLeavesConsumerGroup)
+	t.Run("should stop fetching when consumer is closed", shouldStopFetchingWhenConsumerIsClosed)
+	t.Run("should send message failing on reader to dead-letter queue", shouldSendMessageFailingOnReaderToDeadLetterQueue)
+	t.Run("should retry failed message only in consumer group that failed it", shouldRetryFailedMessageOnlyInConsumerGroupThatFailedIt)
+}
+
+func shouldFetchMessagesOfKeyInOrderTheyWereWrittenIn(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	configuration := givenMemoryTopic(transport, "shelf-events", 3)
+	consumer := transport.Consumer(configuration, "orders")
+	producer := transport.Producer(configuration)
+
+	// when
+	for quantity := 1; quantity <= 5; quantity++ {
+		err := producer.WriteMessages(context.Background(), kafka.Message{Key: ItemKey("hamburger"), Value: []byte(fmt.Sprint(quantity))})
+		assert.NoError(t, err)
+	}
+
+	// then
+	for quantity := 1; quantity <= 5; quantity++ {
+		message := givenFetchedMessage(t, consumer)
+		assert.Equal(t, "shelf-events", message.Topic)
+		assert.Equal(t, int64(quantity-1), message.Offset)
+		assert.Equal(t, fmt.Sprint(quantity), string(message.Value))
+	}
+}
+
+func shouldDeliverEveryMessageToEveryConsumerGroup(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	configuration := givenMemoryTopic(transport, "order-status", 1)
+	orders := transport.Consumer(configuration, "orders")
+	statuses := transport.Consumer(configuration, "statuses")
+
+	// when
+	err := transport.Producer(configuration).WriteMessages(context.Background(), kafka.Message{Key: OrderKey(1010)})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, OrderKey(1010), givenFetchedMessage(t, orders).Key)
+	assert.Equal(t, OrderKey(1010), givenFetchedMessage(t, statuses).Key)
+}
+
+func shouldSpreadPartitionsAmongMembersOfConsumerGroup(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	configuration := givenMemoryTopic(transport, "kitchen-requests", 2)
+	first := transport.Consumer(configuration, "kitchen")
+	second := transport.Consumer(configuration, "kitchen")
+	producer := transport.Producer(configuration)
+
+	// when
+	for orderNumber := int64(1); orderNumber <= 10; orderNumber++ {
+		assert.NoError(t, producer.WriteMessages(context.Background(), kafka.Message{Key: OrderKey(orderNumber)}))
+	}
+
+	// then
+	firstPartitions := givenFetchedPartitions(first)
+	secondPartitions := givenFetchedPartitions(second)
+	assert.Len(t, firstPartitions, 1)
+	assert.Len(t, secondPartitions, 1)
+	assert.NotEqual(t, firstPartitions, secondPartitions)
+}
+
+func shouldRedeliverUncommittedMessagesWhenMemberLeavesConsumerGroup(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	configuration := givenMemoryTopic(transport, "shelf-events", 1)
+	leaving := transport.Consumer(configuration, "orders")
+	producer := transport.Producer(configuration)
+	assert.NoError(t, producer.WriteMessages(context.Background(), kafka.Message{Value: []byte("1")}, kafka.Message{Value: []byte("2")}))
+
+	committed := givenFetchedMessage(t, leaving)
+	assert.NoError(t, leaving.CommitMessages(context.Background(), committed))
+	givenFetchedMessage(t, leaving)
+
+	// when
+	remaining := transport.Consumer(configuration, "orders")
+	assert.NoError(t, leaving.Close())
+
+	// then
+	assert.Equal(t, "2", string(givenFetchedMessage(t, remaining).Value))
+}
+
+func shouldStopFetchingWhenConsumerIsClosed(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	consumer := transport.Consumer(givenMemoryTopic(transport, "shelf-events", 1), "orders")
+	fetched := make(chan error)
+	go func() {
+		_, err := consumer.FetchMessage(context.Background())
+		fetched <- err
+	}()
+
+	// when
+	assert.NoError(t, consumer.Close())
+
+	// then
+	select {
+	case err := <-fetched:
+		assert.ErrorContains(t, err, "EOF")
+	case <-time.After(time.Second):
+		assert.Fail(t, "consumer kept fetching after it was closed")
+	}
+}
+
+func shouldSendMessageFailingOnReaderToDeadLetterQueue(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	configuration := givenMemoryTopic(transport, "kitchen-requests", 1)
+	recordingCommand := &RecordingCommand{processed: make(map[int][]int64), failing: map[int64]bool{0: true, 1: true}}
+	commandHandler := command.NewCommandHandler()
+	commandHandler.AddCommands("request-item", recordingCommand)
+	eventBus := NewInternalEventBus()
+	eventBus.AddHandler(commandHandler)
+
+	reader := NewTopicReader(configuration, eventBus).EnableRetries(RetryConfigs{Delays: []time.Duration{time.Millisecond}})
+	reader.SubscribeToTopic(make(chan kafka.Message))
+	defer reader.Close()
+	deadLetters := NewDeadLetterQueue(configuration)
+
+	// when
+	envelope := NewEnvelope(context.Background(), "request-item", Source("order"))
+	err := NewTopicWriter(configuration).SendMessage(context.Background(), NewMessage(ItemKey("hamburger"), []byte("[]"), envelope))
+
+	// then
+	assert.NoError(t, err)
+	listed := make([]DeadLetter, 0)
+	for deadline := time.Now().Add(2 * time.Second); len(listed) == 0 && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+		listed, err = deadLetters.List(context.Background(), 10)
+		assert.NoError(t, err)
+	}
+	assert.Len(t, listed, 1)
+	assert.Equal(t, "kitchen-requests", listed[0].SourceTopic)
+	assert.Equal(t, "hamburger", listed[0].Key)
+}
+
+func shouldRetryFailedMessageOnlyInConsumerGroupThatFailedIt(t *testing.T) {
+	// given
+	transport := NewMemoryTransport()
+	failingGroup := givenMemoryTopic(transport, "kitchen-requests", 1)
+	failingGroup.ConsumerGroup = "kitchen"
+	otherGroup := givenMemoryTopic(transport, "kitchen-requests", 1)
+	otherGroup.ConsumerGroup = "orders"
+
+	failingCommand := &CountingCommand{failures: 1}
+	failingReader := givenCommandReader(failingGroup, failingCommand).EnableRetries(RetryConfigs{Delays: []time.Duration{time.Millisecond}})
+	failingReader.SubscribeToTopic(make(chan kafka.Message))
+	defer failingReader.Close()
+
+	otherCommand := &CountingCommand{}
+	otherReader := givenCommandReader(otherGroup, otherCommand).EnableRetries(RetryConfigs{Delays: []time.Duration{time.Millisecond}})
+	otherReader.SubscribeToTopic(make(chan kafka.Message))
+	defer otherReader.Close()
+
+	// when
+	envelope := NewEnvelope(context.Background(), "request-item", Source("order"))
+	err := NewTopicWriter(failingGroup).SendMessage(context.Background(), NewMessage(ItemKey("hamburger"), []byte("[]"), envelope))
+
+	// then
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return failingCommand.Invocations() == 2 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, 2, failingCommand.Invocations())
+	assert.Equal(t, 1, otherCommand.Invocations())
+
+	// and
+	sourceMessages, err := transport.ReadPartition(context.Background(), failingGroup, 0, 0, 10)
+	assert.NoError(t, err)
+	assert.Len(t, sourceMessages, 1)
+}
+
+func givenCommandReader(configuration *TopicConfigs, handledBy command.Command) *DefaultReader {
+	commandHandler := command.NewCommandHandler()
+	commandHandler.AddCommands("request-item", handledBy)
+	eventBus := NewInternalEventBus()
+	eventBus.AddHandler(commandHandler)
+	return NewTopicReader(configuration, eventBus)
+}
+
+func givenMemoryTopic(transport *MemoryTransport, topic string, partitions int) *TopicConfigs {
+	configuration := TestTopicConfigs(topic)
+	configuration.NumPartitions = partitions
+	configuration.AwaitBetweenReadsTime = 10 * time.Millisecond
+	configuration.Transport = transport
+	return configuration
+}
+
+func givenFetchedMessage(t *testing.T, consumer TopicConsumer) kafka.Message {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	message, err := consumer.FetchMessage(ctx)
+	assert.NoError(t, err)
+	return message
+}
+
+// givenFetchedPartitions fetches messages until there are none left and returns the partitions they came from.
+func givenFetchedPartitions(consumer TopicConsumer) map[int]bool {
+	partitions := make(map[int]bool)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		message, err := consumer.FetchMessage(ctx)
+		cancel()
+		if err != nil {
+			return partitions
+		}
+		partitions[message.Partition] = true
+	}
+}
@@ -0,0 +1,60 @@
+package event
+
+import (
+	"github.com/segmentio/kafka-go"
+	"slices"
+	"sort"
+	"sync"
+)
+
+// offsetTracker commits messages processed out of order in the order of their offsets. A message is committed once it
+// and every message fetched before it from its partition were processed, so messages still processed when the
+// service stops are read again.
+type offsetTracker struct {
+	mu         sync.Mutex
+	partitions map[int][]*trackedMessage
+}
+
+type trackedMessage struct {
+	message   kafka.Message
+	processed bool
+}
+
+func newOffsetTracker() *offsetTracker {
+	return &offsetTracker{partitions: make(map[int][]*trackedMessage)}
+}
+
+// fetched tracks the message until it is processed.
+func (t *offsetTracker) fetched(message kafka.Message) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked := t.partitions[message.Partition]
+	index := sort.Search(len(tracked), func(i int) bool { return tracked[i].message.Offset > message.Offset })
+	t.partitions[message.Partition] = slices.Insert(tracked, index, &trackedMessage{message: message})
+}
+
+// processed returns the last message of the partition processed together with every message before it, false when
+// a message before the processed one is still processed.
+func (t *offsetTracker) processed(message kafka.Message) (kafka.Message, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	tracked := t.partitions[message.Partition]
+	for _, candidate := range tracked {
+		if candidate.message.Offset == message.Offset && !candidate.processed {
+			candidate.processed = true
+			break
+		}
+	}
+
+	committable := 0
+	for committable < len(tracked) && tracked[committable].processed {
+		committable++
+	}
+	if committable == 0 {
+		return kafka.Message{}, false
+	}
+	t.partitions[message.Partition] = tracked[committable:]
+	return tracked[committable-1].message, true
+}
@@ -0,0 +1,119 @@
+package event
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/log"
+	"time"
+)
+
+const processedMessagesRetention = 7 * 24 * time.Hour
+
+type ProcessedMessageRepository struct {
+	c *mongo.Collection
+}
+
+func NewProcessedMessageRepository(database *mongo.Database) *ProcessedMessageRepository {
+	collection := database.Collection("processed-messages")
+	createProcessedMessagesIndex(collection)
+	return &ProcessedMessageRepository{c: collection}
+}
+
+func createProcessedMessagesIndex(c *mongo.Collection) {
+	expireAfter := int32(processedMessagesRetention.Seconds())
+	index := mongo.IndexModel{
+		Keys:    bson.D{{Key: "startedAt", Value: 1}},
+		Options: options.Index().SetName("processed-messages-retention").SetExpireAfterSeconds(expireAfter),
+	}
+
+	if _, err := c.Indexes().CreateOne(context.Background(), index); err != nil {
+		log.Error.Println("failed to create processed messages retention index", err)
+	}
+}
+
+func (r *ProcessedMessageRepository) Find(ctx context.Context, topic string, messageId string) (ProcessedMessage, bool, error) {
+	filterDef := bson.D{{Key: "_id", Value: processedMessageKey(topic, messageId)}}
+	result := r.c.FindOne(ctx, filterDef)
+	if errors.Is(result.Err(), mongo.ErrNoDocuments) {
+		return ProcessedMessage{}, false, nil
+	}
+
+	message := ProcessedMessage{}
+	if err := result.Decode(&message); err != nil {
+		log.Error.Println("Error when reading processed message from db", err)
+		return ProcessedMessage{}, false, err
+	}
+	return message, true, nil
+}
+
+// StartProcessing keeps the outcomes recorded for earlier deliveries of the message, so commands that succeeded then
+// are not run again.
+func (r *ProcessedMessageRepository) StartProcessing(ctx context.Context, message ProcessedMessage) error {
+	filterDef := bson.D{{Key: "_id", Value: processedMessageKey(message.Topic, message.MessageId)}}
+	updateDef := bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "topic", Value: message.Topic},
+			{Key: "messageId", Value: message.MessageId},
+			{Key: "eventType", Value: message.EventType},
+			{Key: "status", Value: Processing},
+		}},
+		{Key: "$setOnInsert", Value: bson.D{
+			{Key: "outcomes", Value: bson.A{}},
+			{Key: "startedAt", Value: message.StartedAt},
+		}},
+	}
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Error.Println("Error when storing processed message in db", err)
+	}
+	return err
+}
+
+// RecordOutcome replaces the outcome of the command in a single update, so results of commands finishing at the same
+// time do not overwrite each other.
+func (r *ProcessedMessageRepository) RecordOutcome(ctx context.Context, topic string, messageId string, outcome CommandOutcome) error {
+	filterDef := bson.D{{Key: "_id", Value: processedMessageKey(topic, messageId)}}
+	updateDef := mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "topic", Value: topic},
+			{Key: "messageId", Value: bson.D{{Key: "$literal", Value: messageId}}},
+			{Key: "status", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$status", Processing}}}},
+			{Key: "startedAt", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$startedAt", outcome.ProcessedAt}}}},
+			{Key: "outcomes", Value: bson.D{{Key: "$concatArrays", Value: bson.A{
+				bson.D{{Key: "$filter", Value: bson.D{
+					{Key: "input", Value: bson.D{{Key: "$ifNull", Value: bson.A{"$outcomes", bson.A{}}}}},
+					{Key: "as", Value: "outcome"},
+					{Key: "cond", Value: bson.D{{Key: "$ne", Value: bson.A{"$$outcome.command", bson.D{{Key: "$literal", Value: outcome.Command}}}}}},
+				}}},
+				bson.A{bson.D{{Key: "$literal", Value: outcome}}},
+			}}}},
+		}}},
+	}
+
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Error.Println("Error when storing message outcome in db", err)
+	}
+	return err
+}
+
+func (r *ProcessedMessageRepository) FinishProcessing(ctx context.Context, topic string, messageId string, status ProcessingStatus) error {
+	filterDef := bson.D{{Key: "_id", Value: processedMessageKey(topic, messageId)}}
+	updateDef := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: status}}},
+		{Key: "$setOnInsert", Value: bson.D{
+			{Key: "topic", Value: topic},
+			{Key: "messageId", Value: messageId},
+			{Key: "outcomes", Value: bson.A{}},
+			{Key: "startedAt", Value: time.Now()},
+		}},
+	}
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Error.Println("Error when storing status of processed message in db", err)
+	}
+	return err
+}
@@ -0,0 +1,113 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mc-burger-orders/testing/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+var processedMessagesDb *mongo.Collection
+
+func TestIntegrationProcessedMessageRepository(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+	mongoContainer, database := utils.TestWithMongo(t, ctx)
+
+	processedMessagesDb = database.Collection("processed-messages")
+
+	t.Run("should mark message processed when all commands succeeded", shouldMarkMessageProcessedWhenAllCommandsSucceeded)
+	t.Run("should not mark message processed when one of commands failed", shouldNotMarkMessageProcessedWhenOneOfCommandsFailed)
+	t.Run("should replace outcome of command recorded for earlier delivery", shouldReplaceOutcomeOfCommandRecordedForEarlierDelivery)
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+func shouldMarkMessageProcessedWhenAllCommandsSucceeded(t *testing.T) {
+	// given
+	utils.DeleteMany(t, processedMessagesDb, bson.D{})
+	sut := &ProcessedMessageRepository{c: processedMessagesDb}
+	ctx := context.Background()
+	assert.Nil(t, sut.StartProcessing(ctx, ProcessedMessage{Topic: topic, MessageId: "message-1", StartedAt: time.Now()}))
+
+	wg := &sync.WaitGroup{}
+	wg.Add(5)
+
+	// when
+	for i := 0; i < 5; i++ {
+		go func(i int) {
+			defer wg.Done()
+			outcome := CommandOutcome{Command: fmt.Sprintf("Command%d", i), Succeeded: true, ProcessedAt: time.Now()}
+			assert.Nil(t, sut.RecordOutcome(ctx, topic, "message-1", outcome))
+		}(i)
+	}
+	wg.Wait()
+	assert.Nil(t, sut.FinishProcessing(ctx, topic, "message-1", Succeeded))
+
+	// then
+	processed, found, err := sut.Find(ctx, topic, "message-1")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Succeeded, processed.Status)
+	assert.Len(t, processed.Outcomes, 5)
+
+	defer func() {
+		utils.DeleteMany(t, processedMessagesDb, bson.D{})
+	}()
+}
+
+func shouldNotMarkMessageProcessedWhenOneOfCommandsFailed(t *testing.T) {
+	// given
+	utils.DeleteMany(t, processedMessagesDb, bson.D{})
+	sut := &ProcessedMessageRepository{c: processedMessagesDb}
+	ctx := context.Background()
+
+	// when
+	assert.Nil(t, sut.RecordOutcome(ctx, topic, "message-2", CommandOutcome{Command: "PackItemCommand", Succeeded: false, Error: "$failed", ProcessedAt: time.Now()}))
+	assert.Nil(t, sut.RecordOutcome(ctx, topic, "message-2", CommandOutcome{Command: "CreateNewItem", Succeeded: true, ProcessedAt: time.Now()}))
+	assert.Nil(t, sut.FinishProcessing(ctx, topic, "message-2", Failed))
+
+	// then
+	processed, found, err := sut.Find(ctx, topic, "message-2")
+	assert.Nil(t, err)
+	assert.True(t, found)
+	assert.Equal(t, Failed, processed.Status)
+	assert.Equal(t, map[string]bool{"PackItemCommand": false, "CreateNewItem": true}, processed.SucceededCommands())
+
+	defer func() {
+		utils.DeleteMany(t, processedMessagesDb, bson.D{})
+	}()
+}
+
+func shouldReplaceOutcomeOfCommandRecordedForEarlierDelivery(t *testing.T) {
+	// given
+	utils.DeleteMany(t, processedMessagesDb, bson.D{})
+	sut := &ProcessedMessageRepository{c: processedMessagesDb}
+	ctx := context.Background()
+	assert.Nil(t, sut.StartProcessing(ctx, ProcessedMessage{Topic: topic, MessageId: "message-3", StartedAt: time.Now()}))
+	assert.Nil(t, sut.RecordOutcome(ctx, topic, "message-3", CommandOutcome{Command: "PackItemCommand", Succeeded: false, Error: "failed", ProcessedAt: time.Now()}))
+	assert.Nil(t, sut.FinishProcessing(ctx, topic, "message-3", Failed))
+
+	// when
+	assert.Nil(t, sut.StartProcessing(ctx, ProcessedMessage{Topic: topic, MessageId: "message-3", StartedAt: time.Now()}))
+	assert.Nil(t, sut.RecordOutcome(ctx, topic, "message-3", CommandOutcome{Command: "PackItemCommand", Succeeded: true, ProcessedAt: time.Now()}))
+
+	// then
+	processed, _, err := sut.Find(ctx, topic, "message-3")
+	assert.Nil(t, err)
+	assert.Equal(t, Processing, processed.Status)
+	assert.Len(t, processed.Outcomes, 1)
+	assert.True(t, processed.Outcomes[0].Succeeded)
+
+	defer func() {
+		utils.DeleteMany(t, processedMessagesDb, bson.D{})
+	}()
+}
@@ -0,0 +1,141 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"slices"
+	"sync"
+	"time"
+)
+
+type ProcessingStatus string
+
+const (
+	Processing ProcessingStatus = "PROCESSING"
+	Succeeded  ProcessingStatus = "SUCCEEDED"
+	Failed     ProcessingStatus = "FAILED"
+)
+
+type CommandOutcome struct {
+	Command     string    `json:"command" bson:"command"`
+	Succeeded   bool      `json:"succeeded" bson:"succeeded"`
+	Error       string    `json:"error,omitempty" bson:"error,omitempty"`
+	ProcessedAt time.Time `json:"processedAt" bson:"processedAt"`
+}
+
+type ProcessedMessage struct {
+	Topic     string           `json:"topic" bson:"topic"`
+	MessageId string           `json:"messageId" bson:"messageId"`
+	EventType string           `json:"eventType" bson:"eventType"`
+	Status    ProcessingStatus `json:"status" bson:"status"`
+	Outcomes  []CommandOutcome `json:"outcomes" bson:"outcomes"`
+	StartedAt time.Time        `json:"startedAt" bson:"startedAt"`
+}
+
+// SucceededCommands are the commands whose latest outcome for the message succeeded.
+func (m ProcessedMessage) SucceededCommands() map[string]bool {
+	succeeded := make(map[string]bool)
+	for _, outcome := range m.Outcomes {
+		succeeded[outcome.Command] = outcome.Succeeded
+	}
+	return succeeded
+}
+
+// ProcessedMessageStore remembers which messages were consumed already and the outcome of every command run for them.
+// A message counts as processed once it finished without any of its commands failing.
+type ProcessedMessageStore interface {
+	// Find returns what was recorded for the message so far, false when it was not processed yet.
+	Find(ctx context.Context, topic string, messageId string) (ProcessedMessage, bool, error)
+	// StartProcessing marks the message in progress and keeps the outcomes of its earlier deliveries.
+	StartProcessing(ctx context.Context, message ProcessedMessage) error
+	// RecordOutcome replaces the outcome recorded for the command in an earlier delivery of the message.
+	RecordOutcome(ctx context.Context, topic string, messageId string, outcome CommandOutcome) error
+	// FinishProcessing sets the status of the message once all of its commands finished.
+	FinishProcessing(ctx context.Context, topic string, messageId string, status ProcessingStatus) error
+}
+
+// MessageId identifies the message within its topic by its event id header. Keys are shared by all events of an
+// order or item, so a message sent without an event id is identified by its partition and offset instead.
+func MessageId(message kafka.Message) string {
+	if eventId := GetEventId(message); len(eventId) > 0 {
+		return eventId
+	}
+	return fmt.Sprintf("%d-%d", message.Partition, message.Offset)
+}
+
+type InMemoryProcessedMessageStore struct {
+	mu       sync.Mutex
+	messages map[string]*ProcessedMessage
+}
+
+func NewInMemoryProcessedMessageStore() *InMemoryProcessedMessageStore {
+	return &InMemoryProcessedMessageStore{messages: make(map[string]*ProcessedMessage)}
+}
+
+func (s *InMemoryProcessedMessageStore) Find(ctx context.Context, topic string, messageId string) (ProcessedMessage, bool, error) {
+	message, ok := s.Get(topic, messageId)
+	return message, ok, nil
+}
+
+func (s *InMemoryProcessedMessageStore) StartProcessing(ctx context.Context, message ProcessedMessage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := processedMessageKey(message.Topic, message.MessageId)
+	if started, ok := s.messages[key]; ok {
+		started.EventType = message.EventType
+		started.Status = Processing
+		return nil
+	}
+
+	message.Status = Processing
+	message.Outcomes = make([]CommandOutcome, 0)
+	s.messages[key] = &message
+	return nil
+}
+
+func (s *InMemoryProcessedMessageStore) RecordOutcome(ctx context.Context, topic string, messageId string, outcome CommandOutcome) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message := s.startedMessage(topic, messageId, outcome.ProcessedAt)
+	message.Outcomes = slices.DeleteFunc(message.Outcomes, func(recorded CommandOutcome) bool { return recorded.Command == outcome.Command })
+	message.Outcomes = append(message.Outcomes, outcome)
+	return nil
+}
+
+func (s *InMemoryProcessedMessageStore) FinishProcessing(ctx context.Context, topic string, messageId string, status ProcessingStatus) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.startedMessage(topic, messageId, time.Now()).Status = status
+	return nil
+}
+
+func (s *InMemoryProcessedMessageStore) Get(topic string, messageId string) (ProcessedMessage, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	message, ok := s.messages[processedMessageKey(topic, messageId)]
+	if !ok {
+		return ProcessedMessage{}, false
+	}
+	copied := *message
+	copied.Outcomes = slices.Clone(message.Outcomes)
+	return copied, true
+}
+
+func (s *InMemoryProcessedMessageStore) startedMessage(topic string, messageId string, startedAt time.Time) *ProcessedMessage {
+	key := processedMessageKey(topic, messageId)
+	message, ok := s.messages[key]
+	if !ok {
+		message = &ProcessedMessage{Topic: topic, MessageId: messageId, Status: Processing, Outcomes: make([]CommandOutcome, 0), StartedAt: startedAt}
+		s.messages[key] = message
+	}
+	return message
+}
+
+func processedMessageKey(topic string, messageId string) string {
+	return topic + "/" + messageId
+}
@@ -0,0 +1,78 @@
+package event
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/protobuf/proto"
+	"sync"
+)
+
+const ProtobufContentType = "application/x-protobuf"
+
+// ProtobufCodec writes proto messages, registering the .proto definition of their event type in the schema registry
+// under the event type as subject. The definition must declare the message as its first message, which is what the
+// zero message index written before the payload refers to.
+type ProtobufCodec struct {
+	registry    SchemaRegistry
+	mu          sync.RWMutex
+	definitions map[string]string
+}
+
+func NewProtobufCodec(registry SchemaRegistry) *ProtobufCodec {
+	return &ProtobufCodec{registry: registry, definitions: make(map[string]string)}
+}
+
+func (c *ProtobufCodec) WithSchema(eventType string, definition string) *ProtobufCodec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.definitions[eventType] = definition
+	return c
+}
+
+func (c *ProtobufCodec) ContentType() string {
+	return ProtobufContentType
+}
+
+func (c *ProtobufCodec) Encode(ctx context.Context, eventType string, payload any) ([]byte, error) {
+	message, ok := payload.(proto.Message)
+	if !ok {
+		return nil, fmt.Errorf("payload of %v event is not a proto message but %T", eventType, payload)
+	}
+
+	c.mu.RLock()
+	definition, ok := c.definitions[eventType]
+	c.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no proto definition configured for %v event", eventType)
+	}
+
+	data, err := proto.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+
+	schemaId, err := c.registry.Register(ctx, eventType, Schema{Type: ProtobufSchema, Definition: definition})
+	if err != nil {
+		return nil, err
+	}
+	return toWireFormat(schemaId, append([]byte{0}, data...)), nil
+}
+
+func (c *ProtobufCodec) Decode(_ context.Context, data []byte, target any) error {
+	message, ok := target.(proto.Message)
+	if !ok {
+		return fmt.Errorf("target is not a proto message but %T", target)
+	}
+
+	_, payload, err := fromWireFormat(data)
+	if err != nil {
+		return err
+	}
+	if len(payload) == 0 || payload[0] != 0 {
+		return fmt.Errorf("%w: payload is not the first message of its proto definition", ErrMalformedMessage)
+	}
+	if err = proto.Unmarshal(payload[1:], message); err != nil {
+		return fmt.Errorf("%w: %w", ErrMalformedMessage, err)
+	}
+	return nil
+}
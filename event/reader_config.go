@@ -0,0 +1,35 @@
+package event
+
+import (
+	"github.com/spf13/cast"
+	"os"
+)
+
+const defaultReaderWorkers = 4
+
+type ReaderConfigs struct {
+	// Workers is the number of messages a reader processes at once, messages of one partition are always processed
+	// one after another by the same worker
+	Workers int
+	// InFlight is the number of messages processed at once without waiting for the messages before them in their
+	// partition, readers without it process the messages of a partition one after another on Workers. Offsets are
+	// committed in order either way, a message is committed once every message before it in its partition was processed
+	InFlight int
+}
+
+// ReaderConfigsFromEnv reads the number of workers of every topic reader from KAFKA_READER_WORKERS.
+func ReaderConfigsFromEnv() ReaderConfigs {
+	configs := ReaderConfigs{}
+
+	if workersVal := os.Getenv("KAFKA_READER_WORKERS"); len(workersVal) > 0 {
+		configs.Workers = cast.ToInt(workersVal)
+	}
+	return configs.withDefaults()
+}
+
+func (c ReaderConfigs) withDefaults() ReaderConfigs {
+	if c.Workers <= 0 {
+		c.Workers = defaultReaderWorkers
+	}
+	return c
+}
@@ -0,0 +1,50 @@
+package event
+
+import (
+	"fmt"
+	"mc-burger-orders/log"
+	"os"
+	"strings"
+	"time"
+)
+
+var defaultRetryDelays = []time.Duration{5 * time.Second, 30 * time.Second, 2 * time.Minute}
+
+type RetryConfigs struct {
+	// Delays holds the delay of every retry topic, a message failing once more after the last retry ends up in the DLQ
+	Delays []time.Duration
+}
+
+// RetryConfigsFromEnv reads comma separated retry delays, e.g. `5s,30s,2m`, from KAFKA_RETRY_DELAYS.
+func RetryConfigsFromEnv() RetryConfigs {
+	delaysVal := os.Getenv("KAFKA_RETRY_DELAYS")
+	if len(delaysVal) == 0 {
+		return RetryConfigs{Delays: defaultRetryDelays}
+	}
+
+	delays := make([]time.Duration, 0)
+	for _, value := range strings.Split(delaysVal, ",") {
+		delay, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			log.Error.Panicf("invalid KAFKA_RETRY_DELAYS value `%v`. Reason: %v", delaysVal, err)
+		}
+		delays = append(delays, delay)
+	}
+	return RetryConfigs{Delays: delays}
+}
+
+func (c *TopicConfigs) RetryTopic(attempt int) *TopicConfigs {
+	return c.derivedTopic(fmt.Sprintf("%v-retry-%d", c.Topic, attempt))
+}
+
+func (c *TopicConfigs) DeadLetterTopic() *TopicConfigs {
+	return c.derivedTopic(fmt.Sprintf("%v-dlq", c.Topic))
+}
+
+func (c *TopicConfigs) derivedTopic(topic string) *TopicConfigs {
+	derived := *c
+	derived.Brokers = append([]string{}, c.Brokers...)
+	derived.Topic = topic
+	derived.AutoCreateTopic = true
+	return &derived
+}
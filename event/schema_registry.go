@@ -0,0 +1,200 @@
+package event
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mc-burger-orders/log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+type SchemaType string
+
+const (
+	AvroSchema     = SchemaType("AVRO")
+	ProtobufSchema = SchemaType("PROTOBUF")
+	JsonSchema     = SchemaType("JSON")
+)
+
+const schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+
+var ErrIncompatibleSchema = errors.New("schema is incompatible with the schema registered for the subject")
+
+type Schema struct {
+	Type       SchemaType
+	Definition string
+}
+
+type SchemaRegistry interface {
+	// Register returns the id of the schema, registering it as a new version of the subject when it is not yet.
+	// The registry refuses schemas incompatible with the versions registered before with ErrIncompatibleSchema.
+	Register(ctx context.Context, subject string, schema Schema) (int, error)
+	SchemaById(ctx context.Context, id int) (Schema, error)
+}
+
+// SchemaRegistryClient talks to the REST API of the Confluent schema registry. Ids of registered schemas and schemas
+// fetched by id never change, so both are cached.
+type SchemaRegistryClient struct {
+	url        string
+	httpClient *http.Client
+	mu         sync.RWMutex
+	ids        map[string]int
+	schemas    map[int]Schema
+}
+
+type schemaPayload struct {
+	Schema     string     `json:"schema"`
+	SchemaType SchemaType `json:"schemaType,omitempty"`
+}
+
+type schemaIdPayload struct {
+	Id int `json:"id"`
+}
+
+type compatibilityPayload struct {
+	IsCompatible bool `json:"is_compatible"`
+}
+
+type schemaRegistryError struct {
+	ErrorCode int    `json:"error_code"`
+	Message   string `json:"message"`
+}
+
+func NewSchemaRegistryClient(registryUrl string) *SchemaRegistryClient {
+	return &SchemaRegistryClient{
+		url:        strings.TrimSuffix(registryUrl, "/"),
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		ids:        make(map[string]int),
+		schemas:    make(map[int]Schema),
+	}
+}
+
+// SchemaRegistryFromEnv returns the client of the registry at SCHEMA_REGISTRY_URL, or nil when it is not configured.
+func SchemaRegistryFromEnv() *SchemaRegistryClient {
+	registryUrl := os.Getenv("SCHEMA_REGISTRY_URL")
+	if len(registryUrl) == 0 {
+		return nil
+	}
+	log.Info.Println("Using schema registry at", registryUrl)
+	return NewSchemaRegistryClient(registryUrl)
+}
+
+func (c *SchemaRegistryClient) Register(ctx context.Context, subject string, schema Schema) (int, error) {
+	cacheKey := subject + "/" + schema.Definition
+	c.mu.RLock()
+	id, ok := c.ids[cacheKey]
+	c.mu.RUnlock()
+	if ok {
+		return id, nil
+	}
+
+	response := schemaIdPayload{}
+	path := fmt.Sprintf("/subjects/%v/versions", url.PathEscape(subject))
+	if err := c.call(ctx, http.MethodPost, path, newSchemaPayload(schema), &response); err != nil {
+		return 0, fmt.Errorf("failed to register schema of subject %v. Reason: %w", subject, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.ids[cacheKey] = response.Id
+	c.schemas[response.Id] = schema
+	return response.Id, nil
+}
+
+func (c *SchemaRegistryClient) SchemaById(ctx context.Context, id int) (Schema, error) {
+	c.mu.RLock()
+	schema, ok := c.schemas[id]
+	c.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	response := schemaPayload{}
+	if err := c.call(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &response); err != nil {
+		return Schema{}, fmt.Errorf("failed to fetch schema %d. Reason: %w", id, err)
+	}
+
+	// the registry leaves out the type of Avro schemas
+	schema = Schema{Type: response.SchemaType, Definition: response.Schema}
+	if len(schema.Type) == 0 {
+		schema.Type = AvroSchema
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.schemas[id] = schema
+	return schema, nil
+}
+
+// IsCompatible checks the schema against the latest version of the subject without registering it.
+func (c *SchemaRegistryClient) IsCompatible(ctx context.Context, subject string, schema Schema) (bool, error) {
+	response := compatibilityPayload{}
+	path := fmt.Sprintf("/compatibility/subjects/%v/versions/latest", url.PathEscape(subject))
+	err := c.call(ctx, http.MethodPost, path, newSchemaPayload(schema), &response)
+
+	var registryErr *schemaRegistryError
+	if errors.As(err, &registryErr) && registryErr.ErrorCode == 40401 {
+		// nothing registered for the subject yet
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check compatibility of schema of subject %v. Reason: %w", subject, err)
+	}
+	return response.IsCompatible, nil
+}
+
+func newSchemaPayload(schema Schema) schemaPayload {
+	payload := schemaPayload{Schema: schema.Definition, SchemaType: schema.Type}
+	if schema.Type == AvroSchema {
+		payload.SchemaType = ""
+	}
+	return payload
+}
+
+func (c *SchemaRegistryClient) call(ctx context.Context, method string, path string, body any, response any) error {
+	var requestBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		requestBody = bytes.NewReader(payload)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, method, c.url+path, requestBody)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		request.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+
+	httpResponse, err := c.httpClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = httpResponse.Body.Close() }()
+
+	if httpResponse.StatusCode == http.StatusConflict {
+		return ErrIncompatibleSchema
+	}
+	if httpResponse.StatusCode >= http.StatusBadRequest {
+		registryErr := &schemaRegistryError{ErrorCode: httpResponse.StatusCode}
+		_ = json.NewDecoder(httpResponse.Body).Decode(registryErr)
+		return registryErr
+	}
+	return json.NewDecoder(httpResponse.Body).Decode(response)
+}
+
+func (e *schemaRegistryError) Error() string {
+	return fmt.Sprintf("schema registry error %d: %v", e.ErrorCode, e.Message)
+}
@@ -10,22 +10,34 @@ import (
 	"time"
 )
 
+const defaultConsumerGroup = "mc-burger-orders"
+
 type TopicConfigs struct {
 	Controller            string
 	Brokers               []string
 	Topic                 string
 	NumPartitions         int
-	Partition             int
 	ReplicationFactor     int
 	WaitMaxTime           time.Duration
 	AwaitBetweenReadsTime time.Duration
 	AutoCreateTopic       bool
+	Writer                WriterConfigs
+	Reader                ReaderConfigs
+	// ConsumerGroup is shared by all instances of the service, so partitions of the topic are spread among them
+	ConsumerGroup string
+	// Transport carries the messages of the topic, topics without one use the transport set with UseTransport
+	Transport Transport
 }
 
-func NewTopicConfig(topic string, partition int, numPartitionsVal string, replicationFactorVal string) *TopicConfigs {
+func NewTopicConfig(topic string, numPartitionsVal string, replicationFactorVal string) *TopicConfigs {
 	kafkaAddressEnvVal := os.Getenv("KAFKA_ADDRESS")
 	kafkaAddress := strings.Split(kafkaAddressEnvVal, ",")
 
+	consumerGroup := os.Getenv("KAFKA_CONSUMER_GROUP")
+	if len(consumerGroup) == 0 {
+		consumerGroup = defaultConsumerGroup
+	}
+
 	numPartitions := 3
 	replicationFactor := 1
 
@@ -40,10 +52,12 @@ func NewTopicConfig(topic string, partition int, numPartitionsVal string, replic
 		Brokers:               kafkaAddress,
 		Topic:                 topic,
 		NumPartitions:         numPartitions,
-		Partition:             partition,
+		ConsumerGroup:         consumerGroup,
 		ReplicationFactor:     replicationFactor,
 		WaitMaxTime:           2 * time.Second,
 		AwaitBetweenReadsTime: 500 * time.Millisecond,
+		Writer:                WriterConfigsFromEnv(),
+		Reader:                ReaderConfigsFromEnv(),
 	}
 }
 
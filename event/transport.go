@@ -0,0 +1,59 @@
+package event
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"sync"
+)
+
+// Transport carries the messages of topics, readers and writers of a topic reach the broker only through it.
+type Transport interface {
+	// CreateTopic creates the topic when topics are created by the service and the topic does not exist yet.
+	CreateTopic(configuration *TopicConfigs)
+	// Consumer joins the consumer group of the topic, partitions of the topic are spread among the group members.
+	Consumer(configuration *TopicConfigs, groupId string) TopicConsumer
+	Producer(configuration *TopicConfigs) TopicProducer
+	// ReadPartition reads up to limit messages of the partition starting at offset, a negative offset or an offset
+	// removed by retention starts at the first message still kept in the partition. The messages are read without a
+	// consumer group.
+	ReadPartition(ctx context.Context, configuration *TopicConfigs, partition int, offset int64, limit int) ([]kafka.Message, error)
+}
+
+// TopicConsumer returns io.EOF from FetchMessage once it is closed.
+type TopicConsumer interface {
+	FetchMessage(ctx context.Context) (kafka.Message, error)
+	CommitMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+type TopicProducer interface {
+	WriteMessages(ctx context.Context, messages ...kafka.Message) error
+	Close() error
+}
+
+var (
+	transportMu      sync.RWMutex
+	defaultTransport Transport = KafkaTransport{}
+)
+
+// UseTransport sets the transport of topics configured without one, it is meant to be called on start up.
+func UseTransport(transport Transport) {
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	defaultTransport = transport
+}
+
+func (c *TopicConfigs) transport() Transport {
+	if c.Transport != nil {
+		return c.Transport
+	}
+
+	transportMu.RLock()
+	defer transportMu.RUnlock()
+	return defaultTransport
+}
+
+// ReadPartition reads messages of the partition through the transport of the topic, see Transport.ReadPartition.
+func ReadPartition(ctx context.Context, configuration *TopicConfigs, partition int, offset int64, limit int) ([]kafka.Message, error) {
+	return configuration.transport().ReadPartition(ctx, configuration, partition, offset, limit)
+}
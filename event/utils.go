@@ -9,7 +9,7 @@ func TestTopicConfigs(topicName string, brokers ...string) *TopicConfigs {
 		Topic:                 topicName,
 		Brokers:               brokers,
 		NumPartitions:         1,
-		Partition:             0,
+		ConsumerGroup:         "test",
 		ReplicationFactor:     1,
 		WaitMaxTime:           2 * time.Second,
 		AwaitBetweenReadsTime: 500 * time.Millisecond,
@@ -0,0 +1,57 @@
+package event
+
+import (
+	"github.com/spf13/cast"
+	"mc-burger-orders/log"
+	"os"
+	"time"
+)
+
+const (
+	defaultWriterBatchSize  = 100
+	defaultWriterBatchBytes = int64(1048576)
+	defaultWriterLinger     = 10 * time.Millisecond
+)
+
+type WriterConfigs struct {
+	// BatchSize is the number of messages a batch is written with at the latest
+	BatchSize int
+	// BatchBytes limits the size of a single batch
+	BatchBytes int64
+	// Linger is how long messages wait for others to be batched with before the batch is written anyway
+	Linger time.Duration
+}
+
+// WriterConfigsFromEnv reads batching of topic writers from KAFKA_WRITER_BATCH_SIZE, KAFKA_WRITER_BATCH_BYTES and
+// KAFKA_WRITER_LINGER, e.g. `10ms`.
+func WriterConfigsFromEnv() WriterConfigs {
+	configs := WriterConfigs{}
+
+	if batchSizeVal := os.Getenv("KAFKA_WRITER_BATCH_SIZE"); len(batchSizeVal) > 0 {
+		configs.BatchSize = cast.ToInt(batchSizeVal)
+	}
+	if batchBytesVal := os.Getenv("KAFKA_WRITER_BATCH_BYTES"); len(batchBytesVal) > 0 {
+		configs.BatchBytes = cast.ToInt64(batchBytesVal)
+	}
+	if lingerVal := os.Getenv("KAFKA_WRITER_LINGER"); len(lingerVal) > 0 {
+		linger, err := time.ParseDuration(lingerVal)
+		if err != nil {
+			log.Error.Panicf("invalid KAFKA_WRITER_LINGER value `%v`. Reason: %v", lingerVal, err)
+		}
+		configs.Linger = linger
+	}
+	return configs.withDefaults()
+}
+
+func (c WriterConfigs) withDefaults() WriterConfigs {
+	if c.BatchSize <= 0 {
+		c.BatchSize = defaultWriterBatchSize
+	}
+	if c.BatchBytes <= 0 {
+		c.BatchBytes = defaultWriterBatchBytes
+	}
+	if c.Linger <= 0 {
+		c.Linger = defaultWriterLinger
+	}
+	return c
+}
@@ -0,0 +1,76 @@
+package eventstore
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// InMemoryRepository keeps streams in process and checks expected versions like RepositoryImpl.
+type InMemoryRepository struct {
+	mu        sync.RWMutex
+	streams   map[string][]Record
+	snapshots map[string]Snapshot
+}
+
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{streams: make(map[string][]Record), snapshots: make(map[string]Snapshot)}
+}
+
+func (r *InMemoryRepository) Append(_ context.Context, streamId string, expectedVersion int64, records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	version := r.snapshots[streamId].Version
+	if stream := r.streams[streamId]; len(stream) > 0 {
+		version = stream[len(stream)-1].Version
+	}
+	if version != expectedVersion {
+		return fmt.Errorf("%w: %v is at version %d, expected %d", ErrWrongExpectedVersion, streamId, version, expectedVersion)
+	}
+
+	for i, record := range records {
+		record.StreamId = streamId
+		record.Version = expectedVersion + int64(i) + 1
+		r.streams[streamId] = append(r.streams[streamId], record)
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) Load(_ context.Context, streamId string, afterVersion int64) ([]Record, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stream := r.streams[streamId]
+	index := slices.IndexFunc(stream, func(record Record) bool { return record.Version > afterVersion })
+	if index < 0 {
+		return make([]Record, 0), nil
+	}
+	return slices.Clone(stream[index:]), nil
+}
+
+func (r *InMemoryRepository) SaveSnapshot(_ context.Context, snapshot Snapshot) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if current, exists := r.snapshots[snapshot.StreamId]; !exists || current.Version < snapshot.Version {
+		r.snapshots[snapshot.StreamId] = snapshot
+	}
+	return nil
+}
+
+func (r *InMemoryRepository) LoadSnapshot(_ context.Context, streamId string) (*Snapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot, exists := r.snapshots[streamId]
+	if !exists {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
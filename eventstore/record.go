@@ -0,0 +1,53 @@
+package eventstore
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+var ErrWrongExpectedVersion = errors.New("stream was appended to concurrently")
+
+// Record is an event of a stream, Version is its position in the stream starting at 1.
+type Record struct {
+	Id         *primitive.ObjectID `bson:"_id,omitempty"`
+	StreamId   string              `bson:"streamId"`
+	Version    int64               `bson:"version"`
+	Type       string              `bson:"type"`
+	Data       bson.Raw            `bson:"data"`
+	RecordedAt time.Time           `bson:"recordedAt"`
+}
+
+// Snapshot is the state of a stream folded up to Version, streams are read from their latest snapshot on.
+type Snapshot struct {
+	StreamId string    `bson:"_id"`
+	Version  int64     `bson:"version"`
+	State    bson.Raw  `bson:"state"`
+	TakenAt  time.Time `bson:"takenAt"`
+}
+
+func NewRecord(eventType string, data any, recordedAt time.Time) (Record, error) {
+	raw, err := bson.Marshal(data)
+	if err != nil {
+		return Record{}, fmt.Errorf("cannot encode %v event. Reason: %w", eventType, err)
+	}
+	return Record{Type: eventType, Data: raw, RecordedAt: recordedAt}, nil
+}
+
+func NewSnapshot(streamId string, version int64, state any) (Snapshot, error) {
+	raw, err := bson.Marshal(state)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("cannot encode snapshot of %v. Reason: %w", streamId, err)
+	}
+	return Snapshot{StreamId: streamId, Version: version, State: raw, TakenAt: time.Now()}, nil
+}
+
+func (r Record) Decode(data any) error {
+	return bson.Unmarshal(r.Data, data)
+}
+
+func (s Snapshot) Decode(state any) error {
+	return bson.Unmarshal(s.State, state)
+}
@@ -0,0 +1,156 @@
+package eventstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/log"
+	"time"
+)
+
+type Store interface {
+	// Append adds the records to the end of the stream when the stream is still at the expected version, otherwise
+	// it returns ErrWrongExpectedVersion.
+	Append(ctx context.Context, streamId string, expectedVersion int64, records ...Record) error
+	// Load returns the records of the stream following the version in the order they were appended in.
+	Load(ctx context.Context, streamId string, afterVersion int64) ([]Record, error)
+	SaveSnapshot(ctx context.Context, snapshot Snapshot) error
+	// LoadSnapshot returns the latest snapshot of the stream, or nil when none was taken.
+	LoadSnapshot(ctx context.Context, streamId string) (*Snapshot, error)
+}
+
+type RepositoryImpl struct {
+	events    *mongo.Collection
+	snapshots *mongo.Collection
+}
+
+func NewRepository(database *mongo.Database) *RepositoryImpl {
+	events := database.Collection("events")
+	createStreamIndex(events)
+	return &RepositoryImpl{events: events, snapshots: database.Collection("snapshots")}
+}
+
+// createStreamIndex keeps one record at every position of a stream, so concurrent appends to a stream conflict.
+func createStreamIndex(c *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "streamId", Value: 1}, {Key: "version", Value: 1}},
+		Options: options.Index().SetName("unique-stream-version").SetUnique(true),
+	}
+	if _, err := c.Indexes().CreateOne(ctx, indexModel); err != nil {
+		log.Error.Println("Error when creating event stream index", err)
+	}
+}
+
+// Append stores the records, called with the session context of a transaction the records are stored only when
+// the transaction commits.
+func (r *RepositoryImpl) Append(ctx context.Context, streamId string, expectedVersion int64, records ...Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	version, err := r.streamVersion(ctx, streamId)
+	if err != nil {
+		return err
+	}
+	if version != expectedVersion {
+		return fmt.Errorf("%w: %v is at version %d, expected %d", ErrWrongExpectedVersion, streamId, version, expectedVersion)
+	}
+
+	documents := make([]interface{}, 0)
+	for i, record := range records {
+		record.StreamId = streamId
+		record.Version = expectedVersion + int64(i) + 1
+		documents = append(documents, record)
+	}
+	if _, err = r.events.InsertMany(ctx, documents); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return fmt.Errorf("%w: %v was appended to after version %d", ErrWrongExpectedVersion, streamId, expectedVersion)
+		}
+		log.Error.Println("Error when appending events to stream", streamId, err)
+		return err
+	}
+	return nil
+}
+
+// streamVersion is the version of the last record of the stream, or of its snapshot when the stream starts from one.
+func (r *RepositoryImpl) streamVersion(ctx context.Context, streamId string) (int64, error) {
+	last := Record{}
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})
+	err := r.events.FindOne(ctx, bson.D{{Key: "streamId", Value: streamId}}, findOptions).Decode(&last)
+	if err == nil {
+		return last.Version, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		log.Error.Println("Error when reading version of stream", streamId, err)
+		return 0, err
+	}
+
+	snapshot, err := r.LoadSnapshot(ctx, streamId)
+	if err != nil || snapshot == nil {
+		return 0, err
+	}
+	return snapshot.Version, nil
+}
+
+func (r *RepositoryImpl) Load(ctx context.Context, streamId string, afterVersion int64) ([]Record, error) {
+	filterDef := bson.D{
+		{Key: "streamId", Value: streamId},
+		{Key: "version", Value: bson.D{{Key: "$gt", Value: afterVersion}}},
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "version", Value: 1}})
+
+	cursor, err := r.events.Find(ctx, filterDef, findOptions)
+	if err != nil {
+		log.Error.Println("Error when loading events of stream", streamId, err)
+		return nil, err
+	}
+
+	records := make([]Record, 0)
+	if err = cursor.All(ctx, &records); err != nil {
+		log.Error.Println("Error when reading events of stream", streamId, err)
+		return nil, err
+	}
+	return records, nil
+}
+
+// SaveSnapshot replaces the snapshot of the stream unless a later one was saved already. The saved version is checked
+// with a read, a duplicate key error of an upsert would abort the transaction the snapshot is saved in.
+func (r *RepositoryImpl) SaveSnapshot(ctx context.Context, snapshot Snapshot) error {
+	current, err := r.LoadSnapshot(ctx, snapshot.StreamId)
+	if err != nil {
+		return err
+	}
+	if current != nil && current.Version >= snapshot.Version {
+		return nil
+	}
+
+	filterDef := bson.D{{Key: "_id", Value: snapshot.StreamId}}
+	if current != nil {
+		filterDef = append(filterDef, bson.E{Key: "version", Value: current.Version})
+	}
+	_, err = r.snapshots.ReplaceOne(ctx, filterDef, snapshot, options.Replace().SetUpsert(current == nil))
+	if err != nil {
+		log.Error.Println("Error when saving snapshot of stream", snapshot.StreamId, err)
+		return err
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) LoadSnapshot(ctx context.Context, streamId string) (*Snapshot, error) {
+	snapshot := &Snapshot{}
+	err := r.snapshots.FindOne(ctx, bson.D{{Key: "_id", Value: streamId}}).Decode(snapshot)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error.Println("Error when loading snapshot of stream", streamId, err)
+		return nil, err
+	}
+	return snapshot, nil
+}
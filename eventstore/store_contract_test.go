@@ -0,0 +1,164 @@
+package eventstore
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"mc-burger-orders/testing/utils"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRepository_Contract(t *testing.T) {
+	runStoreContract(t, func(t *testing.T) Store {
+		return NewInMemoryRepository()
+	})
+}
+
+func TestIntegrationRepository_Contract(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+	mongoContainer, database := utils.TestWithMongo(t, ctx)
+
+	runStoreContract(t, func(t *testing.T) Store {
+		utils.DeleteMany(t, database.Collection("events"), bson.D{})
+		utils.DeleteMany(t, database.Collection("snapshots"), bson.D{})
+		return NewRepository(database)
+	})
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+type testEvent struct {
+	Name string `bson:"name"`
+}
+
+// runStoreContract runs the cases every Store has to pass, newStore returns an empty store.
+func runStoreContract(t *testing.T, newStore func(t *testing.T) Store) {
+	t.Run("should load records in order they were appended in", func(t *testing.T) {
+		shouldLoadRecordsInOrderTheyWereAppendedIn(t, newStore(t))
+	})
+	t.Run("should reject append when stream is not at expected version", func(t *testing.T) {
+		shouldRejectAppendWhenStreamIsNotAtExpectedVersion(t, newStore(t))
+	})
+	t.Run("should continue stream from its snapshot", func(t *testing.T) {
+		shouldContinueStreamFromItsSnapshot(t, newStore(t))
+	})
+	t.Run("should keep latest snapshot", func(t *testing.T) {
+		shouldKeepLatestSnapshot(t, newStore(t))
+	})
+	t.Run("should replace earlier snapshot", func(t *testing.T) {
+		shouldReplaceEarlierSnapshot(t, newStore(t))
+	})
+}
+
+func shouldLoadRecordsInOrderTheyWereAppendedIn(t *testing.T, sut Store) {
+	// given
+	ctx := context.Background()
+	assert.NoError(t, sut.Append(ctx, "order-1", 0, givenRecord(t, "first"), givenRecord(t, "second")))
+	assert.NoError(t, sut.Append(ctx, "order-1", 2, givenRecord(t, "third")))
+	assert.NoError(t, sut.Append(ctx, "order-2", 0, givenRecord(t, "other")))
+
+	// when
+	records, err := sut.Load(ctx, "order-1", 1)
+
+	// then
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, []int64{2, 3}, []int64{records[0].Version, records[1].Version})
+	assert.Equal(t, "order-1", records[0].StreamId)
+
+	// and
+	decoded := testEvent{}
+	assert.NoError(t, records[1].Decode(&decoded))
+	assert.Equal(t, "third", decoded.Name)
+}
+
+func shouldRejectAppendWhenStreamIsNotAtExpectedVersion(t *testing.T, sut Store) {
+	// given
+	ctx := context.Background()
+	assert.NoError(t, sut.Append(ctx, "order-1", 0, givenRecord(t, "first")))
+
+	// when
+	outdatedErr := sut.Append(ctx, "order-1", 0, givenRecord(t, "concurrent"))
+	aheadErr := sut.Append(ctx, "order-1", 2, givenRecord(t, "ahead"))
+
+	// then
+	assert.ErrorIs(t, outdatedErr, ErrWrongExpectedVersion)
+	assert.ErrorIs(t, aheadErr, ErrWrongExpectedVersion)
+
+	// and
+	records, err := sut.Load(ctx, "order-1", 0)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+}
+
+func shouldContinueStreamFromItsSnapshot(t *testing.T, sut Store) {
+	// given
+	ctx := context.Background()
+	snapshot, err := NewSnapshot("order-1", 5, testEvent{Name: "adopted"})
+	assert.NoError(t, err)
+	assert.NoError(t, sut.SaveSnapshot(ctx, snapshot))
+
+	// when
+	err = sut.Append(ctx, "order-1", 5, givenRecord(t, "sixth"))
+
+	// then
+	assert.NoError(t, err)
+	records, err := sut.Load(ctx, "order-1", 5)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, int64(6), records[0].Version)
+}
+
+func shouldKeepLatestSnapshot(t *testing.T, sut Store) {
+	// given
+	ctx := context.Background()
+	latest, _ := NewSnapshot("order-1", 40, testEvent{Name: "latest"})
+	earlier, _ := NewSnapshot("order-1", 20, testEvent{Name: "earlier"})
+
+	// when
+	assert.NoError(t, sut.SaveSnapshot(ctx, latest))
+	assert.NoError(t, sut.SaveSnapshot(ctx, earlier))
+
+	// then
+	loaded, err := sut.LoadSnapshot(ctx, "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(40), loaded.Version)
+
+	// and
+	missing, err := sut.LoadSnapshot(ctx, "order-2")
+	assert.NoError(t, err)
+	assert.Nil(t, missing)
+}
+
+func shouldReplaceEarlierSnapshot(t *testing.T, sut Store) {
+	// given
+	ctx := context.Background()
+	earlier, _ := NewSnapshot("order-1", 20, testEvent{Name: "earlier"})
+	latest, _ := NewSnapshot("order-1", 40, testEvent{Name: "latest"})
+	assert.NoError(t, sut.SaveSnapshot(ctx, earlier))
+
+	// when
+	err := sut.SaveSnapshot(ctx, latest)
+
+	// then
+	assert.NoError(t, err)
+	loaded, err := sut.LoadSnapshot(ctx, "order-1")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(40), loaded.Version)
+
+	// and
+	decoded := testEvent{}
+	assert.NoError(t, loaded.Decode(&decoded))
+	assert.Equal(t, "latest", decoded.Name)
+}
+
+func givenRecord(t *testing.T, name string) Record {
+	record, err := NewRecord("test-event", testEvent{Name: name}, time.Now())
+	assert.NoError(t, err)
+	return record
+}
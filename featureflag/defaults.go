@@ -0,0 +1,35 @@
+package featureflag
+
+import (
+	"encoding/json"
+	"mc-burger-orders/log"
+	"os"
+	"strings"
+)
+
+// DefaultsFromEnv reads defaults of flags from the JSON file at FEATURE_FLAGS_FILE, e.g. `{"shelf-favorites-par": "8"}`,
+// and from env variables named after the flags, e.g. SHELF_FAVORITES_PAR. Env variables take precedence over the file.
+func DefaultsFromEnv(definitions ...Definition) map[string]string {
+	defaults := make(map[string]string)
+
+	if path := os.Getenv("FEATURE_FLAGS_FILE"); len(path) > 0 {
+		content, err := os.ReadFile(path)
+		if err != nil {
+			log.Error.Panicf("error when reading feature flags file `%v`. Reason: %s", path, err)
+		}
+		if err = json.Unmarshal(content, &defaults); err != nil {
+			log.Error.Panicf("invalid feature flags file `%v`. Reason: %s", path, err)
+		}
+	}
+
+	for _, definition := range definitions {
+		if value := os.Getenv(envNameOf(definition.Name())); len(value) > 0 {
+			defaults[definition.Name()] = value
+		}
+	}
+	return defaults
+}
+
+func envNameOf(flagName string) string {
+	return strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
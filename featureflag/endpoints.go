@@ -0,0 +1,55 @@
+package featureflag
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mc-burger-orders/log"
+	"mc-burger-orders/middleware"
+	"mc-burger-orders/testing/utils"
+	"net/http"
+)
+
+type SetFlagRequest struct {
+	Value string `json:"value" binding:"required"`
+}
+
+type Endpoints struct {
+	flags *Flags
+}
+
+func NewFeatureFlagEndpoints(flags *Flags) middleware.EndpointsSetup {
+	return &Endpoints{flags: flags}
+}
+
+func (e *Endpoints) Setup(r *gin.Engine) {
+	r.GET("/admin/feature-flags", e.listFlagsHandler)
+	r.PUT("/admin/feature-flags/:name", e.setFlagHandler)
+}
+
+func (e *Endpoints) listFlagsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, e.flags.List())
+}
+
+func (e *Endpoints) setFlagHandler(c *gin.Context) {
+	request := &SetFlagRequest{}
+	if err := c.ShouldBindJSON(request); err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(err.Error()))
+		return
+	}
+
+	name := c.Param("name")
+	status, err := e.flags.Set(c, name, request.Value)
+	switch {
+	case errors.Is(err, ErrUnknownFlag):
+		errMessage := fmt.Sprintf("feature flag %v does not exist", name)
+		c.JSON(http.StatusNotFound, utils.ErrorPayload(errMessage))
+	case errors.Is(err, ErrInvalidValue):
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(err.Error()))
+	case err != nil:
+		log.Error.Println("failed to set feature flag", name, err)
+		c.JSON(http.StatusInternalServerError, utils.ErrorPayload(err.Error()))
+	default:
+		c.JSON(http.StatusOK, status)
+	}
+}
@@ -0,0 +1,94 @@
+package featureflag
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func givenFeatureFlagEndpoints(flags *Flags) *gin.Engine {
+	engine := gin.Default()
+	NewFeatureFlagEndpoints(flags).Setup(engine)
+	return engine
+}
+
+func TestFeatureFlagEndpoints(t *testing.T) {
+	t.Run("should list flags with their values", shouldListFlagsWithTheirValues)
+	t.Run("should flip flag", shouldFlipFlag)
+	t.Run("should return BAD REQUEST when value is invalid", shouldReturnBadRequestWhenValueIsInvalid)
+	t.Run("should return NOT FOUND when flag does not exist", shouldReturnNotFoundWhenFlagDoesNotExist)
+}
+
+func shouldListFlagsWithTheirValues(t *testing.T) {
+	// given
+	engine := givenFeatureFlagEndpoints(givenFlags(NewInMemoryRepository(), map[string]string{"test-par": "7"}))
+
+	req, _ := http.NewRequest("GET", "/admin/feature-flags", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var statuses []FlagStatus
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, 4)
+	assert.Equal(t, FlagStatus{Name: "test-par", Description: "par of the test", Value: "7", DefaultValue: "7"}, statuses[1])
+}
+
+func shouldFlipFlag(t *testing.T) {
+	// given
+	flags := givenFlags(NewInMemoryRepository(), map[string]string{})
+	engine := givenFeatureFlagEndpoints(flags)
+
+	req, _ := http.NewRequest("PUT", "/admin/feature-flags/test-toggle", bytes.NewBufferString(`{"value": "false"}`))
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.False(t, testToggleFlag.Get(flags))
+
+	var status FlagStatus
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &status))
+	assert.Equal(t, "false", status.Value)
+	assert.Equal(t, "true", status.DefaultValue)
+}
+
+func shouldReturnBadRequestWhenValueIsInvalid(t *testing.T) {
+	// given
+	flags := givenFlags(NewInMemoryRepository(), map[string]string{})
+	engine := givenFeatureFlagEndpoints(flags)
+
+	req, _ := http.NewRequest("PUT", "/admin/feature-flags/test-policy", bytes.NewBufferString(`{"value": "random"}`))
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusBadRequest, resp.Code)
+	assert.Equal(t, "fifo", testPolicyFlag.Get(flags))
+}
+
+func shouldReturnNotFoundWhenFlagDoesNotExist(t *testing.T) {
+	// given
+	engine := givenFeatureFlagEndpoints(givenFlags(NewInMemoryRepository(), map[string]string{}))
+
+	req, _ := http.NewRequest("PUT", "/admin/feature-flags/unknown", bytes.NewBufferString(`{"value": "1"}`))
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
@@ -0,0 +1,36 @@
+package featureflag
+
+import (
+	"mc-burger-orders/event"
+	"mc-burger-orders/log"
+	"os"
+)
+
+var (
+	FeatureFlagChangedEvent = "feature-flag-changed"
+)
+
+type FlagChangedPayload struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// TopicConfigsFromEnv configures the topic of flag changes. Unlike other topics, every instance of the service reads
+// it in its own consumer group, so every instance sees every change.
+func TopicConfigsFromEnv() *event.TopicConfigs {
+	topic := os.Getenv("KAFKA_TOPICS__FEATURE_FLAGS_TOPIC_NAME")
+	if len(topic) <= 0 {
+		log.Error.Panicf("Kafka Topic `feature flags` name is missing")
+	}
+
+	numPartitionsVal := os.Getenv("KAFKA_TOPICS__FEATURE_FLAGS_NUMBER_OF_PARTITIONS")
+	replicationFactorVal := os.Getenv("KAFKA_TOPICS__FEATURE_FLAGS_REPLICA_FACTOR")
+
+	configuration := event.NewTopicConfig(topic, numPartitionsVal, replicationFactorVal)
+	instance, err := os.Hostname()
+	if err != nil {
+		log.Error.Panicf("error when reading host name of the instance. Reason: %s", err)
+	}
+	configuration.ConsumerGroup = configuration.ConsumerGroup + "-" + instance
+	return configuration
+}
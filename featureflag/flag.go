@@ -0,0 +1,88 @@
+package featureflag
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Definition describes a flag independently of the type of its value, values of flags are kept as text.
+type Definition interface {
+	Name() string
+	Description() string
+	// DefaultValue is the value of the flag set in code, defaults from env or file replace it
+	DefaultValue() string
+	// Validate tells why the value cannot be set on the flag
+	Validate(value string) error
+}
+
+// Flag is a typed flag, its value is read with Get from the flags it is defined in.
+type Flag[T any] struct {
+	name         string
+	description  string
+	defaultValue T
+	parse        func(value string) (T, error)
+	format       func(value T) string
+}
+
+func Bool(name string, defaultValue bool, description string) *Flag[bool] {
+	return &Flag[bool]{name: name, description: description, defaultValue: defaultValue, parse: strconv.ParseBool, format: strconv.FormatBool}
+}
+
+func Int(name string, defaultValue int, description string) *Flag[int] {
+	return &Flag[int]{name: name, description: description, defaultValue: defaultValue, parse: strconv.Atoi, format: strconv.Itoa}
+}
+
+// Duration flags take values like `30s`.
+func Duration(name string, defaultValue time.Duration, description string) *Flag[time.Duration] {
+	format := func(value time.Duration) string { return value.String() }
+	return &Flag[time.Duration]{name: name, description: description, defaultValue: defaultValue, parse: time.ParseDuration, format: format}
+}
+
+// Enum flags take one of the allowed values.
+func Enum(name string, defaultValue string, description string, allowed ...string) *Flag[string] {
+	parse := func(value string) (string, error) {
+		if !slices.Contains(allowed, value) {
+			return "", fmt.Errorf("`%v` is not one of %v", value, strings.Join(allowed, ", "))
+		}
+		return value, nil
+	}
+	format := func(value string) string { return value }
+	return &Flag[string]{name: name, description: description, defaultValue: defaultValue, parse: parse, format: format}
+}
+
+func (f *Flag[T]) Name() string {
+	return f.name
+}
+
+func (f *Flag[T]) Description() string {
+	return f.description
+}
+
+func (f *Flag[T]) DefaultValue() string {
+	return f.format(f.defaultValue)
+}
+
+func (f *Flag[T]) Validate(value string) error {
+	_, err := f.parse(value)
+	return err
+}
+
+// Get returns the current value of the flag, the default set in code when flags is nil or the flag is not defined
+// in it.
+func (f *Flag[T]) Get(flags *Flags) T {
+	if flags == nil {
+		return f.defaultValue
+	}
+	value, exists := flags.valueOf(f.name)
+	if !exists {
+		return f.defaultValue
+	}
+	parsed, err := f.parse(value)
+	if err != nil {
+		return f.defaultValue
+	}
+	return parsed
+}
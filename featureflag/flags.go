@@ -0,0 +1,167 @@
+package featureflag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mc-burger-orders/event"
+	"mc-burger-orders/log"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	ErrUnknownFlag  = errors.New("feature flag does not exist")
+	ErrInvalidValue = errors.New("invalid feature flag value")
+)
+
+// FlagStatus is the current value of a flag, ModifiedAt is set when the value was set at runtime.
+type FlagStatus struct {
+	Name         string     `json:"name"`
+	Description  string     `json:"description"`
+	Value        string     `json:"value"`
+	DefaultValue string     `json:"defaultValue"`
+	ModifiedAt   *time.Time `json:"modifiedAt,omitempty"`
+}
+
+// Flags holds the values of the defined flags. A value set at runtime is stored in the repository and announced with
+// a change event, so every instance of the service reloads it. Flags without one take their default, from env or
+// file when given there, from the definition otherwise.
+type Flags struct {
+	mu          sync.RWMutex
+	definitions map[string]Definition
+	defaults    map[string]string
+	values      map[string]StoredValue
+	repository  FlagRepository
+	writer      *event.DefaultWriter
+}
+
+func NewFlags(repository FlagRepository, defaults map[string]string, definitions ...Definition) *Flags {
+	flags := &Flags{
+		definitions: make(map[string]Definition),
+		defaults:    make(map[string]string),
+		values:      make(map[string]StoredValue),
+		repository:  repository,
+	}
+	for _, definition := range definitions {
+		flags.definitions[definition.Name()] = definition
+		flags.defaults[definition.Name()] = definition.DefaultValue()
+
+		value, exists := defaults[definition.Name()]
+		if !exists {
+			continue
+		}
+		if err := definition.Validate(value); err != nil {
+			log.Error.Printf("Default of feature flag %v is ignored. Reason: %v", definition.Name(), err)
+			continue
+		}
+		flags.defaults[definition.Name()] = value
+	}
+	return flags
+}
+
+// ConfigureWriter sets the writer of change events, without it changes are not announced to other instances.
+func (f *Flags) ConfigureWriter(writer *event.DefaultWriter) {
+	f.writer = writer
+}
+
+// Load replaces the values set at runtime with the ones in the repository.
+func (f *Flags) Load(ctx context.Context) error {
+	stored, err := f.repository.FetchAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	values := make(map[string]StoredValue)
+	for name, value := range stored {
+		definition, defined := f.definitions[name]
+		if !defined {
+			continue
+		}
+		if err = definition.Validate(value.Value); err != nil {
+			log.Error.Printf("Stored value of feature flag %v is ignored. Reason: %v", name, err)
+			continue
+		}
+		values[name] = value
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.values = values
+	return nil
+}
+
+// Set stores the value of the flag and announces the change to every instance. The value is set once it is stored,
+// when the announcement fails other instances pick it up on their next Load.
+func (f *Flags) Set(ctx context.Context, name string, value string) (FlagStatus, error) {
+	definition, defined := f.definitions[name]
+	if !defined {
+		return FlagStatus{}, ErrUnknownFlag
+	}
+	if err := definition.Validate(value); err != nil {
+		return FlagStatus{}, fmt.Errorf("%w: %v", ErrInvalidValue, err)
+	}
+
+	stored := StoredValue{Name: name, Value: value, ModifiedAt: time.Now().Truncate(time.Millisecond).UTC()}
+	if err := f.repository.Save(ctx, stored); err != nil {
+		return FlagStatus{}, err
+	}
+
+	f.mu.Lock()
+	f.values[name] = stored
+	f.mu.Unlock()
+	log.Warning.Printf("Feature flag %v is set to `%v`", name, value)
+
+	if err := f.announce(ctx, stored); err != nil {
+		log.Warning.Printf("Change of feature flag %v was not announced to other instances. Reason: %v", name, err)
+	}
+	return f.statusOf(definition), nil
+}
+
+// List returns the flags by name.
+func (f *Flags) List() []FlagStatus {
+	statuses := make([]FlagStatus, 0, len(f.definitions))
+	for _, definition := range f.definitions {
+		statuses = append(statuses, f.statusOf(definition))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+func (f *Flags) statusOf(definition Definition) FlagStatus {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	status := FlagStatus{Name: definition.Name(), Description: definition.Description(), DefaultValue: f.defaults[definition.Name()]}
+	status.Value = status.DefaultValue
+	if stored, exists := f.values[definition.Name()]; exists {
+		status.Value = stored.Value
+		status.ModifiedAt = &stored.ModifiedAt
+	}
+	return status
+}
+
+func (f *Flags) valueOf(name string) (string, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if stored, exists := f.values[name]; exists {
+		return stored.Value, true
+	}
+	value, exists := f.defaults[name]
+	return value, exists
+}
+
+func (f *Flags) announce(ctx context.Context, stored StoredValue) error {
+	if f.writer == nil {
+		return nil
+	}
+
+	envelope := event.NewEnvelope(ctx, FeatureFlagChangedEvent, event.Source("feature-flags"))
+	message, err := event.EncodeMessage(ctx, event.FlagKey(stored.Name), envelope, FlagChangedPayload{Name: stored.Name, Value: stored.Value})
+	if err != nil {
+		return err
+	}
+	return f.writer.SendMessage(ctx, message)
+}
@@ -0,0 +1,176 @@
+package featureflag
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/event"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	testPolicyFlag = Enum("test-policy", "fifo", "policy of the test", "fifo", "aging")
+	testParFlag    = Int("test-par", 5, "par of the test")
+	testBoostFlag  = Duration("test-boost", 30*time.Second, "boost of the test")
+	testToggleFlag = Bool("test-toggle", true, "toggle of the test")
+)
+
+func givenFlags(repository FlagRepository, defaults map[string]string) *Flags {
+	return NewFlags(repository, defaults, testPolicyFlag, testParFlag, testBoostFlag, testToggleFlag)
+}
+
+func TestFlags(t *testing.T) {
+	t.Run("should return default set in code", shouldReturnDefaultSetInCode)
+	t.Run("should return default from env over the one from file", shouldReturnDefaultFromEnvOverTheOneFromFile)
+	t.Run("should ignore invalid default", shouldIgnoreInvalidDefault)
+	t.Run("should set value of flag", shouldSetValueOfFlag)
+	t.Run("should reject invalid or unknown flag", shouldRejectInvalidOrUnknownFlag)
+	t.Run("should load values set on other instance", shouldLoadValuesSetOnOtherInstance)
+	t.Run("should reload flags when change is announced", shouldReloadFlagsWhenChangeIsAnnounced)
+	t.Run("should set value of flag when change is not announced", shouldSetValueOfFlagWhenChangeIsNotAnnounced)
+}
+
+func shouldReturnDefaultSetInCode(t *testing.T) {
+	// given
+	flags := givenFlags(NewInMemoryRepository(), map[string]string{})
+
+	// then
+	assert.Equal(t, "fifo", testPolicyFlag.Get(flags))
+	assert.Equal(t, 5, testParFlag.Get(flags))
+	assert.Equal(t, 30*time.Second, testBoostFlag.Get(flags))
+	assert.True(t, testToggleFlag.Get(flags))
+
+	// and
+	assert.Equal(t, 5, testParFlag.Get(nil))
+}
+
+func shouldReturnDefaultFromEnvOverTheOneFromFile(t *testing.T) {
+	// given
+	path := filepath.Join(t.TempDir(), "feature-flags.json")
+	assert.NoError(t, os.WriteFile(path, []byte(`{"test-par": "7", "test-toggle": "false"}`), 0600))
+	t.Setenv("FEATURE_FLAGS_FILE", path)
+	t.Setenv("TEST_PAR", "9")
+
+	// when
+	defaults := DefaultsFromEnv(testPolicyFlag, testParFlag, testBoostFlag, testToggleFlag)
+	flags := givenFlags(NewInMemoryRepository(), defaults)
+
+	// then
+	assert.Equal(t, 9, testParFlag.Get(flags))
+	assert.False(t, testToggleFlag.Get(flags))
+	assert.Equal(t, "fifo", testPolicyFlag.Get(flags))
+}
+
+func shouldIgnoreInvalidDefault(t *testing.T) {
+	// given
+	flags := givenFlags(NewInMemoryRepository(), map[string]string{"test-policy": "random", "test-boost": "soon"})
+
+	// then
+	assert.Equal(t, "fifo", testPolicyFlag.Get(flags))
+	assert.Equal(t, 30*time.Second, testBoostFlag.Get(flags))
+}
+
+func shouldSetValueOfFlag(t *testing.T) {
+	// given
+	repository := NewInMemoryRepository()
+	flags := givenFlags(repository, map[string]string{"test-par": "7"})
+
+	// when
+	status, err := flags.Set(context.Background(), "test-par", "12")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "12", status.Value)
+	assert.Equal(t, "7", status.DefaultValue)
+	assert.NotNil(t, status.ModifiedAt)
+	assert.Equal(t, 12, testParFlag.Get(flags))
+
+	// and
+	stored, _ := repository.FetchAll(context.Background())
+	assert.Equal(t, "12", stored["test-par"].Value)
+}
+
+func shouldRejectInvalidOrUnknownFlag(t *testing.T) {
+	// given
+	repository := NewInMemoryRepository()
+	flags := givenFlags(repository, map[string]string{})
+
+	// when
+	_, invalidErr := flags.Set(context.Background(), "test-par", "many")
+	_, unknownErr := flags.Set(context.Background(), "unknown", "1")
+
+	// then
+	assert.ErrorIs(t, invalidErr, ErrInvalidValue)
+	assert.ErrorIs(t, unknownErr, ErrUnknownFlag)
+
+	// and
+	stored, _ := repository.FetchAll(context.Background())
+	assert.Empty(t, stored)
+}
+
+func shouldLoadValuesSetOnOtherInstance(t *testing.T) {
+	// given
+	repository := NewInMemoryRepository()
+	flags := givenFlags(repository, map[string]string{})
+	_, err := givenFlags(repository, map[string]string{}).Set(context.Background(), "test-policy", "aging")
+	assert.NoError(t, err)
+
+	// when
+	err = flags.Load(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "aging", testPolicyFlag.Get(flags))
+}
+
+func shouldReloadFlagsWhenChangeIsAnnounced(t *testing.T) {
+	// given
+	repository := NewInMemoryRepository()
+	topicConfigs := event.TestTopicConfigs("feature-flags")
+	topicConfigs.Transport = event.NewMemoryTransport()
+
+	changed := givenFlags(repository, map[string]string{})
+	changed.ConfigureWriter(event.NewTopicWriter(topicConfigs))
+
+	otherInstance := givenFlags(repository, map[string]string{})
+	bus := event.NewInternalEventBus()
+	bus.AddHandler(NewHandler(otherInstance))
+	reader := event.NewTopicReader(topicConfigs, bus)
+	reader.SubscribeToTopic(make(chan kafka.Message))
+	defer reader.Close()
+
+	// when
+	_, err := changed.Set(context.Background(), "test-toggle", "false")
+
+	// then
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return !testToggleFlag.Get(otherInstance) }, 5*time.Second, 10*time.Millisecond)
+}
+
+func shouldSetValueOfFlagWhenChangeIsNotAnnounced(t *testing.T) {
+	// given
+	repository := NewInMemoryRepository()
+	topicConfigs := event.TestTopicConfigs("feature-flags")
+	topicConfigs.Transport = event.NewMemoryTransport()
+
+	writer := event.NewTopicWriter(topicConfigs)
+	assert.NoError(t, writer.Close())
+
+	flags := givenFlags(repository, map[string]string{})
+	flags.ConfigureWriter(writer)
+
+	// when
+	status, err := flags.Set(context.Background(), "test-toggle", "false")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, "false", status.Value)
+	assert.False(t, testToggleFlag.Get(flags))
+
+	// and
+	stored, _ := repository.FetchAll(context.Background())
+	assert.Equal(t, "false", stored["test-toggle"].Value)
+}
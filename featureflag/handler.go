@@ -0,0 +1,43 @@
+package featureflag
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/command"
+	"mc-burger-orders/log"
+)
+
+// Handler reloads the flags when any of them changed, the repository is the source of the values rather than the
+// event, so changes read again or out of order leave the latest value in place.
+type Handler struct {
+	defaultHandler command.DefaultCommandHandler
+	flags          *Flags
+}
+
+func NewHandler(flags *Flags) *Handler {
+	return &Handler{flags: flags, defaultHandler: command.DefaultCommandHandler{}}
+}
+
+func (h *Handler) GetHandledEvents() []string {
+	return []string{FeatureFlagChangedEvent}
+}
+
+func (h *Handler) AddCommands(event string, commands ...command.Command) {
+	h.defaultHandler.AddCommands(event, commands...)
+}
+
+func (h *Handler) GetCommands(_ string, _ kafka.Message) ([]command.Command, error) {
+	return make([]command.Command, 0), nil
+}
+
+func (h *Handler) Handle(ctx context.Context, eventType string, _ kafka.Message) command.Results {
+	if eventType != FeatureFlagChangedEvent {
+		return command.Results{}
+	}
+
+	if err := h.flags.Load(ctx); err != nil {
+		log.Error.Println("failed to reload feature flags", err)
+		return command.Results{command.NewErrorResult(FeatureFlagChangedEvent, err)}
+	}
+	return command.Results{command.NewSuccessfulResult(FeatureFlagChangedEvent)}
+}
@@ -0,0 +1,88 @@
+package featureflag
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/log"
+	"sync"
+	"time"
+)
+
+// StoredValue is a value of a flag set at runtime, it replaces the default of the flag on every instance.
+type StoredValue struct {
+	Name       string    `bson:"_id"`
+	Value      string    `bson:"value"`
+	ModifiedAt time.Time `bson:"modifiedAt"`
+}
+
+type FlagRepository interface {
+	Save(ctx context.Context, value StoredValue) error
+	// FetchAll returns the values set at runtime by flag name.
+	FetchAll(ctx context.Context) (map[string]StoredValue, error)
+}
+
+type RepositoryImpl struct {
+	c *mongo.Collection
+}
+
+func NewRepository(database *mongo.Database) *RepositoryImpl {
+	return &RepositoryImpl{c: database.Collection("feature-flags")}
+}
+
+func (r *RepositoryImpl) Save(ctx context.Context, value StoredValue) error {
+	filterDef := bson.D{{Key: "_id", Value: value.Name}}
+	if _, err := r.c.ReplaceOne(ctx, filterDef, value, options.Replace().SetUpsert(true)); err != nil {
+		log.Error.Println("Error when saving feature flag", value.Name, err)
+		return err
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) FetchAll(ctx context.Context) (map[string]StoredValue, error) {
+	cursor, err := r.c.Find(ctx, bson.D{})
+	if err != nil {
+		log.Error.Println("Error when fetching feature flags", err)
+		return nil, err
+	}
+
+	stored := make([]StoredValue, 0)
+	if err = cursor.All(ctx, &stored); err != nil {
+		log.Error.Println("Error when fetching feature flags", err)
+		return nil, err
+	}
+
+	values := make(map[string]StoredValue)
+	for _, value := range stored {
+		values[value.Name] = value
+	}
+	return values, nil
+}
+
+type InMemoryRepository struct {
+	mu     sync.Mutex
+	values map[string]StoredValue
+}
+
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{values: make(map[string]StoredValue)}
+}
+
+func (r *InMemoryRepository) Save(_ context.Context, value StoredValue) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.values[value.Name] = value
+	return nil
+}
+
+func (r *InMemoryRepository) FetchAll(_ context.Context) (map[string]StoredValue, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	values := make(map[string]StoredValue)
+	for name, value := range r.values {
+		values[name] = value
+	}
+	return values, nil
+}
@@ -0,0 +1,174 @@
+package kitchen
+
+import (
+	"context"
+	"github.com/spf13/cast"
+	"mc-burger-orders/event"
+	"mc-burger-orders/log"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchWindow     = 500 * time.Millisecond
+	defaultBatchMaxSize    = 10
+	defaultBatchMaxPending = 100
+)
+
+type BatchConfigs struct {
+	// Window is how long a batch takes in requests for its item after the first one, batches are not waited for when 0
+	Window time.Duration
+	// MaxSize is the quantity a batch is cooked at without waiting for the window to pass, 0 for no limit
+	MaxSize int
+	// MaxPending is the number of requests waiting to be cooked at most, further requests wait for them
+	MaxPending int
+}
+
+// BatchConfigsFromEnv reads batching of kitchen requests from KITCHEN_BATCH_WINDOW, e.g. `500ms`,
+// KITCHEN_BATCH_MAX_SIZE and KITCHEN_BATCH_MAX_PENDING.
+func BatchConfigsFromEnv() BatchConfigs {
+	configs := BatchConfigs{Window: defaultBatchWindow, MaxSize: defaultBatchMaxSize}
+
+	if windowVal := os.Getenv("KITCHEN_BATCH_WINDOW"); len(windowVal) > 0 {
+		window, err := time.ParseDuration(windowVal)
+		if err != nil {
+			log.Error.Panicf("invalid KITCHEN_BATCH_WINDOW value `%v`. Reason: %v", windowVal, err)
+		}
+		configs.Window = window
+	}
+	if maxSizeVal := os.Getenv("KITCHEN_BATCH_MAX_SIZE"); len(maxSizeVal) > 0 {
+		configs.MaxSize = cast.ToInt(maxSizeVal)
+	}
+	if maxPendingVal := os.Getenv("KITCHEN_BATCH_MAX_PENDING"); len(maxPendingVal) > 0 {
+		configs.MaxPending = cast.ToInt(maxPendingVal)
+	}
+	return configs.withDefaults()
+}
+
+func (c BatchConfigs) withDefaults() BatchConfigs {
+	if c.MaxPending <= 0 {
+		c.MaxPending = defaultBatchMaxPending
+	}
+	return c
+}
+
+// Requester is the event items of a batch were requested with, the items are put on the shelf on its behalf.
+type Requester struct {
+	EventId       string
+	CorrelationId string
+	OrderNumber   *int64
+	Quantity      int
+	RequestedAt   time.Time
+}
+
+// requesterOf reads the requester from the envelope of the request-item event, requests without the time of the event
+// are requested when received.
+func requesterOf(envelope event.Envelope, request ItemRequest) Requester {
+	requester := Requester{
+		EventId:       envelope.Id,
+		CorrelationId: envelope.CorrelationId,
+		OrderNumber:   envelope.OrderNumber,
+		Quantity:      request.Quantity,
+		RequestedAt:   envelope.Time,
+	}
+	if requester.RequestedAt.IsZero() {
+		requester.RequestedAt = time.Now()
+	}
+	return requester
+}
+
+// Context carries the envelope of the request, events of the items made for it are correlated with the request.
+func (r Requester) Context() context.Context {
+	return event.ContextWithEnvelope(context.Background(), event.Envelope{Id: r.EventId, CorrelationId: r.CorrelationId})
+}
+
+// ItemBatch merges requests for the same item, they are cooked together.
+type ItemBatch struct {
+	ItemName   string
+	Quantity   int
+	Requesters []Requester
+	done       chan struct{}
+}
+
+// requestedAt is the time of the oldest request of the batch, the batch is scheduled as if requested then.
+func (b *ItemBatch) requestedAt() time.Time {
+	requestedAt := b.Requesters[0].RequestedAt
+	for _, requester := range b.Requesters[1:] {
+		if requester.RequestedAt.Before(requestedAt) {
+			requestedAt = requester.RequestedAt
+		}
+	}
+	return requestedAt
+}
+
+// KitchenBatcher merges requests for the same item coming within the window into one batch. A batch is handed over to
+// cook once the window passed or the batch reached its max size.
+type KitchenBatcher struct {
+	mu      sync.Mutex
+	configs BatchConfigs
+	open    map[string]*ItemBatch
+	pending chan struct{}
+	cook    func(batch *ItemBatch)
+}
+
+// NewKitchenBatcher hands batches over to cook, cook returns once the batch is handed over and closes the batch when
+// it is prepared.
+func NewKitchenBatcher(configs BatchConfigs, cook func(batch *ItemBatch)) *KitchenBatcher {
+	configs = configs.withDefaults()
+	return &KitchenBatcher{
+		configs: configs,
+		open:    make(map[string]*ItemBatch),
+		pending: make(chan struct{}, configs.MaxPending),
+		cook:    cook,
+	}
+}
+
+// Add merges the request into the open batch of its item, it waits while MaxPending requests wait to be cooked. The
+// returned channel is closed once the batch is prepared.
+func (b *KitchenBatcher) Add(itemName string, requester Requester) <-chan struct{} {
+	b.pending <- struct{}{}
+
+	b.mu.Lock()
+	batch, exists := b.open[itemName]
+	if !exists {
+		batch = &ItemBatch{ItemName: itemName, done: make(chan struct{})}
+		b.open[itemName] = batch
+		if b.configs.Window > 0 {
+			time.AfterFunc(b.configs.Window, func() { b.close(batch) })
+		}
+	}
+	batch.Quantity += requester.Quantity
+	batch.Requesters = append(batch.Requesters, requester)
+	isFull := b.configs.Window <= 0 || (b.configs.MaxSize > 0 && batch.Quantity >= b.configs.MaxSize)
+	b.mu.Unlock()
+
+	if isFull {
+		b.close(batch)
+	}
+	return batch.done
+}
+
+// close hands the batch over to cook unless it was already handed over.
+func (b *KitchenBatcher) close(batch *ItemBatch) {
+	b.mu.Lock()
+	if b.open[batch.ItemName] != batch {
+		b.mu.Unlock()
+		return
+	}
+	delete(b.open, batch.ItemName)
+	b.mu.Unlock()
+
+	if len(batch.Requesters) > 1 {
+		log.Info.Printf("CookRequest: %d requests for %v merged into a batch of %d", len(batch.Requesters), batch.ItemName, batch.Quantity)
+	}
+	b.cook(batch)
+}
+
+// prepared releases the requests of the batch and tells its requesters the items are on the shelf.
+func (b *KitchenBatcher) prepared(batch *ItemBatch) {
+	for range batch.Requesters {
+		<-b.pending
+	}
+	close(batch.done)
+}
@@ -0,0 +1,161 @@
+package kitchen
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/event"
+	"mc-burger-orders/shelf"
+	"mc-burger-orders/shelf/dto"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestKitchenBatcher(t *testing.T) {
+	t.Run("should cook requests for the same item within window as one batch", shouldCookRequestsForTheSameItemWithinWindowAsOneBatch)
+	t.Run("should cook batch once it reaches max size", shouldCookBatchOnceItReachesMaxSize)
+	t.Run("should not merge requests for different items", shouldNotMergeRequestsForDifferentItems)
+	t.Run("should put batch on shelf on behalf of every requester", shouldPutBatchOnShelfOnBehalfOfEveryRequester)
+	t.Run("should return result once items are on shelf", shouldReturnResultOnceItemsAreOnShelf)
+	t.Run("should take preparation time of every round of batch", shouldTakePreparationTimeOfEveryRoundOfBatch)
+}
+
+func shouldCookRequestsForTheSameItemWithinWindowAsOneBatch(t *testing.T) {
+	// given
+	prepMealStub := NewMealPrepService()
+	kitchenShelf := shelf.NewEmptyShelf()
+	handler := newHandler(kitchenShelf, NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{Window: 100 * time.Millisecond})
+	waitGroup := &sync.WaitGroup{}
+
+	// when
+	for _, orderNumber := range []int64{1, 2, 3} {
+		waitGroup.Add(1)
+		go func(orderNumber int64) {
+			defer waitGroup.Done()
+			_, err := handler.CreateNewItem(context.Background(), givenItemRequestMessage(t, orderNumber, "hamburger", 1))
+			assert.NoError(t, err)
+		}(orderNumber)
+	}
+	waitGroup.Wait()
+
+	// then
+	assert.Equal(t, 1, prepMealStub.CalledCnt())
+	assert.True(t, prepMealStub.HaveBeenCalledWith(MealPrepMatchingFnc("hamburger", 3)))
+	assert.Equal(t, 3, kitchenShelf.GetCurrent("hamburger"))
+}
+
+func shouldCookBatchOnceItReachesMaxSize(t *testing.T) {
+	// given
+	prepMealStub := NewMealPrepService()
+	handler := newHandler(shelf.NewEmptyShelf(), NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{Window: time.Hour, MaxSize: 2})
+	waitGroup := &sync.WaitGroup{}
+
+	// when
+	for _, quantity := range []int{1, 1} {
+		waitGroup.Add(1)
+		go func(quantity int) {
+			defer waitGroup.Done()
+			_, err := handler.CreateNewItem(context.Background(), givenItemRequestMessage(t, 1, "fries", quantity))
+			assert.NoError(t, err)
+		}(quantity)
+	}
+
+	// then
+	assert.Eventually(t, func() bool { waitGroup.Wait(); return true }, time.Second, 10*time.Millisecond)
+	assert.True(t, prepMealStub.HaveBeenCalledWith(MealPrepMatchingFnc("fries", 2)))
+}
+
+func shouldNotMergeRequestsForDifferentItems(t *testing.T) {
+	// given
+	prepMealStub := NewMealPrepService()
+	kitchenShelf := shelf.NewEmptyShelf()
+	handler := newHandler(kitchenShelf, NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{Window: 50 * time.Millisecond})
+	message := givenItemRequestMessage(t, 1, "hamburger", 2)
+	otherMessage := givenItemRequestMessage(t, 2, "cheeseburger", 1)
+
+	// when
+	_, err := handler.requestItems(context.Background(), message)
+	assert.NoError(t, err)
+	result, err := handler.CreateNewItem(context.Background(), otherMessage)
+
+	// then
+	assert.True(t, result)
+	assert.NoError(t, err)
+	assert.Eventually(t, func() bool { return kitchenShelf.GetCurrent("hamburger") == 2 }, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 1, kitchenShelf.GetCurrent("cheeseburger"))
+}
+
+func shouldPutBatchOnShelfOnBehalfOfEveryRequester(t *testing.T) {
+	// given
+	shelfConfig := event.TestTopicConfigs("shelf-events")
+	shelfConfig.Transport = event.NewMemoryTransport()
+	kitchenShelf := shelf.NewEmptyShelf()
+	kitchenShelf.ConfigureWriter(event.NewTopicWriter(shelfConfig))
+	shelfEvents := shelfConfig.Transport.Consumer(shelfConfig, "orders")
+	defer shelfEvents.Close()
+
+	handler := newHandler(kitchenShelf, NewKitchenStations(NewFifoPolicy(), nil), NewMealPrepService(), BatchConfigs{Window: 100 * time.Millisecond})
+	first := givenItemRequestMessage(t, 1, "hamburger", 1)
+	second := givenItemRequestMessage(t, 2, "hamburger", 2)
+
+	// when
+	firstPrepared, err := handler.requestItems(context.Background(), first)
+	assert.NoError(t, err)
+	secondPrepared, err := handler.requestItems(context.Background(), second)
+	assert.NoError(t, err)
+	<-firstPrepared[0]
+	<-secondPrepared[0]
+
+	// then
+	fetchCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	message, err := shelfEvents.FetchMessage(fetchCtx)
+	assert.NoError(t, err)
+
+	envelope, _ := event.ReadEnvelope(message)
+	items := make([]dto.ItemAdded, 0)
+	assert.NoError(t, event.DecodePayload(context.Background(), message, &items))
+	assert.Equal(t, correlationIdOf(first), envelope.CorrelationId)
+	assert.Equal(t, 3, items[0].Quantity)
+	assert.Equal(t, []dto.ItemRequester{
+		{CorrelationId: correlationIdOf(first), Quantity: 1},
+		{CorrelationId: correlationIdOf(second), Quantity: 2},
+	}, items[0].RequestedBy)
+	assert.Equal(t, 3, kitchenShelf.GetCurrent("hamburger"))
+}
+
+func shouldReturnResultOnceItemsAreOnShelf(t *testing.T) {
+	// given
+	prepMealStub := NewMealPrepService()
+	kitchenShelf := shelf.NewEmptyShelf()
+	handler := newHandler(kitchenShelf, NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{Window: 100 * time.Millisecond})
+
+	// when
+	results := handler.Handle(context.Background(), RequestItemEvent, givenItemRequestMessage(t, 1, "hot-wings", 2))
+
+	// then
+	assert.True(t, results[0].Result)
+	assert.Equal(t, 2, kitchenShelf.GetCurrent("hot-wings"))
+	assert.True(t, prepMealStub.HaveBeenCalledWith(MealPrepMatchingFnc("hot-wings", 2)))
+}
+
+func shouldTakePreparationTimeOfEveryRoundOfBatch(t *testing.T) {
+	// then
+	assert.Equal(t, PreparationTime("hamburger", 1), PreparationTime("hamburger", 4))
+	assert.Equal(t, 2*PreparationTime("hamburger", 1), PreparationTime("hamburger", 5))
+	assert.Equal(t, PreparationTime("fries", 1), PreparationTime("fries", 6))
+	assert.Equal(t, time.Second, PreparationTime("unknown", 3))
+}
+
+func givenItemRequestMessage(t *testing.T, orderNumber int64, itemName string, quantity int) kafka.Message {
+	envelope := event.NewEnvelope(context.Background(), RequestItemEvent, event.Source("order")).ForOrder(orderNumber)
+	message, err := event.EncodeMessage(context.Background(), event.ItemKey(itemName), envelope, []ItemRequest{{ItemName: itemName, Quantity: quantity}})
+	assert.NoError(t, err)
+	return message
+}
+
+func correlationIdOf(message kafka.Message) string {
+	envelope, _ := event.ReadEnvelope(message)
+	return envelope.CorrelationId
+}
@@ -0,0 +1,23 @@
+package kitchen
+
+import (
+	"github.com/gin-gonic/gin"
+	"mc-burger-orders/middleware"
+	"net/http"
+)
+
+type Endpoints struct {
+	stations *KitchenStations
+}
+
+func NewKitchenEndpoints(stations *KitchenStations) middleware.EndpointsSetup {
+	return &Endpoints{stations: stations}
+}
+
+func (e *Endpoints) Setup(r *gin.Engine) {
+	r.GET("/admin/kitchen/stations", e.listStationsHandler)
+}
+
+func (e *Endpoints) listStationsHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, e.stations.Statuses())
+}
@@ -3,3 +3,17 @@ package kitchen
 var (
 	RequestItemEvent = "request-item"
 )
+
+// RequestItemSchema is the Avro schema of request-item payloads, a list of items to prepare.
+const RequestItemSchema = `{
+  "type": "array",
+  "items": {
+    "type": "record",
+    "name": "ItemRequest",
+    "namespace": "mcburger.kitchen",
+    "fields": [
+      {"name": "itemName", "type": "string"},
+      {"name": "quantity", "type": "int"}
+    ]
+  }
+}`
@@ -0,0 +1,14 @@
+package kitchen
+
+import (
+	"mc-burger-orders/featureflag"
+	"time"
+)
+
+var (
+	SchedulingPolicyFlag = featureflag.Enum("kitchen-scheduling-policy", FifoScheduling,
+		"order in which cooks take waiting item requests", FifoScheduling, FavoritesFirstScheduling, AgingScheduling)
+	// AgingBoostFlag makes requests of favorites missing on the shelf count as older by the boost under the aging policy
+	AgingBoostFlag = featureflag.Duration("kitchen-scheduling-aging-boost", 30*time.Second,
+		"how much older requests of missing favorites count under the aging policy")
+)
@@ -1,39 +1,39 @@
 package kitchen
 
 import (
-	"github.com/gammazero/workerpool"
+	"context"
 	"github.com/segmentio/kafka-go"
-	"github.com/spf13/cast"
 	"mc-burger-orders/command"
-	"mc-burger-orders/log"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/shelf"
-	"mc-burger-orders/utils"
-	"os"
-	"strconv"
 )
 
 type Handler struct {
 	defaultHandler  command.DefaultCommandHandler
 	mealPreparation MealPreparation
-	kitchenCooks    *workerpool.WorkerPool
+	stations        *KitchenStations
+	batcher         *KitchenBatcher
 	shelf           *shelf.Shelf
 }
 
-func NewHandler(s *shelf.Shelf) *Handler {
-	maxWorkers := 5
-	maxWorkersVal := os.Getenv("KITCHEN_WORKERS_MAX")
+func NewHandler(s *shelf.Shelf, flags *featureflag.Flags) *Handler {
+	stations := NewKitchenStations(NewFlaggedPolicy(s, flags), StationWorkersFromEnv())
+	return newHandler(s, stations, &MealPreparationService{}, BatchConfigsFromEnv())
+}
 
-	if len(maxWorkersVal) > 0 {
-		if value, err := strconv.ParseInt(maxWorkersVal, 10, 16); err == nil {
-			maxWorkers = cast.ToInt(value)
-		}
-	}
-	return &Handler{
-		kitchenCooks:    workerpool.New(maxWorkers),
-		mealPreparation: &MealPreparationService{},
+func newHandler(s *shelf.Shelf, stations *KitchenStations, mealPreparation MealPreparation, batchConfigs BatchConfigs) *Handler {
+	handler := &Handler{
+		stations:        stations,
+		mealPreparation: mealPreparation,
 		shelf:           s,
 		defaultHandler:  command.DefaultCommandHandler{},
 	}
+	handler.batcher = NewKitchenBatcher(batchConfigs, handler.cookBatch)
+	return handler
+}
+
+func (h *Handler) Stations() *KitchenStations {
+	return h.stations
 }
 
 func (h *Handler) GetHandledEvents() []string {
@@ -44,31 +44,25 @@ func (h *Handler) AddCommands(event string, commands ...command.Command) {
 	h.defaultHandler.AddCommands(event, commands...)
 }
 
-func (h *Handler) GetCommands(_ kafka.Message) ([]command.Command, error) {
+func (h *Handler) GetCommands(_ string, _ kafka.Message) ([]command.Command, error) {
 	return make([]command.Command, 0), nil
 }
 
-func (h *Handler) Handle(message kafka.Message, commandResults chan command.TypedResult) {
-	eventType, err := utils.GetEventType(message)
-	if err != nil {
-		log.Error.Println(err.Error())
-		commandResults <- command.NewErrorResult("GetCommandForMessage", err)
-		return
-	}
-
+// Handle hands the requested items over to the batches of their items and returns once they are on the shelf, so the
+// message is committed only after its items were prepared. The kitchen reader handles requests without waiting for
+// the ones before them, see TopicConfigsFromEnv, so requests for an item handled within the batch window are cooked
+// together. Messages of the kitchen topic are not fetched while KITCHEN_BATCH_MAX_PENDING requests wait to be cooked.
+func (h *Handler) Handle(ctx context.Context, eventType string, message kafka.Message) command.Results {
+	results := make(command.Results, 0)
 	switch eventType {
 	case RequestItemEvent:
 		{
-			h.kitchenCooks.Submit(func() {
-				_, err = h.CreateNewItem(message)
-
-				if err != nil {
-					log.Error.Println(err.Error())
-					commandResults <- command.NewErrorResult(RequestItemEvent, err)
-				} else {
-					commandResults <- command.NewSuccessfulResult(RequestItemEvent)
-				}
-			})
+			if _, err := h.CreateNewItem(ctx, message); err != nil {
+				results = append(results, command.NewErrorResult(RequestItemEvent, err))
+			} else {
+				results = append(results, command.NewSuccessfulResult(RequestItemEvent))
+			}
 		}
 	}
+	return results
 }
@@ -53,7 +53,7 @@ func shouldSubmitItemRequestToWorkerWhenMessageArrives(t *testing.T) {
 		go sendMessages(t, msg2)
 	}
 
-	commandHandler := NewHandler(testStack)
+	commandHandler := NewHandler(testStack, nil)
 	eventBus.AddHandler(commandHandler)
 
 	// when
@@ -0,0 +1,183 @@
+package kitchen
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/event"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/shelf"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHandler_WithMemoryTransport(t *testing.T) {
+	t.Run("should put requested items on shelf and announce them", shouldPutRequestedItemsOnShelfAndAnnounceThem)
+	t.Run("should batch requests read one after another from partition of item", shouldBatchRequestsReadOneAfterAnotherFromPartitionOfItem)
+	t.Run("should cook missing favorites first among requests read from topic", shouldCookMissingFavoritesFirstAmongRequestsReadFromTopic)
+	t.Run("should read requests of other stations while one station has backlog", shouldReadRequestsOfOtherStationsWhileOneStationHasBacklog)
+}
+
+// RecordingMealPreparation records the items in the order they were prepared, the blocked item is prepared only once
+// it is released.
+type RecordingMealPreparation struct {
+	mu          sync.Mutex
+	prepared    []string
+	quantities  []int
+	blockedItem string
+	released    chan struct{}
+}
+
+func givenRecordingMealPreparation(blockedItem string) *RecordingMealPreparation {
+	return &RecordingMealPreparation{blockedItem: blockedItem, released: make(chan struct{})}
+}
+
+func (m *RecordingMealPreparation) Prepare(itemName string, quantity int) {
+	if itemName == m.blockedItem {
+		<-m.released
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prepared = append(m.prepared, itemName)
+	m.quantities = append(m.quantities, quantity)
+}
+
+func (m *RecordingMealPreparation) Prepared() ([]string, []int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.prepared...), append([]int{}, m.quantities...)
+}
+
+// givenKitchenReader reads kitchen requests the way the service does, handled by the handler, and returns the writer
+// of requests.
+func givenKitchenReader(t *testing.T, handler *Handler) (*event.DefaultReader, *event.DefaultWriter, *event.TopicConfigs) {
+	kitchenConfig := event.TestTopicConfigs("kitchen-requests")
+	kitchenConfig.Transport = event.NewMemoryTransport()
+	kitchenConfig.Reader.InFlight = defaultBatchMaxPending
+
+	bus := event.NewInternalEventBus()
+	bus.AddHandler(handler)
+	reader := event.NewTopicReader(kitchenConfig, bus)
+	reader.SubscribeToTopic(make(chan kafka.Message))
+	t.Cleanup(func() { _ = reader.Close() })
+	return reader, event.NewTopicWriter(kitchenConfig), kitchenConfig
+}
+
+func givenSentRequests(t *testing.T, writer *event.DefaultWriter, itemNames ...string) {
+	for index, itemName := range itemNames {
+		assert.NoError(t, writer.SendMessage(context.Background(), givenItemRequestMessage(t, int64(index+1), itemName, 1)))
+	}
+}
+
+func shouldPutRequestedItemsOnShelfAndAnnounceThem(t *testing.T) {
+	// given
+	transport := event.NewMemoryTransport()
+	kitchenConfig := event.TestTopicConfigs("kitchen-requests")
+	kitchenConfig.Transport = transport
+	shelfConfig := event.TestTopicConfigs("shelf-events")
+	shelfConfig.Transport = transport
+
+	kitchenShelf := shelf.NewEmptyShelf()
+	kitchenShelf.ConfigureWriter(event.NewTopicWriter(shelfConfig))
+	shelfEvents := transport.Consumer(shelfConfig, "orders")
+
+	bus := event.NewInternalEventBus()
+	bus.AddHandler(NewHandler(kitchenShelf, nil))
+	reader := event.NewTopicReader(kitchenConfig, bus)
+	reader.SubscribeToTopic(make(chan kafka.Message))
+	defer reader.Close()
+
+	// when
+	ctx := context.Background()
+	envelope := event.NewEnvelope(ctx, RequestItemEvent, event.Source("order"))
+	request, err := event.EncodeMessage(ctx, event.ItemKey("hamburger"), envelope, []ItemRequest{{ItemName: "hamburger", Quantity: 2}})
+	assert.NoError(t, err)
+	assert.NoError(t, event.NewTopicWriter(kitchenConfig).SendMessage(ctx, request))
+
+	// then
+	fetchCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	message, err := shelfEvents.FetchMessage(fetchCtx)
+	assert.NoError(t, err)
+
+	// and
+	added, err := event.ReadEnvelope(message)
+	assert.NoError(t, err)
+	assert.Equal(t, shelf.ItemAddedOnShelfEvent, added.Type)
+
+	items := make([]ItemRequest, 0)
+	assert.NoError(t, event.DecodePayload(ctx, message, &items))
+	assert.Equal(t, []ItemRequest{{ItemName: "hamburger", Quantity: 2}}, items)
+	assert.Equal(t, 2, kitchenShelf.GetCurrent("hamburger"))
+}
+
+func shouldBatchRequestsReadOneAfterAnotherFromPartitionOfItem(t *testing.T) {
+	// given
+	preparation := givenRecordingMealPreparation("")
+	kitchenShelf := shelf.NewEmptyShelf()
+	handler := newHandler(kitchenShelf, NewKitchenStations(NewFifoPolicy(), nil), preparation, BatchConfigs{Window: 200 * time.Millisecond})
+	reader, writer, kitchenConfig := givenKitchenReader(t, handler)
+
+	// when
+	givenSentRequests(t, writer, "hamburger", "hamburger", "hamburger")
+
+	// then
+	assert.Eventually(t, func() bool { return kitchenShelf.GetCurrent("hamburger") == 3 }, 5*time.Second, 10*time.Millisecond)
+	prepared, quantities := preparation.Prepared()
+	assert.Equal(t, []string{"hamburger"}, prepared)
+	assert.Equal(t, []int{3}, quantities)
+
+	// and
+	assert.Eventually(t, func() bool { return !hasUncommittedMessage(reader, kitchenConfig) }, 5*time.Second, 50*time.Millisecond)
+}
+
+func shouldCookMissingFavoritesFirstAmongRequestsReadFromTopic(t *testing.T) {
+	// given
+	preparation := givenRecordingMealPreparation("mc-chicken")
+	policy := NewFavoritesFirstPolicy(shelf.NewEmptyShelf(), nil)
+	handler := newHandler(shelf.NewEmptyShelf(), NewKitchenStations(policy, map[item.Station]int{item.Grill: 1}), preparation, BatchConfigs{})
+	_, writer, _ := givenKitchenReader(t, handler)
+	givenSentRequests(t, writer, "mc-chicken")
+	assert.Eventually(t, func() bool { return handler.stations.QueueOf("mc-chicken").Busy() == 1 }, 5*time.Second, 5*time.Millisecond)
+
+	// when
+	givenSentRequests(t, writer, "double-cheese", "mc-spicy", "cheeseburger")
+	assert.Eventually(t, func() bool { return handler.stations.QueueOf("cheeseburger").Len() == 3 }, 5*time.Second, 5*time.Millisecond)
+	close(preparation.released)
+
+	// then
+	assert.Eventually(t, func() bool { prepared, _ := preparation.Prepared(); return len(prepared) == 4 }, 5*time.Second, 10*time.Millisecond)
+	prepared, _ := preparation.Prepared()
+	assert.Equal(t, []string{"mc-chicken", "cheeseburger"}, prepared[:2])
+	assert.ElementsMatch(t, []string{"double-cheese", "mc-spicy"}, prepared[2:])
+}
+
+func shouldReadRequestsOfOtherStationsWhileOneStationHasBacklog(t *testing.T) {
+	// given
+	preparation := givenRecordingMealPreparation("fries")
+	defer close(preparation.released)
+	kitchenShelf := shelf.NewEmptyShelf()
+	workers := map[item.Station]int{item.Grill: 1, item.Fryer: 1}
+	handler := newHandler(kitchenShelf, NewKitchenStations(NewFifoPolicy(), workers), preparation, BatchConfigs{})
+	_, writer, _ := givenKitchenReader(t, handler)
+
+	// when
+	givenSentRequests(t, writer, "fries", "fries", "fries", "fries", "fries", "hamburger")
+
+	// then
+	assert.Eventually(t, func() bool { return kitchenShelf.GetCurrent("hamburger") == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return handler.stations.QueueOf("fries").Len() == 4 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, kitchenShelf.GetCurrent("fries"))
+}
+
+// hasUncommittedMessage tells if a new member of the consumer group of the reader is handed a message.
+func hasUncommittedMessage(reader *event.DefaultReader, kitchenConfig *event.TopicConfigs) bool {
+	consumer := kitchenConfig.Transport.Consumer(kitchenConfig, reader.GroupId())
+	defer consumer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := consumer.FetchMessage(ctx)
+	return err == nil
+}
@@ -7,23 +7,47 @@ type Item struct {
 	Quantity int    `json:"quantity" binding:"gt=0"`
 }
 
+// Station is the part of the kitchen an item is made at, every station has cooks of its own.
+type Station string
+
+const (
+	Grill   Station = "grill"
+	Fryer   Station = "fryer"
+	Wrap    Station = "wrap"
+	Counter Station = "counter"
+)
+
+var Stations = []Station{Grill, Fryer, Wrap, Counter}
+
+// BatchCapacity is the number of items a station makes at once, like patties on the grill or portions in a fryer basket.
+var BatchCapacity = map[Station]int{Grill: 4, Fryer: 6, Wrap: 2, Counter: 10}
+
 type MenuItemConfigs struct {
 	InstantReady    bool
 	Favorite        bool
 	PreparationTime time.Duration
+	Station         Station
 }
 
 var MenuItems = map[string]MenuItemConfigs{
-	"hamburger":       {false, true, 2500},
-	"cheeseburger":    {false, true, 4500},
-	"double-cheese":   {false, false, 3750},
-	"mc-spicy":        {false, false, 3200},
-	"mc-chicken":      {false, false, 4200},
-	"mr-chicken-wrap": {false, false, 6000},
-	"spicy-stripes":   {false, true, 4100},
-	"hot-wings":       {false, true, 3200},
-	"fries":           {false, true, 1500},
-	"coke":            {true, false, 0},
-	"ice-cream":       {true, false, 0},
-	"fanta":           {true, false, 0},
+	"hamburger":       {false, true, 2500, Grill},
+	"cheeseburger":    {false, true, 4500, Grill},
+	"double-cheese":   {false, false, 3750, Grill},
+	"mc-spicy":        {false, false, 3200, Grill},
+	"mc-chicken":      {false, false, 4200, Grill},
+	"mr-chicken-wrap": {false, false, 6000, Wrap},
+	"spicy-stripes":   {false, true, 4100, Fryer},
+	"hot-wings":       {false, true, 3200, Fryer},
+	"fries":           {false, true, 1500, Fryer},
+	"coke":            {true, false, 0, Counter},
+	"ice-cream":       {true, false, 0, Counter},
+	"fanta":           {true, false, 0, Counter},
+}
+
+// StationOf returns the station the item is made at, items missing on the menu are handed over at the counter.
+func StationOf(itemName string) Station {
+	if config, exists := MenuItems[itemName]; exists {
+		return config.Station
+	}
+	return Counter
 }
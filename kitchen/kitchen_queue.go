@@ -0,0 +1,99 @@
+package kitchen
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// cookJob prepares the items of a request-item message.
+type cookJob struct {
+	requests    []ItemRequest
+	requestedAt time.Time
+	sequence    int64
+	cook        func()
+	done        chan struct{}
+}
+
+// KitchenQueue holds the jobs waiting for a free cook, every cook takes the job coming first under the policy.
+type KitchenQueue struct {
+	mu       sync.Mutex
+	jobAdded *sync.Cond
+	policy   SchedulingPolicy
+	pending  []*cookJob
+	sequence int64
+	cooks    int
+	busy     atomic.Int32
+}
+
+func NewKitchenQueue(policy SchedulingPolicy, cooks int) *KitchenQueue {
+	queue := &KitchenQueue{policy: policy, pending: make([]*cookJob, 0), cooks: max(cooks, 1)}
+	queue.jobAdded = sync.NewCond(&queue.mu)
+	for i := 0; i < queue.cooks; i++ {
+		go queue.runCook()
+	}
+	return queue
+}
+
+// Submit queues the preparation of the requested items, requestedAt is when the items were requested. The returned
+// channel is closed once the items are prepared.
+func (q *KitchenQueue) Submit(requests []ItemRequest, requestedAt time.Time, cook func()) <-chan struct{} {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.sequence++
+	job := &cookJob{requests: requests, requestedAt: requestedAt, sequence: q.sequence, cook: cook, done: make(chan struct{})}
+	q.pending = append(q.pending, job)
+	q.jobAdded.Signal()
+	return job.done
+}
+
+// Len is the number of jobs waiting for a cook.
+func (q *KitchenQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.pending)
+}
+
+// Busy is the number of cooks preparing items.
+func (q *KitchenQueue) Busy() int {
+	return int(q.busy.Load())
+}
+
+func (q *KitchenQueue) Cooks() int {
+	return q.cooks
+}
+
+func (q *KitchenQueue) Policy() string {
+	return q.policy.Name()
+}
+
+func (q *KitchenQueue) runCook() {
+	for {
+		job := q.take()
+		q.busy.Add(1)
+		job.cook()
+		q.busy.Add(-1)
+		close(job.done)
+	}
+}
+
+func (q *KitchenQueue) take() *cookJob {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for len(q.pending) == 0 {
+		q.jobAdded.Wait()
+	}
+	policy := q.policy.resolve()
+	next := 0
+	for index, job := range q.pending {
+		if policy.before(job, q.pending[next]) {
+			next = index
+		}
+	}
+	job := q.pending[next]
+	q.pending = slices.Delete(q.pending, next, next+1)
+	return job
+}
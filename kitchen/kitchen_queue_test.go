@@ -0,0 +1,199 @@
+package kitchen
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/featureflag"
+	"mc-burger-orders/shelf"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestKitchenQueue_Scheduling(t *testing.T) {
+	t.Run("should cook requests in submit order when fifo", shouldCookRequestsInSubmitOrderWhenFifo)
+	t.Run("should cook missing favorites first when favorites first", shouldCookMissingFavoritesFirstWhenFavoritesFirst)
+	t.Run("should not prioritize favorites stocked on shelf", shouldNotPrioritizeFavoritesStockedOnShelf)
+	t.Run("should cook long waiting requests before missing favorites when aging", shouldCookLongWaitingRequestsBeforeMissingFavoritesWhenAging)
+	t.Run("should cook every request with many cooks", shouldCookEveryRequestWithManyCooks)
+	t.Run("should switch policy when feature flag is flipped", shouldSwitchPolicyWhenFeatureFlagIsFlipped)
+	t.Run("should resolve policy once per scheduling pass", shouldResolvePolicyOncePerSchedulingPass)
+}
+
+func shouldCookRequestsInSubmitOrderWhenFifo(t *testing.T) {
+	// given
+	queue, release := givenBusyKitchenQueue(NewFifoPolicy())
+	now := time.Now()
+	cooked := &cookedItems{}
+
+	// when
+	cooked.submit(queue, "mc-spicy", now)
+	cooked.submit(queue, "hamburger", now)
+	cooked.submit(queue, "double-cheese", now)
+	release()
+
+	// then
+	assert.Equal(t, []string{"mc-spicy", "hamburger", "double-cheese"}, cooked.waitFor(t, 3))
+}
+
+func shouldCookMissingFavoritesFirstWhenFavoritesFirst(t *testing.T) {
+	// given
+	queue, release := givenBusyKitchenQueue(NewFavoritesFirstPolicy(shelf.NewEmptyShelf(), nil))
+	now := time.Now()
+	cooked := &cookedItems{}
+
+	// when
+	cooked.submit(queue, "mc-spicy", now)
+	cooked.submit(queue, "double-cheese", now)
+	cooked.submit(queue, "hamburger", now)
+	cooked.submit(queue, "fries", now)
+	release()
+
+	// then
+	assert.Equal(t, []string{"hamburger", "fries", "mc-spicy", "double-cheese"}, cooked.waitFor(t, 4))
+}
+
+func shouldNotPrioritizeFavoritesStockedOnShelf(t *testing.T) {
+	// given
+	stockedShelf := shelf.NewEmptyShelf()
+	stockedShelf.AddMany("hamburger", 5)
+	queue, release := givenBusyKitchenQueue(NewFavoritesFirstPolicy(stockedShelf, nil))
+	now := time.Now()
+	cooked := &cookedItems{}
+
+	// when
+	cooked.submit(queue, "mc-spicy", now)
+	cooked.submit(queue, "hamburger", now)
+	cooked.submit(queue, "fries", now)
+	release()
+
+	// then
+	assert.Equal(t, []string{"fries", "mc-spicy", "hamburger"}, cooked.waitFor(t, 3))
+}
+
+func shouldCookLongWaitingRequestsBeforeMissingFavoritesWhenAging(t *testing.T) {
+	// given
+	queue, release := givenBusyKitchenQueue(NewAgingPolicy(shelf.NewEmptyShelf(), nil, 30*time.Second))
+	now := time.Now()
+	cooked := &cookedItems{}
+
+	// when
+	cooked.submit(queue, "hamburger", now)
+	cooked.submit(queue, "mc-spicy", now.Add(-10*time.Second))
+	cooked.submit(queue, "double-cheese", now.Add(-time.Minute))
+	cooked.submit(queue, "fries", now.Add(-5*time.Second))
+	release()
+
+	// then
+	assert.Equal(t, []string{"double-cheese", "fries", "hamburger", "mc-spicy"}, cooked.waitFor(t, 4))
+}
+
+func shouldCookEveryRequestWithManyCooks(t *testing.T) {
+	// given
+	queue := NewKitchenQueue(NewFifoPolicy(), 3)
+	waitGroup := &sync.WaitGroup{}
+	cooked := &cookedItems{}
+
+	// when
+	for i := 0; i < 10; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			<-queue.Submit([]ItemRequest{{ItemName: "fries", Quantity: 1}}, time.Now(), func() { cooked.add("fries") })
+		}()
+	}
+	waitGroup.Wait()
+
+	// then
+	assert.Len(t, cooked.waitFor(t, 10), 10)
+	assert.Equal(t, 0, queue.Len())
+}
+
+func shouldSwitchPolicyWhenFeatureFlagIsFlipped(t *testing.T) {
+	// given
+	flags := featureflag.NewFlags(featureflag.NewInMemoryRepository(), map[string]string{}, SchedulingPolicyFlag, shelf.FavoritesParFlag)
+	queue, release := givenBusyKitchenQueue(NewFlaggedPolicy(shelf.NewEmptyShelf(), flags))
+	now := time.Now()
+	cooked := &cookedItems{}
+	cooked.submit(queue, "mc-spicy", now)
+	cooked.submit(queue, "hamburger", now)
+	assert.Equal(t, FifoScheduling, queue.Policy())
+
+	// when
+	_, err := flags.Set(context.Background(), "kitchen-scheduling-policy", FavoritesFirstScheduling)
+	release()
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, FavoritesFirstScheduling, queue.Policy())
+	assert.Equal(t, []string{"hamburger", "mc-spicy"}, cooked.waitFor(t, 2))
+}
+
+func shouldResolvePolicyOncePerSchedulingPass(t *testing.T) {
+	// given
+	policy := &resolveCountingPolicy{SchedulingPolicy: NewFifoPolicy()}
+	queue, release := givenBusyKitchenQueue(policy)
+	cooked := &cookedItems{}
+	for _, itemName := range []string{"mc-spicy", "hamburger", "fries", "double-cheese"} {
+		cooked.submit(queue, itemName, time.Now())
+	}
+
+	// when
+	release()
+
+	// then
+	assert.Equal(t, []string{"mc-spicy", "hamburger", "fries", "double-cheese"}, cooked.waitFor(t, 4))
+	assert.Equal(t, int32(5), policy.resolved.Load())
+}
+
+// resolveCountingPolicy counts the scheduling passes of the policy it wraps.
+type resolveCountingPolicy struct {
+	SchedulingPolicy
+	resolved atomic.Int32
+}
+
+func (p *resolveCountingPolicy) resolve() SchedulingPolicy {
+	p.resolved.Add(1)
+	return p.SchedulingPolicy.resolve()
+}
+
+// givenBusyKitchenQueue returns a queue with its only cook busy until released, so submitted jobs wait for it.
+func givenBusyKitchenQueue(policy SchedulingPolicy) (*KitchenQueue, func()) {
+	queue := NewKitchenQueue(policy, 1)
+	started := make(chan struct{})
+	released := make(chan struct{})
+	queue.Submit(nil, time.Now(), func() {
+		close(started)
+		<-released
+	})
+	<-started
+	return queue, func() { close(released) }
+}
+
+type cookedItems struct {
+	mu    sync.Mutex
+	items []string
+}
+
+func (c *cookedItems) submit(queue *KitchenQueue, itemName string, requestedAt time.Time) {
+	queue.Submit([]ItemRequest{{ItemName: itemName, Quantity: 1}}, requestedAt, func() { c.add(itemName) })
+}
+
+func (c *cookedItems) add(itemName string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items = append(c.items, itemName)
+}
+
+func (c *cookedItems) waitFor(t *testing.T, count int) []string {
+	assert.Eventually(t, func() bool {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return len(c.items) >= count
+	}, time.Second, 5*time.Millisecond)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string{}, c.items...)
+}
@@ -13,10 +13,18 @@ type MealPreparationService struct {
 }
 
 func (m *MealPreparationService) Prepare(item string, quantity int) {
-	if itemConfig, ok := item2.MenuItems[item]; ok {
-		time.Sleep(itemConfig.PreparationTime * time.Duration(quantity))
-		return
+	time.Sleep(PreparationTime(item, quantity))
+}
+
+// PreparationTime is the time a batch of the item takes. A station makes up to its batch capacity at once, so every
+// round of the batch takes the preparation time of the item.
+func PreparationTime(item string, quantity int) time.Duration {
+	itemConfig, ok := item2.MenuItems[item]
+	if !ok {
+		return time.Second
 	}
 
-	time.Sleep(time.Second)
+	capacity := max(item2.BatchCapacity[itemConfig.Station], 1)
+	rounds := (quantity + capacity - 1) / capacity
+	return itemConfig.PreparationTime * time.Duration(rounds)
 }
@@ -1,29 +1,83 @@
 package kitchen
 
 import (
-	"encoding/json"
+	"context"
 	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/event"
+	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/log"
+	"mc-burger-orders/shelf/dto"
+	"strconv"
 )
 
-func (h *Handler) CreateNewItem(message kafka.Message) (bool, error) {
-	requests := &[]ItemRequest{}
-	err := json.Unmarshal(message.Value, requests)
+// CreateNewItem requests the items of the message and waits until all of them are prepared.
+func (h *Handler) CreateNewItem(ctx context.Context, message kafka.Message) (bool, error) {
+	prepared, err := h.requestItems(ctx, message)
 	if err != nil {
-		log.Error.Println(err.Error())
 		return false, err
 	}
 
-	messageKey := string(message.Key)
-	for _, request := range *requests {
-		log.Info.Printf("CookRequest: %v | Starting to prepare new item -> %v in amount: `%d`", messageKey, request.ItemName, request.Quantity)
+	for _, done := range prepared {
+		<-done
+	}
+	return true, nil
+}
+
+// requestItems adds every requested item to the batch of the item, the returned channels are closed once the batches
+// are prepared.
+func (h *Handler) requestItems(ctx context.Context, message kafka.Message) ([]<-chan struct{}, error) {
+	requests, err := h.requestsOf(ctx, message)
+	if err != nil {
+		return nil, err
+	}
+
+	envelope, _ := event.ReadEnvelope(message)
+	prepared := make([]<-chan struct{}, 0, len(requests))
+	for _, request := range requests {
+		prepared = append(prepared, h.batcher.Add(request.ItemName, requesterOf(envelope, request)))
+	}
+	return prepared, nil
+}
+
+// cookBatch hands the batch to the queue of the station the item is made at. Batches waiting at a station are taken
+// in the order of the scheduling policy.
+func (h *Handler) cookBatch(batch *ItemBatch) {
+	queue := h.stations.QueueOf(batch.ItemName)
+	queue.Submit([]ItemRequest{{ItemName: batch.ItemName, Quantity: batch.Quantity}}, batch.requestedAt(), func() {
+		h.prepare(batch)
+		h.batcher.prepared(batch)
+	})
+}
+
+func (h *Handler) requestsOf(ctx context.Context, message kafka.Message) ([]ItemRequest, error) {
+	requests := make([]ItemRequest, 0)
+	if err := event.DecodePayload(ctx, message, &requests); err != nil {
+		log.Error.Println(err.Error())
+		return nil, err
+	}
+	return requests, nil
+}
+
+// prepare cooks the batch at once and puts it on the shelf in one go on behalf of every requester of the batch. The
+// shelf event is correlated with the request the batch was opened for.
+func (h *Handler) prepare(batch *ItemBatch) {
+	station := item.StationOf(batch.ItemName)
+	log.Info.Printf("CookRequest: Starting to prepare new item -> %v in amount: `%d` at %v for %d request(s)", batch.ItemName, batch.Quantity, station, len(batch.Requesters))
 
-		h.mealPreparation.Prepare(request.ItemName, request.Quantity)
+	h.mealPreparation.Prepare(batch.ItemName, batch.Quantity)
 
-		log.Info.Printf("CookRequest: %v | item(s) %v in prepared", messageKey, request.ItemName)
+	requestedBy := make([]dto.ItemRequester, 0, len(batch.Requesters))
+	for _, requester := range batch.Requesters {
+		log.Info.Printf("CookRequest: %v | %d item(s) %v in prepared for order %v", requester.CorrelationId, requester.Quantity, batch.ItemName, orderNumberOf(requester))
 
-		h.shelf.AddMany(request.ItemName, request.Quantity)
+		requestedBy = append(requestedBy, dto.ItemRequester{CorrelationId: requester.CorrelationId, Quantity: requester.Quantity})
 	}
+	h.shelf.AddRequested(batch.Requesters[0].Context(), batch.ItemName, requestedBy)
+}
 
-	return true, nil
+func orderNumberOf(requester Requester) string {
+	if requester.OrderNumber == nil {
+		return "-"
+	}
+	return strconv.FormatInt(*requester.OrderNumber, 10)
 }
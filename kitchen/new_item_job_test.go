@@ -1,9 +1,9 @@
 package kitchen
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"github.com/gammazero/workerpool"
 	"github.com/segmentio/kafka-go"
 	"github.com/spf13/cast"
 	"github.com/stretchr/testify/assert"
@@ -27,11 +27,7 @@ func shouldPrepareNewItemsWhenRequestedInTheMessage(t *testing.T) {
 	// given
 	emptyStack := shelf.NewEmptyShelf()
 	prepMealStub := NewMealPrepService()
-	handler := &Handler{
-		kitchenCooks:    workerpool.New(1),
-		mealPreparation: prepMealStub,
-		shelf:           emptyStack,
-	}
+	handler := newHandler(emptyStack, NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{})
 
 	messageValue := make([]map[string]any, 0)
 	messageValue = data.AppendHamburgerItem(messageValue, 1)
@@ -40,7 +36,7 @@ func shouldPrepareNewItemsWhenRequestedInTheMessage(t *testing.T) {
 	message := givenKafkaMessage(t, expectedOrderNumber, messageValue)
 
 	// when
-	result, err := handler.CreateNewItem(message)
+	result, err := handler.CreateNewItem(context.Background(), message)
 
 	// then
 	assert.True(t, result)
@@ -55,11 +51,7 @@ func shouldPrepareItemsWhenMessageMissingOrderNumber(t *testing.T) {
 	// given
 	emptyStack := shelf.NewEmptyShelf()
 	prepMealStub := NewMealPrepService()
-	handler := &Handler{
-		kitchenCooks:    workerpool.New(1),
-		mealPreparation: prepMealStub,
-		shelf:           emptyStack,
-	}
+	handler := newHandler(emptyStack, NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{})
 
 	messageValue := make([]map[string]any, 0)
 	messageValue = data.AppendHamburgerItem(messageValue, 1)
@@ -68,7 +60,7 @@ func shouldPrepareItemsWhenMessageMissingOrderNumber(t *testing.T) {
 	message := givenKafkaMessage(t, -1, messageValue)
 
 	// when
-	result, err := handler.CreateNewItem(message)
+	result, err := handler.CreateNewItem(context.Background(), message)
 
 	// then
 	assert.True(t, result)
@@ -83,16 +75,12 @@ func shouldSkipWhenMessageHasZeroRequests(t *testing.T) {
 	// given
 	emptyStack := shelf.NewEmptyShelf()
 	prepMealStub := NewMealPrepService()
-	handler := &Handler{
-		kitchenCooks:    workerpool.New(1),
-		mealPreparation: prepMealStub,
-		shelf:           emptyStack,
-	}
+	handler := newHandler(emptyStack, NewKitchenStations(NewFifoPolicy(), nil), prepMealStub, BatchConfigs{})
 
 	message := givenKafkaMessage(t, expectedOrderNumber, make([]map[string]any, 0))
 
 	// when
-	result, err := handler.CreateNewItem(message)
+	result, err := handler.CreateNewItem(context.Background(), message)
 
 	// then
 	assert.True(t, result)
@@ -0,0 +1,149 @@
+package kitchen
+
+import (
+	"mc-burger-orders/featureflag"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/shelf"
+	"time"
+)
+
+const (
+	FifoScheduling           = "fifo"
+	FavoritesFirstScheduling = "favorites-first"
+	AgingScheduling          = "aging"
+)
+
+// SchedulingPolicy decides which of the waiting cook jobs is taken by the next free cook.
+type SchedulingPolicy interface {
+	Name() string
+	// before tells if the job a is cooked before the job b, it is asked when a cook takes a job so the shelf is
+	// looked at as it is then
+	before(a *cookJob, b *cookJob) bool
+	// resolve returns the policy a scheduling pass compares the waiting jobs with
+	resolve() SchedulingPolicy
+}
+
+// FlaggedPolicy schedules by the policy currently set on SchedulingPolicyFlag, so the policy is changed at runtime. The
+// flag is read once per scheduling pass, every job of the pass is compared by the same policy.
+type FlaggedPolicy struct {
+	shelf *shelf.Shelf
+	flags *featureflag.Flags
+}
+
+func NewFlaggedPolicy(s *shelf.Shelf, flags *featureflag.Flags) *FlaggedPolicy {
+	return &FlaggedPolicy{shelf: s, flags: flags}
+}
+
+func (p *FlaggedPolicy) Name() string {
+	return p.current().Name()
+}
+
+func (p *FlaggedPolicy) before(a *cookJob, b *cookJob) bool {
+	return p.current().before(a, b)
+}
+
+func (p *FlaggedPolicy) resolve() SchedulingPolicy {
+	return p.current()
+}
+
+func (p *FlaggedPolicy) current() SchedulingPolicy {
+	switch SchedulingPolicyFlag.Get(p.flags) {
+	case FavoritesFirstScheduling:
+		return NewFavoritesFirstPolicy(p.shelf, p.flags)
+	case AgingScheduling:
+		return NewAgingPolicy(p.shelf, p.flags, AgingBoostFlag.Get(p.flags))
+	default:
+		return NewFifoPolicy()
+	}
+}
+
+// FifoPolicy cooks jobs in the order they were submitted.
+type FifoPolicy struct{}
+
+func NewFifoPolicy() *FifoPolicy {
+	return &FifoPolicy{}
+}
+
+func (p *FifoPolicy) Name() string {
+	return FifoScheduling
+}
+
+func (p *FifoPolicy) resolve() SchedulingPolicy {
+	return p
+}
+
+func (p *FifoPolicy) before(a *cookJob, b *cookJob) bool {
+	return a.sequence < b.sequence
+}
+
+// FavoritesFirstPolicy cooks jobs with favorite items missing on the shelf before any other job, the README FF.1.
+type FavoritesFirstPolicy struct {
+	shelf *shelf.Shelf
+	flags *featureflag.Flags
+}
+
+func NewFavoritesFirstPolicy(s *shelf.Shelf, flags *featureflag.Flags) *FavoritesFirstPolicy {
+	return &FavoritesFirstPolicy{shelf: s, flags: flags}
+}
+
+func (p *FavoritesFirstPolicy) Name() string {
+	return FavoritesFirstScheduling
+}
+
+func (p *FavoritesFirstPolicy) resolve() SchedulingPolicy {
+	return p
+}
+
+func (p *FavoritesFirstPolicy) before(a *cookJob, b *cookJob) bool {
+	par := shelf.FavoritesParFlag.Get(p.flags)
+	aMissing, bMissing := hasMissingFavorite(p.shelf, par, a), hasMissingFavorite(p.shelf, par, b)
+	if aMissing != bMissing {
+		return aMissing
+	}
+	return a.sequence < b.sequence
+}
+
+// AgingPolicy cooks the oldest requests first, requests with favorite items missing on the shelf count as older by
+// the boost. Unlike FavoritesFirstPolicy, orders waiting longer than the boost are not starved by favorites.
+type AgingPolicy struct {
+	shelf *shelf.Shelf
+	flags *featureflag.Flags
+	boost time.Duration
+}
+
+func NewAgingPolicy(s *shelf.Shelf, flags *featureflag.Flags, boost time.Duration) *AgingPolicy {
+	return &AgingPolicy{shelf: s, flags: flags, boost: boost}
+}
+
+func (p *AgingPolicy) Name() string {
+	return AgingScheduling
+}
+
+func (p *AgingPolicy) resolve() SchedulingPolicy {
+	return p
+}
+
+func (p *AgingPolicy) before(a *cookJob, b *cookJob) bool {
+	par := shelf.FavoritesParFlag.Get(p.flags)
+	aRank, bRank := p.rankOf(par, a), p.rankOf(par, b)
+	if !aRank.Equal(bRank) {
+		return aRank.Before(bRank)
+	}
+	return a.sequence < b.sequence
+}
+
+func (p *AgingPolicy) rankOf(par int, job *cookJob) time.Time {
+	if hasMissingFavorite(p.shelf, par, job) {
+		return job.requestedAt.Add(-p.boost)
+	}
+	return job.requestedAt
+}
+
+func hasMissingFavorite(s *shelf.Shelf, par int, job *cookJob) bool {
+	for _, request := range job.requests {
+		if item.MenuItems[request.ItemName].Favorite && s.GetCurrent(request.ItemName) < par {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package kitchen
+
+import (
+	"github.com/spf13/cast"
+	"mc-burger-orders/kitchen/item"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const defaultStationWorkers = 5
+
+// StationStatus tells how busy a station is, Waiting is the depth of its queue.
+type StationStatus struct {
+	Station item.Station `json:"station"`
+	Policy  string       `json:"policy"`
+	Cooks   int          `json:"cooks"`
+	Busy    int          `json:"busy"`
+	Waiting int          `json:"waiting"`
+}
+
+// KitchenStations has a queue and cooks of its own for every station, so a backlog at one station does not hold
+// items of the others.
+type KitchenStations struct {
+	queues map[item.Station]*KitchenQueue
+}
+
+// NewKitchenStations starts the cooks of every station, stations missing in workers get a single cook.
+func NewKitchenStations(policy SchedulingPolicy, workers map[item.Station]int) *KitchenStations {
+	queues := make(map[item.Station]*KitchenQueue)
+	for _, station := range item.Stations {
+		queues[station] = NewKitchenQueue(policy, workers[station])
+	}
+	return &KitchenStations{queues: queues}
+}
+
+// StationWorkersFromEnv reads the number of cooks of every station from KITCHEN_STATION_WORKERS__<STATION>, e.g.
+// KITCHEN_STATION_WORKERS__GRILL, stations without one get KITCHEN_WORKERS_MAX cooks.
+func StationWorkersFromEnv() map[item.Station]int {
+	maxWorkers := defaultStationWorkers
+	if maxWorkersVal := os.Getenv("KITCHEN_WORKERS_MAX"); len(maxWorkersVal) > 0 {
+		if value, err := strconv.ParseInt(maxWorkersVal, 10, 16); err == nil {
+			maxWorkers = cast.ToInt(value)
+		}
+	}
+
+	workers := make(map[item.Station]int)
+	for _, station := range item.Stations {
+		workers[station] = maxWorkers
+		workersVal := os.Getenv("KITCHEN_STATION_WORKERS__" + strings.ToUpper(string(station)))
+		if value, err := strconv.ParseInt(workersVal, 10, 16); err == nil && value > 0 {
+			workers[station] = cast.ToInt(value)
+		}
+	}
+	return workers
+}
+
+// QueueOf returns the queue of the station the item is made at.
+func (s *KitchenStations) QueueOf(itemName string) *KitchenQueue {
+	return s.queues[item.StationOf(itemName)]
+}
+
+func (s *KitchenStations) Statuses() []StationStatus {
+	statuses := make([]StationStatus, 0, len(s.queues))
+	for _, station := range item.Stations {
+		queue := s.queues[station]
+		statuses = append(statuses, StationStatus{
+			Station: station,
+			Policy:  queue.Policy(),
+			Cooks:   queue.Cooks(),
+			Busy:    queue.Busy(),
+			Waiting: queue.Len(),
+		})
+	}
+	return statuses
+}
@@ -0,0 +1,119 @@
+package kitchen
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/shelf"
+	"mc-burger-orders/testing/data"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// BlockingMealPreparation prepares the blocked item only once it is released, other items are prepared at once.
+type BlockingMealPreparation struct {
+	blockedItem string
+	released    chan struct{}
+}
+
+func (m *BlockingMealPreparation) Prepare(itemName string, _ int) {
+	if itemName == m.blockedItem {
+		<-m.released
+	}
+}
+
+func givenStationsHandler(blockedItem string) (*Handler, *BlockingMealPreparation) {
+	preparation := &BlockingMealPreparation{blockedItem: blockedItem, released: make(chan struct{})}
+	return newHandler(shelf.NewEmptyShelf(), NewKitchenStations(NewFifoPolicy(), nil), preparation, BatchConfigs{}), preparation
+}
+
+func TestKitchenStations(t *testing.T) {
+	t.Run("should prepare items of other stations while one station has a backlog", shouldPrepareItemsOfOtherStationsWhileOneStationHasBacklog)
+	t.Run("should report queue depth of every station", shouldReportQueueDepthOfEveryStation)
+	t.Run("should configure cooks of every station", shouldConfigureCooksOfEveryStation)
+	t.Run("should list stations", shouldListStations)
+}
+
+func shouldPrepareItemsOfOtherStationsWhileOneStationHasBacklog(t *testing.T) {
+	// given
+	handler, preparation := givenStationsHandler("spicy-stripes")
+	defer close(preparation.released)
+	friesMessage := givenKafkaMessage(t, expectedOrderNumber, data.AppendSpicyStripesItem(make([]map[string]any, 0), 3))
+	go func() { _, _ = handler.CreateNewItem(context.Background(), friesMessage) }()
+	assert.Eventually(t, func() bool { return handler.stations.QueueOf("spicy-stripes").Busy() == 1 }, time.Second, 5*time.Millisecond)
+
+	burgerMessage := givenKafkaMessage(t, expectedOrderNumber, data.AppendHamburgerItem(make([]map[string]any, 0), 2))
+
+	// when
+	result, err := handler.CreateNewItem(context.Background(), burgerMessage)
+
+	// then
+	assert.True(t, result)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, handler.shelf.GetCurrent("hamburger"))
+	assert.Equal(t, 0, handler.shelf.GetCurrent("spicy-stripes"))
+}
+
+func shouldReportQueueDepthOfEveryStation(t *testing.T) {
+	// given
+	handler, preparation := givenStationsHandler("spicy-stripes")
+	waitGroup := &sync.WaitGroup{}
+	for i := 0; i < 3; i++ {
+		waitGroup.Add(1)
+		go func() {
+			defer waitGroup.Done()
+			message := givenKafkaMessage(t, expectedOrderNumber, data.AppendSpicyStripesItem(make([]map[string]any, 0), 1))
+			_, _ = handler.CreateNewItem(context.Background(), message)
+		}()
+	}
+
+	// when
+	assert.Eventually(t, func() bool { return handler.stations.QueueOf("spicy-stripes").Len() == 2 }, time.Second, 5*time.Millisecond)
+	statuses := handler.stations.Statuses()
+
+	// then
+	assert.Equal(t, StationStatus{Station: item.Fryer, Policy: FifoScheduling, Cooks: 1, Busy: 1, Waiting: 2}, statuses[1])
+	assert.Equal(t, StationStatus{Station: item.Grill, Policy: FifoScheduling, Cooks: 1, Busy: 0, Waiting: 0}, statuses[0])
+
+	// and
+	close(preparation.released)
+	waitGroup.Wait()
+	assert.Equal(t, 3, handler.shelf.GetCurrent("spicy-stripes"))
+}
+
+func shouldConfigureCooksOfEveryStation(t *testing.T) {
+	// given
+	t.Setenv("KITCHEN_WORKERS_MAX", "3")
+	t.Setenv("KITCHEN_STATION_WORKERS__GRILL", "6")
+
+	// when
+	workers := StationWorkersFromEnv()
+
+	// then
+	assert.Equal(t, map[item.Station]int{item.Grill: 6, item.Fryer: 3, item.Wrap: 3, item.Counter: 3}, workers)
+}
+
+func shouldListStations(t *testing.T) {
+	// given
+	engine := gin.Default()
+	NewKitchenEndpoints(NewKitchenStations(NewFifoPolicy(), map[item.Station]int{item.Grill: 2})).Setup(engine)
+
+	req, _ := http.NewRequest("GET", "/admin/kitchen/stations", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var statuses []StationStatus
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+	assert.Len(t, statuses, len(item.Stations))
+	assert.Equal(t, StationStatus{Station: item.Grill, Policy: FifoScheduling, Cooks: 2}, statuses[0])
+}
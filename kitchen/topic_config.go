@@ -1,25 +1,24 @@
 package kitchen
 
 import (
-	"github.com/spf13/cast"
 	"mc-burger-orders/event"
 	"mc-burger-orders/log"
 	"os"
 )
 
+// TopicConfigsFromEnv configures the topic of kitchen requests. Its reader takes in as many requests as may wait to be
+// cooked, KITCHEN_BATCH_MAX_PENDING, without waiting for the requests before them, so requests for an item read one
+// after another from its partition are batched and waiting requests are scheduled by the policy of their station.
 func TopicConfigsFromEnv() *event.TopicConfigs {
 	topic := os.Getenv("KAFKA_TOPICS__KITCHEN_REQUESTS_TOPIC_NAME")
 	if len(topic) <= 0 {
 		log.Error.Panicf("Kafka Topic `kitchen request events handler` name is missing")
 	}
 
-	partition := 3
-	partitionVal := os.Getenv("KAFKA_TOPICS__KITCHEN_REQUESTS_PARTITION")
 	numPartitionsVal := os.Getenv("KAFKA_TOPICS__KITCHEN_REQUESTS_NUMBER_OF_PARTITIONS")
 	replicationFactorVal := os.Getenv("KAFKA_TOPICS__KITCHEN_REQUESTS_REPLICA_FACTOR")
 
-	if len(partitionVal) > 0 {
-		partition = cast.ToInt(partitionVal)
-	}
-	return event.NewTopicConfig(topic, partition, numPartitionsVal, replicationFactorVal)
+	configuration := event.NewTopicConfig(topic, numPartitionsVal, replicationFactorVal)
+	configuration.Reader.InFlight = BatchConfigsFromEnv().MaxPending
+	return configuration
 }
@@ -1,13 +1,19 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"github.com/joho/godotenv"
 	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/mongo"
 	"mc-burger-orders/event"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/kitchen"
 	"mc-burger-orders/log"
 	"mc-burger-orders/middleware"
 	"mc-burger-orders/order/management"
+	"mc-burger-orders/outbox"
+	"mc-burger-orders/projection"
 	"mc-burger-orders/schedule"
 	"mc-burger-orders/shelf"
 	sh "mc-burger-orders/shelf/handler"
@@ -16,10 +22,20 @@ import "github.com/gin-gonic/gin"
 import "mc-burger-orders/order"
 
 func main() {
+	transport := flag.String("transport", "kafka", "carries events between readers and writers, `kafka` or `memory`, MongoDB is required with both")
+	flag.Parse()
+
 	loadEnv()
+	configureTransport(*transport)
+	configureCodecs()
+	defer event.CloseWriters()
 	mongoDb := middleware.GetMongoClient()
-	ordersShelf := shelf.NewEmptyShelf()
-	eventBus := event.NewInternalEventBus()
+	ordersShelf, err := shelf.NewPersistentShelf(context.Background(), shelf.NewShelfRepository(mongoDb))
+	if err != nil {
+		log.Error.Panicf("error when restoring kitchen shelf. Reason: %s", err)
+	}
+	flags, flagsReader := configureFeatureFlags(mongoDb)
+	eventBus := event.NewIdempotentEventBus(event.NewInternalEventBus(), event.NewProcessedMessageRepository(mongoDb))
 
 	shelfTopicConfigs := shelf.TopicConfigsFromEnv()
 	orderStatusTopicConfigs := order.StatusUpdatedTopicConfigsFromEnv()
@@ -30,25 +46,27 @@ func main() {
 
 	ordersShelf.ConfigureWriter(event.NewTopicWriter(shelfTopicConfigs))
 	shelfHandlerTopicConfig := sh.TopicConfigsFromEnv()
-	shelfHandler := sh.NewShelfHandler(kitchenTopicConfigs, ordersShelf)
+	shelfHandler := sh.NewShelfHandler(kitchenTopicConfigs, ordersShelf, flags)
 
-	orderJobsReader := event.NewTopicReader(orderManagementJobsTopicConfigs, eventBus)
-	orderStatusReader := event.NewTopicReader(orderStatusTopicConfigs, eventBus)
+	retryConfigs := event.RetryConfigsFromEnv()
+	orderJobsReader := event.NewTopicReader(orderManagementJobsTopicConfigs, eventBus).EnableRetries(retryConfigs)
+	orderStatusReader := event.NewTopicReader(orderStatusTopicConfigs, eventBus).EnableRetries(retryConfigs)
 
-	stackTopicReader := event.NewTopicReader(shelfTopicConfigs, eventBus)
-	shelfSchedulerReader := event.NewTopicReader(shelfHandlerTopicConfig, eventBus)
+	stackTopicReader := event.NewTopicReader(shelfTopicConfigs, eventBus).EnableRetries(retryConfigs)
+	shelfSchedulerReader := event.NewTopicReader(shelfHandlerTopicConfig, eventBus).EnableRetries(retryConfigs)
 
-	orderStreamService := order.NewOrderStreamService(orderStreamTopicConfigs)
-	orderCommandsHandler := order.NewHandler(mongoDb, kitchenTopicConfigs, orderStatusTopicConfigs, orderStreamService, ordersShelf)
-	orderManagementCommandsHandler := management.NewHandler(mongoDb, kitchenTopicConfigs)
+	orderEvents := order.NewOrderEvents(orderStreamTopicConfigs, orderStatusTopicConfigs)
+	outboxRelay := outbox.NewRelay(outbox.NewRepository(mongoDb), orderStreamTopicConfigs, orderStatusTopicConfigs)
+	orderCommandsHandler := order.NewHandler(mongoDb, kitchenTopicConfigs, orderEvents, ordersShelf)
+	orderManagementCommandsHandler := management.NewHandler(mongoDb, kitchenTopicConfigs, flags)
 
-	kitchenTopicReader := event.NewTopicReader(kitchenTopicConfigs, eventBus)
-	kitchenEventsHandler := kitchen.NewHandler(ordersShelf)
+	kitchenTopicReader := event.NewTopicReader(kitchenTopicConfigs, eventBus).EnableRetries(retryConfigs)
+	kitchenEventsHandler := kitchen.NewHandler(ordersShelf, flags)
 
 	r := gin.Default()
 	r.ForwardedByClientIP = true
 
-	err := r.SetTrustedProxies([]string{"127.0.0.1"})
+	err = r.SetTrustedProxies([]string{"127.0.0.1"})
 	if err != nil {
 		log.Error.Panicf("error when setting trusted proxies. Reason: %s", err)
 	}
@@ -58,12 +76,31 @@ func main() {
 	eventBus.AddHandler(kitchenEventsHandler)
 	eventBus.AddHandler(orderManagementCommandsHandler)
 
-	orderEndpoints := order.NewOrderEndpoints(mongoDb, kitchenTopicConfigs, orderStatusTopicConfigs, orderStreamService, ordersShelf)
-	statusUpdatesEndpoints := order.NewOrderStatusEventsEndpoints(mongoDb, orderStatusEndpointsTopicConfigs, orderStreamService)
+	orderReadModels := order.NewOrderReadModels()
+	orderProjector := projection.NewProjector(orderStreamTopicConfigs, projection.NewRepository(mongoDb), orderReadModels.All()...)
+
+	orderEndpoints := order.NewOrderEndpoints(mongoDb, kitchenTopicConfigs, orderEvents, ordersShelf).
+		WithReadModels(orderReadModels, order.QueriesFromReadModelsFromEnv())
+	statusUpdatesEndpoints := order.NewOrderStatusEventsEndpoints(mongoDb, orderStatusEndpointsTopicConfigs, orderEvents)
+
+	deadLetterEndpoints := event.NewDeadLetterEndpoints(
+		event.NewDeadLetterQueue(orderManagementJobsTopicConfigs),
+		event.NewDeadLetterQueue(orderStatusTopicConfigs),
+		event.NewDeadLetterQueue(shelfTopicConfigs),
+		event.NewDeadLetterQueue(shelfHandlerTopicConfig),
+		event.NewDeadLetterQueue(kitchenTopicConfigs),
+	)
 
 	orderEndpoints.Setup(r)
 	statusUpdatesEndpoints.Setup(r)
-
+	deadLetterEndpoints.Setup(r)
+	projection.NewProjectionEndpoints(orderProjector).Setup(r)
+	featureflag.NewFeatureFlagEndpoints(flags).Setup(r)
+	kitchen.NewKitchenEndpoints(kitchenEventsHandler.Stations()).Setup(r)
+
+	go outboxRelay.Run(context.Background())
+	go orderProjector.Run(context.Background())
+	go flagsReader.SubscribeToTopic(make(chan kafka.Message))
 	go stackTopicReader.SubscribeToTopic(make(chan kafka.Message))
 	go kitchenTopicReader.SubscribeToTopic(make(chan kafka.Message))
 	go orderStatusReader.SubscribeToTopic(make(chan kafka.Message))
@@ -79,6 +116,56 @@ func main() {
 	}
 }
 
+// configureTransport keeps topics in memory instead of Kafka when asked for, so the service runs without a broker.
+// State is still kept in MongoDB.
+func configureTransport(transport string) {
+	switch transport {
+	case "kafka":
+	case "memory":
+		log.Warning.Println("Events are kept in memory, they are lost when the service stops. MongoDB is still required")
+		event.UseTransport(event.NewMemoryTransport())
+	default:
+		log.Error.Panicf("unknown transport `%v`, use kafka or memory", transport)
+	}
+}
+
+// configureFeatureFlags restores the flags set at runtime. Changes are read on a bus of their own, the processed
+// messages of the shared bus are shared by all instances while every instance needs to reload the flags.
+func configureFeatureFlags(database *mongo.Database) (*featureflag.Flags, *event.DefaultReader) {
+	definitions := []featureflag.Definition{
+		kitchen.SchedulingPolicyFlag,
+		kitchen.AgingBoostFlag,
+		shelf.FavoritesParFlag,
+		management.RerequestMissingItemsFlag,
+	}
+	flags := featureflag.NewFlags(featureflag.NewRepository(database), featureflag.DefaultsFromEnv(definitions...), definitions...)
+	if err := flags.Load(context.Background()); err != nil {
+		log.Error.Panicf("error when loading feature flags. Reason: %s", err)
+	}
+
+	topicConfigs := featureflag.TopicConfigsFromEnv()
+	flags.ConfigureWriter(event.NewTopicWriter(topicConfigs))
+
+	flagsBus := event.NewInternalEventBus()
+	flagsBus.AddHandler(featureflag.NewHandler(flags))
+	return flags, event.NewTopicReader(topicConfigs, flagsBus)
+}
+
+// configureCodecs writes payloads with an Avro schema as Avro when the schema registry is configured, so producer
+// changes breaking consumers fail when the event is published.
+func configureCodecs() {
+	registry := event.SchemaRegistryFromEnv()
+	if registry == nil {
+		return
+	}
+
+	avroCodec := event.NewAvroCodec(registry).
+		WithSchema(shelf.ItemAddedOnShelfEvent, shelf.ItemAddedOnShelfSchema).
+		WithSchema(kitchen.RequestItemEvent, kitchen.RequestItemSchema).
+		WithSchema(order.StatusUpdatedEvent, order.StatusUpdatedSchema)
+	event.UseCodec(avroCodec, shelf.ItemAddedOnShelfEvent, kitchen.RequestItemEvent, order.StatusUpdatedEvent)
+}
+
 func loadEnv() {
 	err := godotenv.Load()
 
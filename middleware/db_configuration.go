@@ -2,6 +2,7 @@ package middleware
 
 import (
 	"context"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"mc-burger-orders/log"
@@ -23,9 +24,25 @@ func GetMongoClient() *mongo.Database {
 	}
 
 	database := client.Database(db)
+	requireTransactions(database)
 	return database
 }
 
+// requireTransactions stops the service when Mongo runs as a standalone server. Orders are stored together with
+// their outbox events in a transaction, which needs a replica set or a sharded cluster.
+func requireTransactions(database *mongo.Database) {
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	hello := bson.M{}
+	if err := database.RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		log.Error.Fatalln("Unable to check if Mongo supports transactions", err)
+	}
+	if _, isReplicaSet := hello["setName"]; !isReplicaSet && hello["msg"] != "isdbgrid" {
+		log.Error.Fatalln("Mongo does not support transactions, it needs to run as a replica set or a sharded cluster")
+	}
+}
+
 func getConnectionOptions() *options.ClientOptions {
 	url := os.Getenv("DB_MONGO_URL")
 	if len(url) == 0 {
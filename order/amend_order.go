@@ -0,0 +1,10 @@
+package order
+
+import (
+	"mc-burger-orders/kitchen/item"
+)
+
+type AmendOrder struct {
+	Add    []item.Item `json:"add" binding:"omitempty,dive"`
+	Remove []item.Item `json:"remove" binding:"omitempty,dive"`
+}
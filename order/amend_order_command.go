@@ -0,0 +1,126 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/command"
+	item2 "mc-burger-orders/kitchen/item"
+	"mc-burger-orders/log"
+	"mc-burger-orders/shelf"
+	"net/http"
+)
+
+type AmendOrderCommand struct {
+	Repository     OrderRepository
+	Shelf          *shelf.Shelf
+	KitchenService KitchenRequestService
+	OrderNumber    int64
+	AmendOrder     AmendOrder
+}
+
+func (c *AmendOrderCommand) Execute(ctx context.Context, _ kafka.Message, commandResults chan command.TypedResult) {
+	log.Info.Printf("Following order %d is going to be amended with %+v", c.OrderNumber, c.AmendOrder)
+	for attempt := 1; ; attempt++ {
+		order, err := c.Repository.FetchByOrderNumber(ctx, c.OrderNumber)
+		if err != nil {
+			errMessage := fmt.Sprintf("failed to find order by order number. Reason: %v", err.Error())
+			commandResults <- command.NewHttpErrorResult("AmendOrderCommand", errMessage, http.StatusNotFound)
+			return
+		}
+
+		if !isNotInRequiredStatus(order.Status) {
+			errMessage := fmt.Sprintf("requested order in status %v can no longer be amended", order.Status)
+			commandResults <- command.NewHttpErrorResult("AmendOrderCommand", errMessage, http.StatusPreconditionFailed)
+			return
+		}
+
+		itemsToReturn := make([]item2.Item, 0)
+		for _, removed := range c.AmendOrder.Remove {
+			unpacked, err := order.RemoveItem(removed.Name, removed.Quantity)
+			if err != nil {
+				commandResults <- command.NewErrorResult("AmendOrderCommand", err)
+				return
+			}
+			if unpacked > 0 {
+				itemsToReturn = append(itemsToReturn, item2.Item{Name: removed.Name, Quantity: unpacked})
+			}
+		}
+
+		if len(order.Items) == 0 && len(c.AmendOrder.Add) == 0 {
+			err = fmt.Errorf("order needs to contain at least one item, consider cancelling it instead")
+			commandResults <- command.NewErrorResult("AmendOrderCommand", err)
+			return
+		}
+
+		for _, added := range c.AmendOrder.Add {
+			order.AddItem(added.Name, added.Quantity)
+		}
+		order.UpdateStatus()
+
+		result, err := c.Repository.InsertOrUpdate(ctx, order)
+		if isVersionConflict(err) && attempt < maxUpdateAttempts {
+			log.Warning.Printf("Order %d was modified concurrently, amending it again (attempt %d)", c.OrderNumber, attempt)
+			continue
+		}
+		if err != nil {
+			errMessage := fmt.Sprintf("failed to update order `%d`, reason: %v", order.OrderNumber, err)
+			commandResults <- command.NewHttpErrorResult("AmendOrderCommand", errMessage, http.StatusInternalServerError)
+			return
+		}
+		returnItemsToShelf(c.Shelf, c.OrderNumber, itemsToReturn)
+
+		result, err = c.packAddedItems(ctx, result)
+		if err != nil {
+			commandResults <- command.NewErrorResult("AmendOrderCommand", err)
+			return
+		}
+
+		commandResults <- command.NewSuccessfulResult("AmendOrderCommand")
+		return
+	}
+}
+
+func (c *AmendOrderCommand) packAddedItems(ctx context.Context, order *Order) (*Order, error) {
+	if len(c.AmendOrder.Add) == 0 {
+		return order, nil
+	}
+
+	alreadyPacked := len(order.PackedItems)
+	reservations := make([]*shelf.Reservation, 0)
+	for _, added := range c.AmendOrder.Add {
+		isReady, err := item2.IsItemReady(added.Name)
+		if err != nil {
+			releaseReservations(c.Shelf, reservations...)
+			return nil, err
+		}
+
+		if isReady {
+			log.Info.Printf("Item %v is of type automatically ready. Packing automatically.", added.Name)
+			order.PackItem(added.Name, added.Quantity)
+			continue
+		}
+
+		reservation, err := handlePreparationItems(ctx, c.Shelf, c.KitchenService, added, order)
+		if err != nil {
+			releaseReservations(c.Shelf, reservations...)
+			return nil, err
+		}
+		if reservation != nil {
+			reservations = append(reservations, reservation)
+		}
+	}
+
+	if len(order.PackedItems) == alreadyPacked {
+		return order, nil
+	}
+	result, err := storePackedItems(ctx, c.Repository, c.Shelf, order, order.PackedItems[alreadyPacked:])
+	if err != nil {
+		releaseReservations(c.Shelf, reservations...)
+		return nil, err
+	}
+	if err = commitReservations(ctx, c.Repository, c.KitchenService, c.Shelf, result, reservations...); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
@@ -0,0 +1,158 @@
+package order
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/command"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/shelf"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestAmendOrderCommand_Execute(t *testing.T) {
+	t.Run("should request missing items from kitchen when lines are added", shouldRequestMissingItemsWhenLinesAreAdded)
+	t.Run("should return packed items to shelf when lines are removed", shouldReturnPackedItemsToShelfWhenLinesAreRemoved)
+	t.Run("should not amend order when order is already ready", shouldNotAmendOrderWhenOrderIsAlreadyReady)
+	t.Run("should not amend order when removing item not present on the order", shouldNotAmendOrderWhenRemovingUnknownLine)
+}
+
+func shouldRequestMissingItemsWhenLinesAreAdded(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+	s := shelf.NewEmptyShelf()
+
+	kitchenWg := &sync.WaitGroup{}
+	kitchenWg.Add(1)
+	stubKitchenService := NewStubService()
+	stubKitchenService.WithWaitGroup(kitchenWg)
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{
+		OrderNumber: expectedOrderNumber,
+		Items:       []item.Item{{Name: "hamburger", Quantity: 1}},
+		PackedItems: []item.Item{},
+		Status:      Requested,
+	})
+
+	sut := &AmendOrderCommand{
+		Repository:     stubRepository,
+		Shelf:          s,
+		KitchenService: stubKitchenService,
+		OrderNumber:    expectedOrderNumber,
+		AmendOrder:     AmendOrder{Add: []item.Item{{Name: "cheeseburger", Quantity: 2}}},
+	}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.True(t, result.Result)
+
+	upsertArgs := stubRepository.GetUpsertArgs()
+	assert.Len(t, upsertArgs, 1)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}, {Name: "cheeseburger", Quantity: 2}}, upsertArgs[0].Items)
+	assert.Equal(t, Requested, upsertArgs[0].Status)
+
+	// and
+	kitchenWg.Wait()
+	assert.True(t, stubKitchenService.HaveBeenCalledWith(RequestMatchingFnc("cheeseburger", 2)))
+}
+
+func shouldReturnPackedItemsToShelfWhenLinesAreRemoved(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+	s := shelf.NewEmptyShelf()
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{
+		OrderNumber: expectedOrderNumber,
+		Items:       []item.Item{{Name: "hamburger", Quantity: 2}, {Name: "fries", Quantity: 1}},
+		PackedItems: []item.Item{{Name: "hamburger", Quantity: 2}},
+		Status:      InProgress,
+	})
+
+	sut := &AmendOrderCommand{
+		Repository:     stubRepository,
+		Shelf:          s,
+		KitchenService: NewStubService(),
+		OrderNumber:    expectedOrderNumber,
+		AmendOrder:     AmendOrder{Remove: []item.Item{{Name: "hamburger", Quantity: 1}, {Name: "fries", Quantity: 1}}},
+	}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.True(t, result.Result)
+
+	upsertArgs := stubRepository.GetUpsertArgs()
+	assert.Len(t, upsertArgs, 1)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}}, upsertArgs[0].Items)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}}, upsertArgs[0].PackedItems)
+	assert.Equal(t, Ready, upsertArgs[0].Status)
+
+	// and
+	assert.Equal(t, 1, s.GetCurrent("hamburger"))
+
+	// and
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Ready)
+}
+
+func shouldNotAmendOrderWhenOrderIsAlreadyReady(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Ready})
+
+	sut := &AmendOrderCommand{
+		Repository:     stubRepository,
+		Shelf:          shelf.NewEmptyShelf(),
+		KitchenService: NewStubService(),
+		OrderNumber:    expectedOrderNumber,
+		AmendOrder:     AmendOrder{Add: []item.Item{{Name: "fries", Quantity: 1}}},
+	}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.False(t, result.Result)
+	assert.Equal(t, "requested order in status READY can no longer be amended", result.Error.ErrorMessage)
+	assert.Equal(t, http.StatusPreconditionFailed, result.Error.HttpResponse)
+
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+}
+
+func shouldNotAmendOrderWhenRemovingUnknownLine(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested})
+
+	sut := &AmendOrderCommand{
+		Repository:     stubRepository,
+		Shelf:          shelf.NewEmptyShelf(),
+		KitchenService: NewStubService(),
+		OrderNumber:    expectedOrderNumber,
+		AmendOrder:     AmendOrder{Remove: []item.Item{{Name: "fries", Quantity: 1}}},
+	}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.False(t, result.Result)
+	assert.Equal(t, "could not find item `fries` on the order list", result.Error.ErrorMessage)
+	assert.Equal(t, http.StatusBadRequest, result.Error.HttpResponse)
+
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+}
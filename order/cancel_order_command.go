@@ -0,0 +1,79 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/command"
+	item2 "mc-burger-orders/kitchen/item"
+	"mc-burger-orders/log"
+	"mc-burger-orders/shelf"
+	"net/http"
+)
+
+type CancelOrderCommand struct {
+	Repository  OrderRepository
+	Shelf       *shelf.Shelf
+	OrderNumber int64
+}
+
+func (c *CancelOrderCommand) Execute(ctx context.Context, _ kafka.Message, commandResults chan command.TypedResult) {
+	log.Info.Printf("Following order %d is going to be cancelled", c.OrderNumber)
+	for attempt := 1; ; attempt++ {
+		order, err := c.Repository.FetchByOrderNumber(ctx, c.OrderNumber)
+		if err != nil {
+			errMessage := fmt.Sprintf("failed to find order by order number. Reason: %v", err.Error())
+			commandResults <- command.NewHttpErrorResult("CancelOrderCommand", errMessage, http.StatusNotFound)
+			return
+		}
+
+		if order.Status == Collected {
+			errMessage := "requested order already is collected"
+			commandResults <- command.NewHttpErrorResult("CancelOrderCommand", errMessage, http.StatusPreconditionFailed)
+			return
+		}
+
+		if order.Status == Cancelled {
+			errMessage := "requested order already is cancelled"
+			commandResults <- command.NewHttpErrorResult("CancelOrderCommand", errMessage, http.StatusPreconditionFailed)
+			return
+		}
+
+		packedItems := order.PackedItems
+		missingItems := order.GetMissingItems()
+		order.Status = Cancelled
+		order.PackedItems = make([]item2.Item, 0)
+
+		log.Info.Printf("Order %d has been cancelled, outstanding items %+v will not be requested anymore", c.OrderNumber, missingItems)
+		_, err = c.Repository.InsertOrUpdate(ctx, order)
+		if isVersionConflict(err) && attempt < maxUpdateAttempts {
+			log.Warning.Printf("Order %d was modified concurrently, cancelling it again (attempt %d)", c.OrderNumber, attempt)
+			continue
+		}
+		if err != nil {
+			errMessage := fmt.Sprintf("failed to update order `%d`, reason: %v", order.OrderNumber, err)
+			commandResults <- command.NewHttpErrorResult("CancelOrderCommand", errMessage, http.StatusInternalServerError)
+			return
+		}
+
+		returnItemsToShelf(c.Shelf, c.OrderNumber, packedItems)
+		commandResults <- command.NewSuccessfulResult("CancelOrderCommand")
+		return
+	}
+}
+
+func returnItemsToShelf(s *shelf.Shelf, orderNumber int64, packedItems []item2.Item) {
+	for _, packedItem := range packedItems {
+		isReady, err := item2.IsItemReady(packedItem.Name)
+		if err != nil {
+			log.Error.Printf("cannot return item `%v` to shelf. Reason: %v", packedItem.Name, err)
+			continue
+		}
+		if isReady || packedItem.Quantity <= 0 {
+			continue
+		}
+
+		log.Info.Printf("Returning %d of %v from order %d back to shelf", packedItem.Quantity, packedItem.Name, orderNumber)
+		s.AddMany(packedItem.Name, packedItem.Quantity)
+	}
+}
@@ -0,0 +1,122 @@
+package order
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/command"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/shelf"
+	"net/http"
+	"testing"
+)
+
+func TestCancelOrderCommand_Execute(t *testing.T) {
+	t.Run("should return packed items to shelf when order is cancelled", shouldReturnPackedItemsToShelfWhenOrderIsCancelled)
+	t.Run("should not cancel order when order was already collected", shouldNotCancelOrderWhenOrderWasAlreadyCollected)
+	t.Run("should not cancel order when order was already cancelled", shouldNotCancelOrderWhenOrderWasAlreadyCancelled)
+	t.Run("should not cancel order when order by number does not exists", shouldNotCancelOrderWhenOrderByNumberDoesNotExists)
+}
+
+func shouldReturnPackedItemsToShelfWhenOrderIsCancelled(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+	s := shelf.NewEmptyShelf()
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{
+		OrderNumber: expectedOrderNumber,
+		Items:       []item.Item{{Name: "hamburger", Quantity: 3}, {Name: "coke", Quantity: 1}},
+		PackedItems: []item.Item{{Name: "hamburger", Quantity: 2}, {Name: "coke", Quantity: 1}},
+		Status:      InProgress,
+	})
+
+	sut := &CancelOrderCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, Shelf: s}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.True(t, result.Result)
+
+	upsertArgs := stubRepository.GetUpsertArgs()
+	assert.Len(t, upsertArgs, 1)
+	assert.Equal(t, Cancelled, upsertArgs[0].Status)
+	assert.Empty(t, upsertArgs[0].PackedItems)
+	assert.Empty(t, upsertArgs[0].GetMissingItems())
+
+	// and
+	assert.Equal(t, 2, s.GetCurrent("hamburger"))
+	assert.Equal(t, 0, s.GetCurrent("coke"))
+
+	// and
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Cancelled)
+}
+
+func shouldNotCancelOrderWhenOrderWasAlreadyCollected(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Collected})
+
+	sut := &CancelOrderCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, Shelf: shelf.NewEmptyShelf()}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.False(t, result.Result)
+	assert.Equal(t, "requested order already is collected", result.Error.ErrorMessage)
+	assert.Equal(t, http.StatusPreconditionFailed, result.Error.HttpResponse)
+
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+}
+
+func shouldNotCancelOrderWhenOrderWasAlreadyCancelled(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Cancelled})
+
+	sut := &CancelOrderCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, Shelf: shelf.NewEmptyShelf()}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.False(t, result.Result)
+	assert.Equal(t, "requested order already is cancelled", result.Error.ErrorMessage)
+	assert.Equal(t, http.StatusPreconditionFailed, result.Error.HttpResponse)
+
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+}
+
+func shouldNotCancelOrderWhenOrderByNumberDoesNotExists(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+
+	stubRepository.ReturnError(fmt.Errorf("error fetching order"))
+
+	sut := &CancelOrderCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, Shelf: shelf.NewEmptyShelf()}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.False(t, result.Result)
+	assert.Equal(t, "failed to find order by order number. Reason: error fetching order", result.Error.ErrorMessage)
+	assert.Equal(t, http.StatusNotFound, result.Error.HttpResponse)
+
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+}
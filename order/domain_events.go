@@ -0,0 +1,148 @@
+package order
+
+import (
+	"fmt"
+	"mc-burger-orders/eventstore"
+	"mc-burger-orders/kitchen/item"
+	"slices"
+	"time"
+)
+
+const (
+	OrderRequestedEvent = "OrderRequested"
+	ItemPackedEvent     = "ItemPacked"
+	ItemsAmendedEvent   = "ItemsAmended"
+	StatusChangedEvent  = "StatusChanged"
+	OrderCollectedEvent = "OrderCollected"
+)
+
+// DomainEvent is a change of an order recorded in its stream, the order is the fold of its events.
+type DomainEvent interface {
+	EventType() string
+	apply(order *Order)
+}
+
+type OrderRequested struct {
+	OrderNumber int64       `bson:"orderNumber"`
+	CustomerId  int         `bson:"customerId"`
+	Items       []item.Item `bson:"items"`
+	CreatedAt   time.Time   `bson:"createdAt"`
+}
+
+type ItemPacked struct {
+	ItemName string `bson:"itemName"`
+	Quantity int    `bson:"quantity"`
+}
+
+// ItemsAmended replaces the items of the order, items removed by the customer are unpacked.
+type ItemsAmended struct {
+	Items       []item.Item `bson:"items"`
+	PackedItems []item.Item `bson:"packedItems"`
+}
+
+type StatusChanged struct {
+	Status OrderStatus `bson:"status"`
+}
+
+type OrderCollected struct{}
+
+func (e OrderRequested) EventType() string { return OrderRequestedEvent }
+func (e ItemPacked) EventType() string     { return ItemPackedEvent }
+func (e ItemsAmended) EventType() string   { return ItemsAmendedEvent }
+func (e StatusChanged) EventType() string  { return StatusChangedEvent }
+func (e OrderCollected) EventType() string { return OrderCollectedEvent }
+
+func (e OrderRequested) apply(order *Order) {
+	order.OrderNumber = e.OrderNumber
+	order.CustomerId = e.CustomerId
+	order.Items = slices.Clone(e.Items)
+	order.Status = Requested
+	order.CreatedAt = e.CreatedAt
+}
+
+func (e ItemPacked) apply(order *Order) {
+	order.PackedItems = append(slices.Clone(order.PackedItems), item.Item{Name: e.ItemName, Quantity: e.Quantity})
+}
+
+func (e ItemsAmended) apply(order *Order) {
+	order.Items = slices.Clone(e.Items)
+	order.PackedItems = slices.Clone(e.PackedItems)
+}
+
+func (e StatusChanged) apply(order *Order) {
+	order.Status = e.Status
+}
+
+func (e OrderCollected) apply(order *Order) {
+	order.Status = Collected
+}
+
+// orderChanges returns the events turning the previous state of the order into the current one, an order without
+// previous state is requested first. Items packed on top of the packed ones are recorded one by one, any other change
+// of the items amends them.
+func orderChanges(previous *Order, current Order) []DomainEvent {
+	changes := make([]DomainEvent, 0)
+	if previous == nil {
+		requested := OrderRequested{OrderNumber: current.OrderNumber, CustomerId: current.CustomerId, Items: current.Items, CreatedAt: current.CreatedAt}
+		changes = append(changes, requested)
+		previous = foldOrder(nil, requested)
+	}
+
+	packedBefore := len(previous.PackedItems)
+	isPackedOnTop := len(current.PackedItems) >= packedBefore && slices.Equal(current.PackedItems[:packedBefore], previous.PackedItems)
+	if !slices.Equal(current.Items, previous.Items) || !isPackedOnTop {
+		changes = append(changes, ItemsAmended{Items: current.Items, PackedItems: current.PackedItems})
+	} else {
+		for _, packed := range current.PackedItems[packedBefore:] {
+			changes = append(changes, ItemPacked{ItemName: packed.Name, Quantity: packed.Quantity})
+		}
+	}
+
+	switch {
+	case current.Status == previous.Status:
+	case current.Status == Collected:
+		changes = append(changes, OrderCollected{})
+	default:
+		changes = append(changes, StatusChanged{Status: current.Status})
+	}
+	return changes
+}
+
+// foldOrder applies the events on a copy of the state, every event moves the order to its next version.
+func foldOrder(state *Order, events ...DomainEvent) *Order {
+	order := &Order{}
+	if state != nil {
+		order = cloneOrder(state)
+	}
+	for _, domainEvent := range events {
+		domainEvent.apply(order)
+		order.Version++
+	}
+	return order
+}
+
+func decodeDomainEvent(record eventstore.Record) (DomainEvent, error) {
+	var err error
+	switch record.Type {
+	case OrderRequestedEvent:
+		requested := OrderRequested{}
+		err = record.Decode(&requested)
+		return requested, err
+	case ItemPackedEvent:
+		packed := ItemPacked{}
+		err = record.Decode(&packed)
+		return packed, err
+	case ItemsAmendedEvent:
+		amended := ItemsAmended{}
+		err = record.Decode(&amended)
+		return amended, err
+	case StatusChangedEvent:
+		changed := StatusChanged{}
+		err = record.Decode(&changed)
+		return changed, err
+	case OrderCollectedEvent:
+		return OrderCollected{}, nil
+	default:
+		return nil, fmt.Errorf("unknown order event `%v` at version %d of %v", record.Type, record.Version, record.StreamId)
+	}
+}
@@ -1,8 +1,8 @@
 package dto
 
 type KitchenRequestMessage struct {
-	ItemName string `json:"itemName"`
-	Quantity int    `json:"quantity"`
+	ItemName string `json:"itemName" avro:"itemName"`
+	Quantity int    `json:"quantity" avro:"quantity"`
 }
 
 func NewKitchenRequestMessage(name string, quantity int) *KitchenRequestMessage {
@@ -1,6 +1,6 @@
 package dto
 
 type StackItemAddedMessage struct {
-	ItemName string `json:"itemName"`
-	Quantity int    `json:"quantity"`
+	ItemName string `json:"itemName" avro:"itemName"`
+	Quantity int    `json:"quantity" avro:"quantity"`
 }
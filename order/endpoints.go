@@ -1,6 +1,7 @@
 package order
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
@@ -10,7 +11,6 @@ import (
 	"mc-burger-orders/event"
 	i "mc-burger-orders/kitchen/item"
 	"mc-burger-orders/log"
-	"mc-burger-orders/middleware"
 	"mc-burger-orders/shelf"
 	"mc-burger-orders/testing/utils"
 	"net/http"
@@ -22,33 +22,39 @@ type Endpoints struct {
 	queryService    OrderQueryService
 	orderRepository OrderRepository
 	kitchenService  KitchenRequestService
-	statusEmitter   StatusEmitter
 	dispatcher      command.Dispatcher
+	readModels      *OrderReadModels
 }
 
-func NewOrderEndpoints(database *mongo.Database, kitchenTopicConfigs *event.TopicConfigs, statusEmitterTopicConfigs *event.TopicConfigs, streamService OrderStreamService, s *shelf.Shelf) middleware.EndpointsSetup {
-	repository := NewRepository(database, streamService)
+func NewOrderEndpoints(database *mongo.Database, kitchenTopicConfigs *event.TopicConfigs, orderEvents *OrderEvents, s *shelf.Shelf) *Endpoints {
+	repository := NewRepository(database, orderEvents)
 	orderNumberRepository := NewOrderNumberRepository(database)
 	queryService := OrderQueryService{Repository: repository, orderNumberRepository: orderNumberRepository}
 	kitchenService := NewKitchenServiceFrom(kitchenTopicConfigs)
-	statusEmitter := NewStatusEmitterFrom(statusEmitterTopicConfigs)
 
 	return &Endpoints{
 		stack:           s,
 		queryService:    queryService,
 		orderRepository: repository,
 		kitchenService:  kitchenService,
-		statusEmitter:   statusEmitter,
 		dispatcher:      &command.DefaultDispatcher{},
 	}
 }
 
+// WithReadModels exposes the read models, order queries are answered by them when serveQueries is set.
+func (e *Endpoints) WithReadModels(readModels *OrderReadModels, serveQueries bool) *Endpoints {
+	e.readModels = readModels
+	if serveQueries {
+		e.queryService.Repository = &readModelQueries{readModels: readModels, repository: e.orderRepository}
+	}
+	return e
+}
+
 func (e *Endpoints) CreateNewOrderCommand(orderNumber int64, order NewOrder) command.Command {
 	return &NewRequestCommand{
 		Shelf:          e.stack,
 		Repository:     e.orderRepository,
 		KitchenService: e.kitchenService,
-		StatusEmitter:  e.statusEmitter,
 		OrderNumber:    orderNumber,
 		NewOrder:       order,
 	}
@@ -56,8 +62,17 @@ func (e *Endpoints) CreateNewOrderCommand(orderNumber int64, order NewOrder) com
 
 func (e *Endpoints) Setup(r *gin.Engine) {
 	r.GET("/order", e.queryService.FetchOrders)
+	r.GET("/order/:orderNumber", e.queryService.FetchOrder)
 	r.POST("/order", e.newOrderHandler)
 	r.POST("/order/:orderNumber/collect", e.collectOrderHandler)
+	r.POST("/order/:orderNumber/cancel", e.cancelOrderHandler)
+	r.PATCH("/order/:orderNumber", e.amendOrderHandler)
+
+	if e.readModels != nil {
+		r.GET("/read-models/active-orders", e.activeOrdersHandler)
+		r.GET("/read-models/customer-history/:customerId", e.customerHistoryHandler)
+		r.GET("/read-models/item-backlog", e.itemBacklogHandler)
+	}
 }
 
 func (e *Endpoints) newOrderHandler(c *gin.Context) {
@@ -79,7 +94,7 @@ func (e *Endpoints) newOrderHandler(c *gin.Context) {
 	commandResults := make(chan command.TypedResult)
 	orderNumber := e.queryService.GetNextOrderNumber(c)
 	cmd := e.CreateNewOrderCommand(orderNumber, newOrder)
-	go e.dispatcher.Execute(cmd, kafka.Message{}, commandResults)
+	go e.dispatcher.Execute(context.Background(), cmd, kafka.Message{}, commandResults)
 
 	commandResult := <-commandResults
 
@@ -104,8 +119,8 @@ func (e *Endpoints) collectOrderHandler(c *gin.Context) {
 	}
 
 	commandResults := make(chan command.TypedResult)
-	cmd := &OrderCollectedCommand{OrderNumber: orderNumber, Repository: e.orderRepository, StatusEmitter: e.statusEmitter}
-	go e.dispatcher.Execute(cmd, kafka.Message{}, commandResults)
+	cmd := &OrderCollectedCommand{OrderNumber: orderNumber, Repository: e.orderRepository}
+	go e.dispatcher.Execute(context.Background(), cmd, kafka.Message{}, commandResults)
 
 	commandResult := <-commandResults
 
@@ -118,9 +133,94 @@ func (e *Endpoints) collectOrderHandler(c *gin.Context) {
 	c.JSON(http.StatusNoContent, nil)
 }
 
+func (e *Endpoints) cancelOrderHandler(c *gin.Context) {
+	param := c.Param("orderNumber")
+	orderNumber, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		log.Error.Println("Unable to parse url parameter to OrderNumber", err.Error())
+
+		errResponse := fmt.Sprintf("unable to parse url parameter to OrderNumber. Reason - %v", err.Error())
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(errResponse))
+		return
+	}
+
+	commandResults := make(chan command.TypedResult)
+	cmd := &CancelOrderCommand{OrderNumber: orderNumber, Repository: e.orderRepository, Shelf: e.stack}
+	go e.dispatcher.Execute(context.Background(), cmd, kafka.Message{}, commandResults)
+
+	commandResult := <-commandResults
+
+	if commandResult.Error != nil {
+		log.Error.Println(commandResult.Error.ErrorMessage)
+		c.JSON(commandResult.Error.HttpResponse, utils.ErrorPayload(commandResult.Error.ErrorMessage))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func (e *Endpoints) amendOrderHandler(c *gin.Context) {
+	param := c.Param("orderNumber")
+	orderNumber, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		log.Error.Println("Unable to parse url parameter to OrderNumber", err.Error())
+
+		errResponse := fmt.Sprintf("unable to parse url parameter to OrderNumber. Reason - %v", err.Error())
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(errResponse))
+		return
+	}
+
+	amendOrder := AmendOrder{}
+	err = c.ShouldBindJSON(&amendOrder)
+	if err != nil {
+		errorMessage := fmt.Sprintf("Schema Error. %s", err)
+		log.Info.Println("Amend Order request Error: ", errorMessage)
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(errorMessage))
+		return
+	}
+	err = validateAmendment(amendOrder)
+	if err != nil {
+		log.Error.Println(err)
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(err.Error()))
+		return
+	}
+
+	commandResults := make(chan command.TypedResult)
+	cmd := &AmendOrderCommand{
+		Repository:     e.orderRepository,
+		Shelf:          e.stack,
+		KitchenService: e.kitchenService,
+		OrderNumber:    orderNumber,
+		AmendOrder:     amendOrder,
+	}
+	go e.dispatcher.Execute(context.Background(), cmd, kafka.Message{}, commandResults)
+
+	commandResult := <-commandResults
+
+	if commandResult.Error != nil {
+		log.Error.Println(commandResult.Error.ErrorMessage)
+		c.JSON(commandResult.Error.HttpResponse, utils.ErrorPayload(commandResult.Error.ErrorMessage))
+		return
+	}
+
+	c.JSON(http.StatusNoContent, nil)
+}
+
+func validateAmendment(c AmendOrder) error {
+	if len(c.Add) == 0 && len(c.Remove) == 0 {
+		return errors.New("amendment needs to add or remove at least one item")
+	}
+
+	return errors.Join(validateItems(c.Add), validateItems(c.Remove))
+}
+
 func validate(c NewOrder) error {
+	return validateItems(c.Items)
+}
+
+func validateItems(items []i.Item) error {
 	var errs []error
-	for _, item := range c.Items {
+	for _, item := range items {
 		if err := i.IsKnownItem(item.Name); err != nil {
 			errs = append(errs, err)
 		}
@@ -10,16 +10,18 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go/modules/mongodb"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
 	"math/rand"
 	"mc-burger-orders/event"
 	"mc-burger-orders/kitchen/item"
 	s "mc-burger-orders/order/dto"
+	"mc-burger-orders/outbox"
 	"mc-burger-orders/shelf"
 	"mc-burger-orders/testing/utils"
 	"net/http"
 	"net/http/httptest"
-	"sync"
 	"testing"
 	"time"
 )
@@ -29,6 +31,7 @@ var (
 	database                   *mongo.Database
 	collectionDb               *mongo.Collection
 	orderNumberCollectionDb    *mongo.Collection
+	outboxCollectionDb         *mongo.Collection
 	kitchenRequestsKafkaConfig *event.TopicConfigs
 	kitchenRequestsReader      *kafka.Reader
 	topic                      = fmt.Sprintf("test-kitchen-requests-%d", rand.Intn(100))
@@ -44,8 +47,10 @@ func TestIntegrationOrder_HttpEndpoints(t *testing.T) {
 
 	collectionDb = database.Collection("orders")
 	orderNumberCollectionDb = database.Collection("order-numbers")
+	outboxCollectionDb = database.Collection("outbox")
 
 	t.Run("should return orders", shouldFetchOrdersWhenMultipleStored)
+	t.Run("should return filtered page of orders", shouldFetchFilteredPageOfOrders)
 	t.Run("should store and begin packing order when received valid request", shouldBeginPackingAndStoreOrderWhenRequested)
 	t.Run("should collect order when given number is already ready", shouldCollectOrderWhenGivenNumberIsAlreadyReady)
 	t.Run("should return 404 when collecting unknown order", shouldReturn404WhenCollectingUnknownOrder)
@@ -68,9 +73,7 @@ func shouldFetchOrdersWhenMultipleStored(t *testing.T) {
 	utils.DeleteMany(t, collectionDb, bson.D{})
 	utils.InsertMany(t, collectionDb, expectedOrders)
 
-	orderStream := NewStubService()
-
-	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, orderStatusKafkaConfig, orderStream, shelf.NewEmptyShelf())
+	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, testOrderEvents(), shelf.NewEmptyShelf())
 	engine := utils.SetUpRouter(endpoints.Setup)
 
 	req, _ := http.NewRequest("GET", "/order", nil)
@@ -99,6 +102,48 @@ func shouldFetchOrdersWhenMultipleStored(t *testing.T) {
 	defer func() {
 		utils.DeleteMany(t, collectionDb, bson.D{})
 		utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+		utils.DeleteMany(t, outboxCollectionDb, bson.D{})
+	}()
+}
+
+func shouldFetchFilteredPageOfOrders(t *testing.T) {
+	// given
+	thirdId, fourthId := primitive.NewObjectID(), primitive.NewObjectID()
+	expectedOrders := []interface{}{
+		Order{OrderNumber: 1000, CustomerId: 1, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Collected, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+		Order{OrderNumber: 1001, CustomerId: 3, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Collected, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+		Order{OrderNumber: 1002, CustomerId: 3, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Ready, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+		Order{Id: &thirdId, OrderNumber: 1003, CustomerId: 3, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Collected, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+		Order{Id: &fourthId, OrderNumber: 1004, CustomerId: 3, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Collected, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+	}
+	utils.DeleteMany(t, collectionDb, bson.D{})
+	utils.InsertMany(t, collectionDb, expectedOrders)
+
+	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, testOrderEvents(), shelf.NewEmptyShelf())
+	engine := utils.SetUpRouter(endpoints.Setup)
+
+	req, _ := http.NewRequest("GET", "/order?status=COLLECTED&customerId=3&sort=-orderNumber&after="+fourthId.Hex()+"&limit=1", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, thirdId.Hex(), resp.Header().Get("X-Next-Cursor"))
+
+	// and
+	var payload []map[string]any
+	err := json.Unmarshal(resp.Body.Bytes(), &payload)
+	if err != nil {
+		assert.Fail(t, "Error while unmarshalling response payload to map", err)
+	}
+
+	assert.Equal(t, 1, len(payload))
+	assert.Equal(t, 1003.0, payload[0]["orderNumber"])
+
+	defer func() {
+		utils.DeleteMany(t, collectionDb, bson.D{})
 	}()
 }
 
@@ -146,15 +191,9 @@ func shouldBeginPackingAndStoreOrderWhenRequested(t *testing.T) {
 	req, _ := http.NewRequest("POST", "/order", reqBody)
 	resp := httptest.NewRecorder()
 
-	wg := &sync.WaitGroup{}
-	wg.Add(2)
-
-	orderStream := NewStubService()
-	orderStream.WithWaitGroup(wg)
+	repository := NewRepository(database, testOrderEvents())
 
-	repository := NewRepository(database, orderStream)
-
-	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, orderStatusKafkaConfig, orderStream, shelf.NewEmptyShelf())
+	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, testOrderEvents(), shelf.NewEmptyShelf())
 	engine := utils.SetUpRouter(endpoints.Setup)
 
 	kitchenRequestsReader = kafka.NewReader(kafka.ReaderConfig{
@@ -201,14 +240,18 @@ func shouldBeginPackingAndStoreOrderWhenRequested(t *testing.T) {
 		assert.Equal(t, len(expectedMessages), len(actualMessages))
 		assert.Equal(t, expectedMessages, actualMessages)
 
-		wg.Wait()
-		assert.Len(t, orderStream.GetEmitUpdatedEventArgs(), 2)
+		// and
+		updatedOrders := fetchOutboxOrders(t, orderStreamTopic)
+		assert.Len(t, updatedOrders, 2)
+
+		assert.Equal(t, expectedOrderNumber, updatedOrders[0].OrderNumber)
+		assert.Equal(t, expectedOrderNumber, updatedOrders[1].OrderNumber)
 
-		assert.Equal(t, expectedOrderNumber, orderStream.GetEmitUpdatedEventArgs()[0].OrderNumber)
-		assert.Equal(t, expectedOrderNumber, orderStream.GetEmitUpdatedEventArgs()[1].OrderNumber)
+		assert.Equal(t, Requested, updatedOrders[0].Status)
+		assert.Equal(t, InProgress, updatedOrders[1].Status)
 
-		assert.Equal(t, Requested, orderStream.GetEmitUpdatedEventArgs()[0].Status)
-		assert.Equal(t, InProgress, orderStream.GetEmitUpdatedEventArgs()[1].Status)
+		// and
+		assert.Len(t, fetchOutboxMessages(t, orderStatusTopic), 2)
 	} else {
 		assert.Fail(t, "Failed to read order by number from DB")
 	}
@@ -217,6 +260,7 @@ func shouldBeginPackingAndStoreOrderWhenRequested(t *testing.T) {
 		utils.TerminateKafkaReader(t, kitchenRequestsReader)
 		utils.DeleteMany(t, collectionDb, bson.D{})
 		utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+		utils.DeleteMany(t, outboxCollectionDb, bson.D{})
 	}()
 }
 
@@ -234,15 +278,9 @@ func shouldCollectOrderWhenGivenNumberIsAlreadyReady(t *testing.T) {
 	req, _ := http.NewRequest("POST", fmt.Sprintf("/order/%d/collect", expectedOrderNumber), nil)
 	resp := httptest.NewRecorder()
 
-	wg := &sync.WaitGroup{}
-	wg.Add(1)
-
-	orderStream := NewStubService()
-	orderStream.WithWaitGroup(wg)
-
-	repository := NewRepository(database, orderStream)
+	repository := NewRepository(database, testOrderEvents())
 
-	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, orderStatusKafkaConfig, orderStream, shelf.NewEmptyShelf())
+	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, testOrderEvents(), shelf.NewEmptyShelf())
 	engine := utils.SetUpRouter(endpoints.Setup)
 
 	// when
@@ -256,10 +294,11 @@ func shouldCollectOrderWhenGivenNumberIsAlreadyReady(t *testing.T) {
 		assert.Equal(t, expectedOrderNumber, actualOrder.OrderNumber)
 		assert.Equal(t, Collected, actualOrder.Status)
 
-		wg.Wait()
-		assert.Len(t, orderStream.GetEmitUpdatedEventArgs(), 1)
-		assert.Equal(t, expectedOrderNumber, orderStream.GetEmitUpdatedEventArgs()[0].OrderNumber)
-		assert.Equal(t, Collected, orderStream.GetEmitUpdatedEventArgs()[0].Status)
+		// and
+		updatedOrders := fetchOutboxOrders(t, orderStreamTopic)
+		assert.Len(t, updatedOrders, 1)
+		assert.Equal(t, expectedOrderNumber, updatedOrders[0].OrderNumber)
+		assert.Equal(t, Collected, updatedOrders[0].Status)
 	} else {
 		assert.Fail(t, "Failed to read order by number from DB")
 	}
@@ -267,6 +306,7 @@ func shouldCollectOrderWhenGivenNumberIsAlreadyReady(t *testing.T) {
 	defer func() {
 		utils.DeleteMany(t, collectionDb, bson.D{})
 		utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+		utils.DeleteMany(t, outboxCollectionDb, bson.D{})
 	}()
 }
 
@@ -278,9 +318,7 @@ func shouldReturn404WhenCollectingUnknownOrder(t *testing.T) {
 	req, _ := http.NewRequest("POST", fmt.Sprintf("/order/%d/collect", expectedOrderNumber), nil)
 	resp := httptest.NewRecorder()
 
-	orderStream := NewStubService()
-
-	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, orderStatusKafkaConfig, orderStream, shelf.NewEmptyShelf())
+	endpoints := NewOrderEndpoints(database, kitchenRequestsKafkaConfig, testOrderEvents(), shelf.NewEmptyShelf())
 	engine := utils.SetUpRouter(endpoints.Setup)
 
 	// when
@@ -300,6 +338,33 @@ func shouldReturn404WhenCollectingUnknownOrder(t *testing.T) {
 	assert.Equal(t, "failed to find order by order number. Reason: mongo: no documents in result", payload["errorMessage"])
 }
 
+func fetchOutboxMessages(t *testing.T, topic string) []outbox.Message {
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}})
+	cursor, err := outboxCollectionDb.Find(context.Background(), bson.D{{Key: "topic", Value: topic}}, findOptions)
+	if err != nil {
+		assert.Fail(t, "failed to fetch outbox messages", err)
+		return nil
+	}
+
+	messages := make([]outbox.Message, 0)
+	if err = cursor.All(context.Background(), &messages); err != nil {
+		assert.Fail(t, "failed to decode outbox messages", err)
+	}
+	return messages
+}
+
+func fetchOutboxOrders(t *testing.T, topic string) []Order {
+	orders := make([]Order, 0)
+	for _, message := range fetchOutboxMessages(t, topic) {
+		o := Order{}
+		if err := json.Unmarshal(message.Value, &o); err != nil {
+			assert.Fail(t, "failed to unmarshal outbox message", err)
+		}
+		orders = append(orders, o)
+	}
+	return orders
+}
+
 func ReadMessages(t *testing.T) []*s.KitchenRequestMessage {
 	retries := 2
 	messages := make([]kafka.Message, 0)
@@ -2,11 +2,15 @@ package order
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"github.com/segmentio/kafka-go"
 	"github.com/spf13/cast"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	command2 "mc-burger-orders/command"
+	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/middleware"
 	"mc-burger-orders/shelf"
 	"mc-burger-orders/testing/utils"
@@ -28,7 +32,7 @@ type FakeCommandDispatcher struct {
 	methodCalled bool
 }
 
-func (e *FakeCommandDispatcher) Execute(c command2.Command, message kafka.Message, commandResults chan command2.TypedResult) {
+func (e *FakeCommandDispatcher) Execute(ctx context.Context, c command2.Command, message kafka.Message, commandResults chan command2.TypedResult) {
 	e.methodCalled = true
 	commandResults <- command2.TypedResult{Result: e.result, Type: "FakeCommandDispatcher"}
 }
@@ -47,6 +51,9 @@ func Test_UnitOrderEndpoints(t *testing.T) {
 	t.Run("should execute order request command when request is valid", shouldExecuteNewOrderCommand)
 	t.Run("should return BAD REQUEST when request has no items", shouldReturnBadRequestWhenItemsEmpty)
 	t.Run("should return BAD REQUEST when request is missing items", shouldReturnBadRequestWhenNoItems)
+	t.Run("should return order with per line packing progress", shouldReturnOrderWithPerLinePackingProgress)
+	t.Run("should return NOT FOUND when order does not exist", shouldReturnNotFoundWhenOrderDoesNotExist)
+	t.Run("should fetch orders matching query criteria with next page cursor", shouldFetchOrdersMatchingQueryCriteria)
 }
 
 func shouldExecuteNewOrderCommand(t *testing.T) {
@@ -195,3 +202,119 @@ func shouldReturnBadRequestWhenItemsEmpty(t *testing.T) {
 	// and
 	assert.False(t, fakeEndpoints.dispatcher.methodCalled)
 }
+
+func shouldReturnOrderWithPerLinePackingProgress(t *testing.T) {
+	// given
+	req, _ := http.NewRequest("GET", "/order/1010", nil)
+	resp := httptest.NewRecorder()
+
+	repository := GivenRepository()
+	repository.ReturnFetchByOrderNumber(&Order{
+		OrderNumber: 1010,
+		CustomerId:  10,
+		Items:       []item.Item{{Name: "hamburger", Quantity: 3}, {Name: "coke", Quantity: 1}},
+		PackedItems: []item.Item{{Name: "hamburger", Quantity: 1}, {Name: "coke", Quantity: 1}, {Name: "hamburger", Quantity: 1}},
+		Status:      InProgress,
+	})
+
+	fakeEndpoints := FakeOrderEndpoints{
+		s:              shelf.NewEmptyShelf(),
+		repository:     repository,
+		queryService:   OrderQueryService{Repository: repository, orderNumberRepository: repository},
+		kitchenService: &KitchenService{},
+		dispatcher:     &FakeCommandDispatcher{},
+	}
+	endpoints := fakeEndpoints.FakeEndpoints()
+	engine := utils.SetUpRouter(endpoints.Setup)
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	// and
+	var payload OrderDetails
+	err := json.Unmarshal(resp.Body.Bytes(), &payload)
+	if err != nil {
+		assert.Fail(t, "Error while unmarshalling response payload to OrderDetails", err)
+	}
+
+	assert.Equal(t, int64(1010), payload.OrderNumber)
+	assert.Equal(t, InProgress, payload.Status)
+	assert.Equal(t, []OrderLine{
+		{Name: "hamburger", Ordered: 3, Packed: 2, Missing: 1, InstantReady: false},
+		{Name: "coke", Ordered: 1, Packed: 1, Missing: 0, InstantReady: true},
+	}, payload.Lines)
+
+	// and
+	assert.False(t, fakeEndpoints.dispatcher.methodCalled)
+}
+
+func shouldReturnNotFoundWhenOrderDoesNotExist(t *testing.T) {
+	// given
+	req, _ := http.NewRequest("GET", "/order/1010", nil)
+	resp := httptest.NewRecorder()
+
+	repository := GivenRepository()
+	repository.ReturnError(mongo.ErrNoDocuments)
+
+	fakeEndpoints := FakeOrderEndpoints{
+		s:              shelf.NewEmptyShelf(),
+		repository:     repository,
+		queryService:   OrderQueryService{Repository: repository, orderNumberRepository: repository},
+		kitchenService: &KitchenService{},
+		dispatcher:     &FakeCommandDispatcher{},
+	}
+	endpoints := fakeEndpoints.FakeEndpoints()
+	engine := utils.SetUpRouter(endpoints.Setup)
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+
+	// and
+	var payload map[string]any
+	err := json.Unmarshal(resp.Body.Bytes(), &payload)
+	if err != nil {
+		assert.Fail(t, "Error while unmarshalling response payload to map", err)
+	}
+
+	assert.Equal(t, "order 1010 does not exist", payload["errorMessage"])
+}
+
+func shouldFetchOrdersMatchingQueryCriteria(t *testing.T) {
+	// given
+	req, _ := http.NewRequest("GET", "/order?status=COLLECTED&customerId=3&limit=2", nil)
+	resp := httptest.NewRecorder()
+
+	lastOrderId := primitive.NewObjectID()
+	repository := GivenRepository()
+	repository.ReturnOrders(&Order{OrderNumber: 1000, CustomerId: 3, Status: Collected}, &Order{Id: &lastOrderId, OrderNumber: 1002, CustomerId: 3, Status: Collected})
+
+	fakeEndpoints := FakeOrderEndpoints{
+		s:              shelf.NewEmptyShelf(),
+		repository:     repository,
+		queryService:   OrderQueryService{Repository: repository, orderNumberRepository: repository},
+		kitchenService: &KitchenService{},
+		dispatcher:     &FakeCommandDispatcher{},
+	}
+	endpoints := fakeEndpoints.FakeEndpoints()
+	engine := utils.SetUpRouter(endpoints.Setup)
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.Equal(t, lastOrderId.Hex(), resp.Header().Get("X-Next-Cursor"))
+
+	// and
+	criteria := repository.GetFetchManyArgs()
+	assert.Len(t, criteria, 1)
+	assert.Equal(t, []OrderStatus{Collected}, criteria[0].Statuses)
+	assert.Equal(t, 3, *criteria[0].CustomerId)
+	assert.Equal(t, int64(2), criteria[0].Limit)
+}
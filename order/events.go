@@ -5,3 +5,17 @@ var (
 	StatusUpdatedEvent = "order-status-updated"
 	OrderUpdatedEvent  = "order-updated"
 )
+
+// StatusUpdatedSchema is the Avro schema of order-status-updated payloads.
+const StatusUpdatedSchema = `{
+  "type": "record",
+  "name": "StatusUpdated",
+  "namespace": "mcburger.order",
+  "fields": [
+    {"name": "status", "type": "string"}
+  ]
+}`
+
+type StatusUpdatedPayload struct {
+	Status OrderStatus `json:"status" avro:"status"`
+}
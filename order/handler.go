@@ -1,6 +1,7 @@
 package order
 
 import (
+	"context"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/mongo"
@@ -8,7 +9,6 @@ import (
 	"mc-burger-orders/event"
 	"mc-burger-orders/log"
 	"mc-burger-orders/shelf"
-	utils2 "mc-burger-orders/utils"
 )
 
 type OrdersHandler struct {
@@ -16,35 +16,31 @@ type OrdersHandler struct {
 	shelf          *shelf.Shelf
 	queryService   OrderQueryService
 	repository     OrderRepository
-	statusEmitter  StatusEmitter
 	kitchenService KitchenRequestService
 }
 
-func NewHandler(database *mongo.Database, kitchenTopicConfigs *event.TopicConfigs, statusEmitterTopicConfigs *event.TopicConfigs, orderStreamService OrderStreamService, s *shelf.Shelf) *OrdersHandler {
-	repository := NewRepository(database, orderStreamService)
+func NewHandler(database *mongo.Database, kitchenTopicConfigs *event.TopicConfigs, orderEvents *OrderEvents, s *shelf.Shelf) *OrdersHandler {
+	repository := NewRepository(database, orderEvents)
 	orderNumberRepository := NewOrderNumberRepository(database)
 	queryService := OrderQueryService{Repository: repository, orderNumberRepository: orderNumberRepository}
 	kitchenService := NewKitchenServiceFrom(kitchenTopicConfigs)
-	statusEmitter := NewStatusEmitterFrom(statusEmitterTopicConfigs)
 
 	return &OrdersHandler{
 		shelf:          s,
 		queryService:   queryService,
 		repository:     repository,
 		kitchenService: kitchenService,
-		statusEmitter:  statusEmitter,
 		defaultHandler: command.DefaultCommandHandler{},
 	}
 }
 
-func (o *OrdersHandler) Handle(message kafka.Message, commandResults chan command.TypedResult) {
-	commands, err := o.GetCommands(message)
+func (o *OrdersHandler) Handle(ctx context.Context, eventType string, message kafka.Message) command.Results {
+	commands, err := o.GetCommands(eventType, message)
 	if err != nil {
-		commandResults <- command.NewErrorResult("OrderHandler", err)
-		return
+		return command.Results{command.NewErrorResult("OrderHandler", err)}
 	}
 
-	o.defaultHandler.HandleCommands(message, commandResults, commands...)
+	return o.defaultHandler.HandleCommands(ctx, message, commands...)
 }
 
 func (o *OrdersHandler) GetHandledEvents() []string {
@@ -55,13 +51,7 @@ func (o *OrdersHandler) AddCommands(event string, commands ...command.Command) {
 	o.defaultHandler.AddCommands(event, commands...)
 }
 
-func (o *OrdersHandler) GetCommands(message kafka.Message) ([]command.Command, error) {
-	eventType, err := utils2.GetEventType(message)
-	if err != nil {
-		log.Error.Println(err.Error())
-		return nil, err
-	}
-
+func (o *OrdersHandler) GetCommands(eventType string, message kafka.Message) ([]command.Command, error) {
 	commands := make([]command.Command, 0)
 	switch eventType {
 	case shelf.ItemAddedOnShelfEvent:
@@ -70,12 +60,11 @@ func (o *OrdersHandler) GetCommands(message kafka.Message) ([]command.Command, e
 				Shelf:          o.shelf,
 				Repository:     o.repository,
 				KitchenService: o.kitchenService,
-				StatusEmitter:  o.statusEmitter,
 			})
 		}
 	case StatusUpdatedEvent:
 		{
-			orderNumber, err := utils2.GetOrderNumber(message)
+			orderNumber, err := event.GetOrderNumber(message)
 			if err != nil {
 				log.Error.Println(err.Error())
 				return nil, err
@@ -87,7 +76,7 @@ func (o *OrdersHandler) GetCommands(message kafka.Message) ([]command.Command, e
 		}
 	case CollectedEvent:
 		{
-			orderNumber, err := utils2.GetOrderNumber(message)
+			orderNumber, err := event.GetOrderNumber(message)
 			if err != nil {
 				log.Error.Println(err.Error())
 				return nil, err
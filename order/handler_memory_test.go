@@ -0,0 +1,78 @@
+package order
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/command"
+	"mc-burger-orders/event"
+	"mc-burger-orders/featureflag"
+	"mc-burger-orders/kitchen"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/outbox"
+	"mc-burger-orders/shelf"
+	"testing"
+	"time"
+)
+
+func TestOrdersHandler_WithMemoryTransport(t *testing.T) {
+	t.Run("should pack items cooked by kitchen into order", shouldPackItemsCookedByKitchenIntoOrder)
+}
+
+func shouldPackItemsCookedByKitchenIntoOrder(t *testing.T) {
+	// given
+	t.Setenv("KITCHEN_BATCH_WINDOW", "10ms")
+	transport := event.NewMemoryTransport()
+	kitchenConfig := givenMemoryTopicConfigs(transport, "kitchen-requests")
+	kitchenConfig.Reader.InFlight = 10
+	shelfConfig := givenMemoryTopicConfigs(transport, "shelf-events")
+	orderEvents := NewOrderEvents(givenMemoryTopicConfigs(transport, "order-stream"), givenMemoryTopicConfigs(transport, "order-status"))
+
+	s := shelf.NewEmptyShelf()
+	s.ConfigureWriter(event.NewTopicWriter(shelfConfig))
+	flags := featureflag.NewFlags(featureflag.NewInMemoryRepository(), map[string]string{}, kitchen.SchedulingPolicyFlag, shelf.FavoritesParFlag)
+	repository := NewInMemoryRepository(orderEvents, outbox.GivenRepository())
+	kitchenService := NewKitchenServiceFrom(kitchenConfig)
+
+	bus := event.NewInternalEventBus()
+	bus.AddHandler(kitchen.NewHandler(s, flags))
+	bus.AddHandler(&OrdersHandler{shelf: s, repository: repository, kitchenService: kitchenService, defaultHandler: command.DefaultCommandHandler{}})
+	givenSubscribedReader(t, kitchenConfig, bus)
+	givenSubscribedReader(t, shelfConfig, bus)
+
+	results := make(chan command.TypedResult)
+	sut := &NewRequestCommand{
+		Repository:     repository,
+		Shelf:          s,
+		KitchenService: kitchenService,
+		OrderNumber:    1,
+		NewOrder:       NewOrder{CustomerId: 1, Items: []item.Item{{Name: "hamburger", Quantity: 2}, {Name: "fries", Quantity: 1}}},
+	}
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, results)
+
+	// then
+	result := <-results
+	assert.True(t, result.Result)
+	assert.Nil(t, result.Error)
+
+	// and
+	assert.Eventually(t, func() bool {
+		order, err := repository.FetchByOrderNumber(context.Background(), 1)
+		return err == nil && order.Status == Ready
+	}, 5*time.Second, 20*time.Millisecond)
+}
+
+func givenMemoryTopicConfigs(transport *event.MemoryTransport, topic string) *event.TopicConfigs {
+	configuration := event.TestTopicConfigs(topic)
+	configuration.Transport = transport
+	configuration.AwaitBetweenReadsTime = 10 * time.Millisecond
+	return configuration
+}
+
+func givenSubscribedReader(t *testing.T, configuration *event.TopicConfigs, bus event.EventBus) {
+	reader := event.NewTopicReader(configuration, bus)
+	reader.SubscribeToTopic(make(chan kafka.Message))
+	t.Cleanup(func() { _ = reader.Close() })
+}
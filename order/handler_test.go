@@ -22,6 +22,7 @@ var (
 	orderStatusKafkaConfig *event.TopicConfigs
 	stackTopic             = fmt.Sprintf("test-shelf-events-%d", rand.Intn(100))
 	orderStatusTopic       = fmt.Sprintf("test-order-status-events-%d", rand.Intn(100))
+	orderStreamTopic       = fmt.Sprintf("test-order-stream-%d", rand.Intn(100))
 )
 
 func TestOrdersHandler_Handle(t *testing.T) {
@@ -57,10 +58,8 @@ func shouldPackPreparedItemWhenEvenFromStackOccurred(t *testing.T) {
 	utils.DeleteMany(t, collectionDb, bson.D{})
 	utils.InsertMany(t, collectionDb, expectedOrders)
 
-	orderStream := NewStubService()
-
 	eventBus := event.NewInternalEventBus()
-	ordersHandler := NewHandler(database, kitchenRequestsKafkaConfig, orderStatusKafkaConfig, orderStream, kitchenStack)
+	ordersHandler := NewHandler(database, kitchenRequestsKafkaConfig, testOrderEvents(), kitchenStack)
 
 	eventBus.AddHandler(ordersHandler)
 	kitchenStack.Add("fries")
@@ -124,6 +123,10 @@ func sendItemAddedToStackMessages(t *testing.T, requestPayload []map[string]any)
 	}
 }
 
+func testOrderEvents() *OrderEvents {
+	return NewOrderEvents(event.TestTopicConfigs(orderStreamTopic), orderStatusKafkaConfig)
+}
+
 func fetchByOrderNumber(t *testing.T, orderNumber int64) *Order {
 	filter := bson.D{{Key: "orderNumber", Value: orderNumber}}
 	result := collectionDb.FindOne(context.Background(), filter)
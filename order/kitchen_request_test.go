@@ -55,7 +55,6 @@ func shouldSendNewMessageToTopic(t *testing.T) {
 	assert.Nil(t, err)
 
 	// and
-	expectedEventHeader := kafka.Header{Key: "event", Value: []byte("request-item")}
 	expectedMessage := make([]*dto.KitchenRequestMessage, 0)
 	expectedMessage = append(expectedMessage, dto.NewKitchenRequestMessage(itemName, quantity))
 	message, err := testReader.ReadMessage(context.Background())
@@ -66,8 +65,11 @@ func shouldSendNewMessageToTopic(t *testing.T) {
 
 	// and
 	assert.Equal(t, topic, message.Topic)
-	assert.Len(t, message.Headers, 1)
-	assert.Contains(t, message.Headers, expectedEventHeader)
+	envelope, err := event.ReadEnvelope(message)
+	assert.NoError(t, err)
+	assert.Equal(t, "request-item", envelope.Type)
+	assert.Equal(t, event.Source("order"), envelope.Source)
+	assert.NotEmpty(t, envelope.Id)
 
 	actualMessage := make([]*dto.KitchenRequestMessage, 0)
 	actualMessage = append(actualMessage, dto.NewKitchenRequestMessage(itemName, quantity))
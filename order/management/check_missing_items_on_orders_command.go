@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/log"
 	"mc-burger-orders/order"
 )
@@ -11,9 +12,16 @@ import (
 type CheckMissingItemsOnOrdersCommand struct {
 	queryService   OrderQueryService
 	kitchenService order.KitchenRequestService
+	flags          *featureflag.Flags
 }
 
 func (c *CheckMissingItemsOnOrdersCommand) Execute(ctx context.Context, message kafka.Message, result chan command.TypedResult) {
+	if !RerequestMissingItemsFlag.Get(c.flags) {
+		log.Info.Printf("Requesting missing items of orders again is turned off")
+		result <- command.NewSuccessfulResult("CheckMissingItemsOnOrdersCommand")
+		return
+	}
+
 	log.Info.Printf("Checking for orders that where requested but items are still missing...")
 
 	orders, err := c.queryService.FetchOrdersForPacking(ctx)
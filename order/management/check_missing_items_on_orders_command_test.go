@@ -5,6 +5,7 @@ import (
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	"mc-burger-orders/command"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/order"
 	"testing"
@@ -14,6 +15,7 @@ func TestCheckMissingItemsOnOrdersCommand_Execute(t *testing.T) {
 	t.Run("should request new item when order is not ready yet", shouldRequestNewItemWhenOrderIsNotReadyYet)
 	t.Run("should request multiple items when multiple orders arte not yet ready", shouldRequestMultipleItemsWhenMultipleOrdersArteNotYetReady)
 	t.Run("should not request any items when no orders are missing any items", shouldNotRequestAnyItemsWhenNoOrdersReturnedByQueryService)
+	t.Run("should not request any items when turned off by feature flag", shouldNotRequestAnyItemsWhenTurnedOffByFeatureFlag)
 }
 
 func shouldRequestNewItemWhenOrderIsNotReadyYet(t *testing.T) {
@@ -94,3 +96,30 @@ func shouldNotRequestAnyItemsWhenNoOrdersReturnedByQueryService(t *testing.T) {
 
 	assert.Equal(t, 0, kitchenStubService.CalledCnt())
 }
+
+func shouldNotRequestAnyItemsWhenTurnedOffByFeatureFlag(t *testing.T) {
+	// given
+	stubQueryService := NewStubQueryService()
+	kitchenStubService := NewKitchenStubService(nil)
+	defaults := map[string]string{"order-management-rerequest-missing-items": "false"}
+	cmd := CheckMissingItemsOnOrdersCommand{
+		queryService:   stubQueryService,
+		kitchenService: kitchenStubService,
+		flags:          featureflag.NewFlags(featureflag.NewInMemoryRepository(), defaults, RerequestMissingItemsFlag),
+	}
+
+	stubQueryService.ReturnOnFindPackingOrders([]order.Order{
+		{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 1}}},
+	})
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go cmd.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+
+	assert.True(t, result.Result)
+	assert.Equal(t, 0, stubQueryService.CalledCnt())
+	assert.Equal(t, 0, kitchenStubService.CalledCnt())
+}
@@ -0,0 +1,7 @@
+package management
+
+import "mc-burger-orders/featureflag"
+
+// RerequestMissingItemsFlag turns off requesting items again from the kitchen for orders still missing them.
+var RerequestMissingItemsFlag = featureflag.Bool("order-management-rerequest-missing-items", true,
+	"request items still missing on orders again from the kitchen")
@@ -1,41 +1,43 @@
 package management
 
 import (
+	"context"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"go.mongodb.org/mongo-driver/mongo"
 	"mc-burger-orders/command"
 	"mc-burger-orders/event"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/log"
 	"mc-burger-orders/order"
-	utils2 "mc-burger-orders/utils"
 )
 
 type OrderManagementHandler struct {
 	defaultHandler command.DefaultCommandHandler
 	queryService   OrderQueryService
 	kitchenService order.KitchenRequestService
+	flags          *featureflag.Flags
 }
 
-func NewHandler(database *mongo.Database, kitchenTopicConfigs *event.TopicConfigs) *OrderManagementHandler {
+func NewHandler(database *mongo.Database, kitchenTopicConfigs *event.TopicConfigs, flags *featureflag.Flags) *OrderManagementHandler {
 	queryService := NewOrderQueryService(NewOrderRepository(database))
 	kitchenService := order.NewKitchenServiceFrom(kitchenTopicConfigs)
 
 	return &OrderManagementHandler{
 		queryService:   queryService,
 		kitchenService: kitchenService,
+		flags:          flags,
 		defaultHandler: command.DefaultCommandHandler{},
 	}
 }
 
-func (o *OrderManagementHandler) Handle(message kafka.Message, commandResults chan command.TypedResult) {
-	commands, err := o.GetCommands(message)
+func (o *OrderManagementHandler) Handle(ctx context.Context, eventType string, message kafka.Message) command.Results {
+	commands, err := o.GetCommands(eventType, message)
 	if err != nil {
-		commandResults <- command.NewErrorResult("OrderManagementHandler", err)
-		return
+		return command.Results{command.NewErrorResult("OrderManagementHandler", err)}
 	}
 
-	o.defaultHandler.HandleCommands(message, commandResults, commands...)
+	return o.defaultHandler.HandleCommands(ctx, message, commands...)
 }
 
 func (o *OrderManagementHandler) GetHandledEvents() []string {
@@ -46,13 +48,7 @@ func (o *OrderManagementHandler) AddCommands(event string, commands ...command.C
 	o.defaultHandler.AddCommands(event, commands...)
 }
 
-func (o *OrderManagementHandler) GetCommands(message kafka.Message) ([]command.Command, error) {
-	eventType, err := utils2.GetEventType(message)
-	if err != nil {
-		log.Error.Println(err.Error())
-		return nil, err
-	}
-
+func (o *OrderManagementHandler) GetCommands(eventType string, message kafka.Message) ([]command.Command, error) {
 	commands := make([]command.Command, 0)
 	switch eventType {
 	case CheckMissingItemsOnOrdersEvent:
@@ -60,6 +56,7 @@ func (o *OrderManagementHandler) GetCommands(message kafka.Message) ([]command.C
 			commands = append(commands, &CheckMissingItemsOnOrdersCommand{
 				queryService:   o.queryService,
 				kitchenService: o.kitchenService,
+				flags:          o.flags,
 			})
 		}
 	default:
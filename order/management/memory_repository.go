@@ -0,0 +1,37 @@
+package management
+
+import (
+	"context"
+	"mc-burger-orders/order"
+	"time"
+)
+
+// InMemoryOrderRepository queries the orders of the order repository it is created with, the way
+// OrderRepositoryImpl queries the orders collection shared with the order package.
+type InMemoryOrderRepository struct {
+	orders order.FetchManyRepository
+}
+
+func NewInMemoryOrderRepository(orders order.FetchManyRepository) *InMemoryOrderRepository {
+	return &InMemoryOrderRepository{orders: orders}
+}
+
+func (r *InMemoryOrderRepository) FetchOrdersWithMissingPackedItems(ctx context.Context) ([]order.Order, error) {
+	modifiedTo := time.Now().Add(-missingItemsThreshold)
+	criteria := order.OrderCriteria{
+		Statuses:   []order.OrderStatus{order.Requested, order.InProgress},
+		ModifiedTo: &modifiedTo,
+		SortBy:     "orderNumber",
+	}
+
+	records, err := r.orders.FetchMany(ctx, criteria)
+	if err != nil {
+		return make([]order.Order, 0), err
+	}
+
+	orders := make([]order.Order, 0, len(records))
+	for _, record := range records {
+		orders = append(orders, *record)
+	}
+	return orders, nil
+}
@@ -10,6 +10,9 @@ import (
 	"time"
 )
 
+// missingItemsThreshold is how long orders wait for missing items before they are checked
+const missingItemsThreshold = 2 * time.Minute
+
 type OrderRepository interface {
 	PackingOrderItemsRepository
 }
@@ -41,7 +44,7 @@ func (s *OrderRepositoryImpl) FetchOrdersWithMissingPackedItems(ctx context.Cont
 			Key: "modifiedAt",
 			Value: bson.D{{
 				Key:   "$lte",
-				Value: now.Add(time.Minute * -2),
+				Value: now.Add(-missingItemsThreshold),
 			}},
 		},
 	}
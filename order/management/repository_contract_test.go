@@ -0,0 +1,90 @@
+package management
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/order"
+	"mc-burger-orders/testing/utils"
+	"testing"
+	"time"
+)
+
+func TestInMemoryOrderRepository_Contract(t *testing.T) {
+	runOrderRepositoryContract(t, func(t *testing.T, orders ...order.Order) OrderRepository {
+		return NewInMemoryOrderRepository(order.GivenInMemoryRepository(orders...))
+	})
+}
+
+func TestIntegrationOrderRepository_Contract(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+
+	mongoContainer, database = utils.TestWithMongo(t, ctx)
+	collectionDb = database.Collection("orders")
+
+	runOrderRepositoryContract(t, func(t *testing.T, orders ...order.Order) OrderRepository {
+		records := make([]interface{}, 0)
+		for _, record := range orders {
+			records = append(records, record)
+		}
+		utils.DeleteMany(t, collectionDb, bson.D{})
+		utils.InsertMany(t, collectionDb, records)
+		return NewOrderRepository(database)
+	})
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+// runOrderRepositoryContract runs the cases every OrderRepository has to pass, givenRepository returns a repository
+// holding the orders.
+func runOrderRepositoryContract(t *testing.T, givenRepository func(t *testing.T, orders ...order.Order) OrderRepository) {
+	t.Run("should return orders waiting longer than threshold ordered by number", func(t *testing.T) {
+		shouldReturnOrdersWaitingLongerThanThresholdOrderedByNumber(t, givenRepository)
+	})
+	t.Run("should skip orders which are not requested or in progress", func(t *testing.T) {
+		shouldSkipOrdersWhichAreNotRequestedOrInProgress(t, givenRepository)
+	})
+}
+
+func shouldReturnOrdersWaitingLongerThanThresholdOrderedByNumber(t *testing.T, givenRepository func(t *testing.T, orders ...order.Order) OrderRepository) {
+	// given
+	currentTime := time.Now()
+	sut := givenRepository(t,
+		order.Order{OrderNumber: 1002, CustomerId: 3, Items: []item.Item{{Name: "cheeseburger", Quantity: 2}}, Status: order.InProgress, ModifiedAt: currentTime.Add(time.Minute * -10)},
+		order.Order{OrderNumber: 1000, CustomerId: 1, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: order.Requested, ModifiedAt: currentTime.Add(time.Minute * -3)},
+		order.Order{OrderNumber: 1001, CustomerId: 2, Items: []item.Item{{Name: "fries", Quantity: 1}}, Status: order.Requested, ModifiedAt: currentTime.Add(time.Second * -115)},
+	)
+
+	// when
+	results, err := sut.FetchOrdersWithMissingPackedItems(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	orderNumbers := make([]int64, 0)
+	for _, result := range results {
+		orderNumbers = append(orderNumbers, result.OrderNumber)
+	}
+	assert.Equal(t, []int64{1000, 1002}, orderNumbers)
+}
+
+func shouldSkipOrdersWhichAreNotRequestedOrInProgress(t *testing.T, givenRepository func(t *testing.T, orders ...order.Order) OrderRepository) {
+	// given
+	waitingSince := time.Now().Add(time.Minute * -10)
+	sut := givenRepository(t,
+		order.Order{OrderNumber: 999, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: order.Ready, ModifiedAt: waitingSince},
+		order.Order{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: order.Collected, ModifiedAt: waitingSince},
+		order.Order{OrderNumber: 1001, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: order.Cancelled, ModifiedAt: waitingSince},
+	)
+
+	// when
+	results, err := sut.FetchOrdersWithMissingPackedItems(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
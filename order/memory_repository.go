@@ -0,0 +1,288 @@
+package order
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mc-burger-orders/event"
+	"mc-burger-orders/eventstore"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/log"
+	"mc-burger-orders/outbox"
+	"slices"
+	"sync"
+	"time"
+)
+
+// InMemoryRepository keeps orders in process and behaves like OrderRepositoryImpl: changes of orders identified by
+// id or by order number are recorded in their stream when their version is current, order numbers are unique among
+// active orders and the events of a stored order are added to the outbox together with it. Like Mongo, it stores
+// times in UTC with millisecond precision and returns copies of the stored orders.
+type InMemoryRepository struct {
+	mu     sync.RWMutex
+	orders []*Order
+	outbox outbox.AddRepository
+	events *OrderEvents
+	stream *OrderStream
+}
+
+func NewInMemoryRepository(events *OrderEvents, outbox outbox.AddRepository) *InMemoryRepository {
+	stream := NewOrderStream(eventstore.NewInMemoryRepository())
+	return &InMemoryRepository{orders: make([]*Order, 0), outbox: outbox, events: events, stream: stream}
+}
+
+// GivenInMemoryRepository stores the orders as they are, the way they are inserted into the orders collection in tests.
+func GivenInMemoryRepository(orders ...Order) *InMemoryRepository {
+	events := NewOrderEvents(event.TestTopicConfigs("order-stream"), event.TestTopicConfigs("order-status"))
+	repository := NewInMemoryRepository(events, outbox.GivenRepository())
+	for _, order := range orders {
+		stored := storedCopy(&order)
+		if stored.Id == nil {
+			id := primitive.NewObjectID()
+			stored.Id = &id
+		}
+		repository.orders = append(repository.orders, stored)
+	}
+	return repository
+}
+
+func (r *InMemoryRepository) InsertOrUpdate(ctx context.Context, order *Order) (*Order, error) {
+	order.ModifiedAt = time.Now()
+	log.Info.Printf("Updating existing Order Number: %v", order.OrderNumber)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	index := r.indexOf(func(stored *Order) bool {
+		return isSameOrder(stored, order) && (order.Id != nil || isActive(stored.Status))
+	})
+	current := cloneOrder(order)
+	if index >= 0 {
+		current.Id = r.orders[index].Id
+	} else if current.Id == nil {
+		id := primitive.NewObjectID()
+		current.Id = &id
+	}
+	if r.isDuplicate(current, index) {
+		err := fmt.Errorf("order number %d is used by another active order", order.OrderNumber)
+		log.Error.Println("Error when updating order in db", err)
+		return nil, err
+	}
+
+	previous, err := r.previousState(ctx, *current.Id, index)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := r.stream.Record(ctx, previous, *current)
+	if err != nil {
+		if isVersionConflict(err) {
+			log.Warning.Printf("Order %d was modified concurrently, version %d is outdated", order.OrderNumber, order.Version)
+		}
+		return nil, err
+	}
+
+	// like the transaction of OrderRepositoryImpl, the order is stored only once its events are in the outbox
+	var previousStatus OrderStatus
+	if previous != nil {
+		previousStatus = previous.Status
+	}
+	messages, err := r.events.Messages(ctx, previousStatus, *cloneOrder(stored))
+	if err != nil {
+		return nil, err
+	}
+	if err = r.outbox.Add(ctx, messages...); err != nil {
+		return nil, err
+	}
+
+	if index >= 0 {
+		r.orders[index] = stored
+	} else {
+		r.orders = append(r.orders, stored)
+	}
+	order.Version = stored.Version
+	return cloneOrder(stored), nil
+}
+
+// previousState folds the stream of the order, orders stored before their changes were recorded start their stream
+// from their projection like in OrderRepositoryImpl.
+func (r *InMemoryRepository) previousState(ctx context.Context, id primitive.ObjectID, index int) (*Order, error) {
+	previous, err := r.stream.Load(ctx, id)
+	if err != nil || previous != nil || index < 0 || r.orders[index].Version == 0 {
+		return previous, err
+	}
+	projected := cloneOrder(r.orders[index])
+	return projected, r.stream.Adopt(ctx, *projected)
+}
+
+func (r *InMemoryRepository) FetchById(_ context.Context, id interface{}) (*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	objectId, ok := id.(primitive.ObjectID)
+	if !ok {
+		if pointer, isPointer := id.(*primitive.ObjectID); isPointer && pointer != nil {
+			objectId, ok = *pointer, true
+		}
+	}
+	index := r.indexOf(func(stored *Order) bool { return ok && *stored.Id == objectId })
+	if index < 0 {
+		log.Error.Println("Error when fetching order by id", id, mongo.ErrNoDocuments)
+		return nil, mongo.ErrNoDocuments
+	}
+	return cloneOrder(r.orders[index]), nil
+}
+
+// FetchByOrderNumber returns the latest created order with the number, numbers of collected or cancelled orders
+// may have been reused.
+func (r *InMemoryRepository) FetchByOrderNumber(_ context.Context, orderNumber int64) (*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var latest *Order
+	for _, stored := range r.orders {
+		if stored.OrderNumber == orderNumber && (latest == nil || stored.CreatedAt.After(latest.CreatedAt)) {
+			latest = stored
+		}
+	}
+	if latest == nil {
+		log.Error.Println("Error when fetching order by orderNumber", orderNumber, mongo.ErrNoDocuments)
+		return nil, mongo.ErrNoDocuments
+	}
+	return cloneOrder(latest), nil
+}
+
+func (r *InMemoryRepository) FetchMany(_ context.Context, criteria OrderCriteria) ([]*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return fetchMany(r.orders, criteria, nil)
+}
+
+// fetchMany queries the orders the way OrderRepositoryImpl queries the orders collection.
+// fetchMany pages after the given cursor order, without it the order of criteria.After is looked up among the stored ones.
+func fetchMany(stored []*Order, criteria OrderCriteria, cursorOrder *Order) ([]*Order, error) {
+	sortBy := criteria.SortBy
+	if len(sortBy) == 0 {
+		sortBy = "orderNumber"
+	}
+	direction := 1
+	if criteria.Descending {
+		direction = -1
+	}
+	compareOrders := func(a *Order, b *Order) int {
+		if byField := compareField(sortBy, a, b); byField != 0 {
+			return direction * byField
+		}
+		return direction * bytes.Compare(a.Id[:], b.Id[:])
+	}
+
+	// keyset pagination like OrderRepositoryImpl, orders sharing the sort value of the cursor are ordered by id
+	if criteria.After != nil && cursorOrder == nil {
+		index := slices.IndexFunc(stored, func(order *Order) bool { return *order.Id == *criteria.After })
+		if index < 0 {
+			return nil, ErrUnknownCursor
+		}
+		cursorOrder = stored[index]
+	}
+
+	orders := make([]*Order, 0)
+	for _, order := range stored {
+		if matchesCriteria(order, criteria) && (cursorOrder == nil || compareOrders(order, cursorOrder) > 0) {
+			orders = append(orders, order)
+		}
+	}
+	slices.SortStableFunc(orders, compareOrders)
+	if criteria.Limit > 0 && int64(len(orders)) > criteria.Limit {
+		orders = orders[:criteria.Limit]
+	}
+	return copyOrders(orders), nil
+}
+
+func (r *InMemoryRepository) FetchByMissingItem(_ context.Context, itemName string) ([]*Order, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	orders := make([]*Order, 0)
+	for _, stored := range r.orders {
+		if !isNotInRequiredStatus(stored.Status) || !slices.ContainsFunc(stored.Items, func(i item.Item) bool { return i.Name == itemName }) {
+			continue
+		}
+		if count, err := stored.GetMissingItemsCount(itemName); err == nil && count > 0 {
+			orders = append(orders, stored)
+		}
+	}
+	slices.SortStableFunc(orders, func(a *Order, b *Order) int { return cmp.Compare(a.OrderNumber, b.OrderNumber) })
+	return copyOrders(orders), nil
+}
+
+func (r *InMemoryRepository) indexOf(matches func(stored *Order) bool) int {
+	return slices.IndexFunc(r.orders, matches)
+}
+
+// isDuplicate tells if storing the order would break the unique number of active orders.
+func (r *InMemoryRepository) isDuplicate(order *Order, replacedIndex int) bool {
+	for index, stored := range r.orders {
+		if index == replacedIndex {
+			continue
+		}
+		if isActive(stored.Status) && isActive(order.Status) && stored.OrderNumber == order.OrderNumber {
+			return true
+		}
+	}
+	return false
+}
+
+func isSameOrder(stored *Order, order *Order) bool {
+	if order.Id != nil {
+		return *stored.Id == *order.Id
+	}
+	return stored.OrderNumber == order.OrderNumber
+}
+
+func isActive(status OrderStatus) bool {
+	return status == Requested || status == InProgress || status == Ready
+}
+
+func matchesCriteria(order *Order, criteria OrderCriteria) bool {
+	if len(criteria.Statuses) > 0 && !slices.Contains(criteria.Statuses, order.Status) {
+		return false
+	}
+	if criteria.CustomerId != nil && order.CustomerId != *criteria.CustomerId {
+		return false
+	}
+	return isInRange(order.CreatedAt, criteria.CreatedFrom, criteria.CreatedTo) &&
+		isInRange(order.ModifiedAt, criteria.ModifiedFrom, criteria.ModifiedTo)
+}
+
+func isInRange(value time.Time, from *time.Time, to *time.Time) bool {
+	return (from == nil || !value.Before(*from)) && (to == nil || !value.After(*to))
+}
+
+func compareField(field string, a *Order, b *Order) int {
+	switch field {
+	case "createdAt":
+		return a.CreatedAt.Compare(b.CreatedAt)
+	case "modifiedAt":
+		return a.ModifiedAt.Compare(b.ModifiedAt)
+	default:
+		return cmp.Compare(a.OrderNumber, b.OrderNumber)
+	}
+}
+
+// storedCopy copies the order the way Mongo stores it, with times in UTC truncated to milliseconds.
+func storedCopy(order *Order) *Order {
+	stored := cloneOrder(order)
+	stored.CreatedAt = stored.CreatedAt.Truncate(time.Millisecond).UTC()
+	stored.ModifiedAt = stored.ModifiedAt.Truncate(time.Millisecond).UTC()
+	return stored
+}
+
+func copyOrders(orders []*Order) []*Order {
+	copies := make([]*Order, 0, len(orders))
+	for _, order := range orders {
+		copies = append(copies, cloneOrder(order))
+	}
+	return copies
+}
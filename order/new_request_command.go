@@ -14,7 +14,6 @@ type NewRequestCommand struct {
 	Repository     OrderRepository
 	Shelf          *shelf.Shelf
 	KitchenService KitchenRequestService
-	StatusEmitter  StatusEmitter
 	OrderNumber    int64
 	NewOrder       NewOrder
 }
@@ -26,58 +25,58 @@ func (c *NewRequestCommand) Execute(ctx context.Context, _ kafka.Message, comman
 		return
 	}
 
-	log.Warning.Println("Emitting Status Update for", c.OrderNumber, "status =>", orderRecord.Status)
-	go c.StatusEmitter.EmitStatusUpdatedEvent(*orderRecord)
 	log.Info.Printf("New Order with number %v created %+v\n", c.OrderNumber, c.NewOrder)
-	statusUpdated := false
+	reservations := make([]*shelf.Reservation, 0)
 	for _, item := range c.NewOrder.Items {
 		isReady, err := item2.IsItemReady(item.Name)
 		if err != nil {
+			releaseReservations(c.Shelf, reservations...)
 			commandResults <- command.NewErrorResult("NewRequestCommand", err)
 			return
 		}
 
 		if isReady {
 			log.Info.Printf("Item %v is of type automatically ready. No need to check shelf if one in available. Packing automatically.", item.Name)
-			if sUpdated := orderRecord.PackItem(item.Name, item.Quantity); sUpdated {
-				statusUpdated = sUpdated
-			}
+			orderRecord.PackItem(item.Name, item.Quantity)
 		} else {
-			sUpdated, err := c.handlePreparationItems(ctx, item, orderRecord)
+			reservation, err := handlePreparationItems(ctx, c.Shelf, c.KitchenService, item, orderRecord)
 			if err != nil {
+				releaseReservations(c.Shelf, reservations...)
 				commandResults <- command.NewErrorResult("NewRequestCommand", err)
 				return
 			}
-			if sUpdated {
-				statusUpdated = sUpdated
+			if reservation != nil {
+				reservations = append(reservations, reservation)
 			}
 		}
 	}
 
-	result, err := c.Repository.InsertOrUpdate(ctx, orderRecord)
+	result, err := storePackedItems(ctx, c.Repository, c.Shelf, orderRecord, orderRecord.PackedItems)
 	if err != nil {
+		releaseReservations(c.Shelf, reservations...)
 		commandResults <- command.NewErrorResult("NewRequestCommand", err)
 		return
 	}
 	if result == nil {
+		releaseReservations(c.Shelf, reservations...)
 		commandResults <- command.NewErrorResult("NewRequestCommand", fmt.Errorf("failed to store Order in DB, despite MongoDB Driver returning success"))
 		return
 	}
-	if statusUpdated {
-		log.Warning.Println("Emitting Status Update for", c.OrderNumber, "status =>", result.Status)
-		go c.StatusEmitter.EmitStatusUpdatedEvent(*result)
+	if err = commitReservations(ctx, c.Repository, c.KitchenService, c.Shelf, result, reservations...); err != nil {
+		commandResults <- command.NewErrorResult("NewRequestCommand", err)
+		return
 	}
 	commandResults <- command.NewSuccessfulResult("NewRequestCommand")
 }
 
-func (c *NewRequestCommand) handlePreparationItems(ctx context.Context, item item2.Item, orderRecord *Order) (statusUpdated bool, err error) {
+func handlePreparationItems(ctx context.Context, s *shelf.Shelf, kitchenService KitchenRequestService, item item2.Item, orderRecord *Order) (reservation *shelf.Reservation, err error) {
 	log.Info.Println("Item", item, "needs to be prepared first. Checking shelf if one in available.")
-	amountInStock := c.Shelf.GetCurrent(item.Name)
+	amountInStock := s.GetCurrent(item.Name)
 	if amountInStock == 0 {
 		log.Info.Printf("Sending Request to kitchen for %d new %v", item.Quantity, item.Name)
-		err = c.KitchenService.RequestNew(ctx, item.Name, item.Quantity)
+		err = kitchenService.RequestNew(ctx, item.Name, item.Quantity)
 		if err != nil {
-			return statusUpdated, err
+			return nil, err
 		}
 	} else {
 		var itemTaken int
@@ -90,27 +89,28 @@ func (c *NewRequestCommand) handlePreparationItems(ctx context.Context, item ite
 			remaining := item.Quantity - itemTaken
 
 			log.Info.Printf("Sending Request to kitchen for %d new %v", remaining, item.Name)
-			err = c.KitchenService.RequestNew(ctx, item.Name, remaining)
+			err = kitchenService.RequestNew(ctx, item.Name, remaining)
 			if err != nil {
-				return statusUpdated, err
+				return nil, err
 			}
 		}
 
-		succeeded, taken, err := c.Shelf.Take(item.Name, itemTaken)
-		if !succeeded {
-			remaining := itemTaken - taken
+		reservation, err = s.Reserve(item.Name, itemTaken, orderRecord.OrderNumber, shelfReservationTtl)
+		if err == nil && reservation.Quantity < itemTaken {
+			remaining := itemTaken - reservation.Quantity
 
 			log.Info.Printf("Sending Request to kitchen for %d new %v", remaining, item.Name)
-			err = c.KitchenService.RequestNew(ctx, item.Name, remaining)
+			err = kitchenService.RequestNew(ctx, item.Name, remaining)
 		}
 
 		if err != nil {
+			releaseReservations(s, reservation)
 			err = fmt.Errorf("error when collecting '%d' item(s) '%s' from shelf. Reason: %v", item.Quantity, item.Name, err)
-			return statusUpdated, err
+			return nil, err
 		}
 
-		log.Info.Printf("Packing %d of %v into order %d", itemTaken, item.Name, orderRecord.OrderNumber)
-		statusUpdated = orderRecord.PackItem(item.Name, itemTaken)
+		log.Info.Printf("Packing %d of %v into order %d", reservation.Quantity, item.Name, orderRecord.OrderNumber)
+		orderRecord.PackItem(item.Name, reservation.Quantity)
 	}
-	return statusUpdated, err
+	return reservation, err
 }
@@ -45,16 +45,10 @@ func Test_CreateNewOrder(t *testing.T) {
 	stubRepository := GivenRepository()
 	stubKitchenService := NewStubService()
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(2)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	command := &NewRequestCommand{
 		Repository:     stubRepository,
 		Shelf:          s,
 		KitchenService: stubKitchenService,
-		StatusEmitter:  stubStatusEmitter,
 		OrderNumber:    expectedOrderNumber,
 		NewOrder:       newOrder,
 	}
@@ -88,12 +82,9 @@ func Test_CreateNewOrder(t *testing.T) {
 	assert.Empty(t, stubKitchenService.CalledCnt())
 
 	// and
-	statusUpdateWg.Wait()
 
-	t.Log("StatusUpdatedEvent Args:", stubStatusEmitter.MethodCalled)
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 2)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Requested)))
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Ready)))
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Requested)
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Ready)
 	close(commandResults)
 }
 
@@ -133,11 +124,6 @@ func Test_CreateNewOrderAndPackOnlyTheseItemsThatAreAvailable(t *testing.T) {
 	stubKitchenService := NewStubService()
 	stubKitchenService.WithWaitGroup(kitchenWg)
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(2)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	expectedOrder := &Order{
 		OrderNumber: expectedOrderNumber,
 		CustomerId:  10,
@@ -152,7 +138,6 @@ func Test_CreateNewOrderAndPackOnlyTheseItemsThatAreAvailable(t *testing.T) {
 		Repository:     stubRepository,
 		Shelf:          s,
 		KitchenService: stubKitchenService,
-		StatusEmitter:  stubStatusEmitter,
 		OrderNumber:    expectedOrderNumber,
 		NewOrder:       newOrder,
 	}
@@ -189,12 +174,9 @@ func Test_CreateNewOrderAndPackOnlyTheseItemsThatAreAvailable(t *testing.T) {
 	assert.True(t, stubKitchenService.HaveBeenCalledWith(RequestMatchingFnc("hamburger", 1)))
 
 	// and
-	statusUpdateWg.Wait()
-	t.Log("StatusUpdatedEvent Args:", stubStatusEmitter.MethodCalled)
 
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 2)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Requested)))
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(InProgress)))
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Requested)
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), InProgress)
 	close(commandResults)
 }
 
@@ -222,16 +204,10 @@ func Test_DontPackItemsWhenNonIsInStack(t *testing.T) {
 	stubKitchenService := NewStubService()
 	stubKitchenService.WithWaitGroup(kitchenWg)
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(1)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	command := &NewRequestCommand{
 		Repository:     stubRepository,
 		Shelf:          s,
 		KitchenService: stubKitchenService,
-		StatusEmitter:  stubStatusEmitter,
 		OrderNumber:    expectedOrderNumber,
 		NewOrder:       newOrder,
 	}
@@ -267,8 +243,6 @@ func Test_DontPackItemsWhenNonIsInStack(t *testing.T) {
 	assert.True(t, stubKitchenService.HaveBeenCalledWith(RequestMatchingFnc("fries", 1)))
 
 	// and
-	statusUpdateWg.Wait()
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 1)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Requested)))
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Requested)
 	close(commandResults)
 }
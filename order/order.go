@@ -5,6 +5,7 @@ import (
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/log"
+	"slices"
 	"time"
 )
 
@@ -17,6 +18,7 @@ type Order struct {
 	Status      OrderStatus         `json:"status" bson:"status"`
 	CreatedAt   time.Time           `json:"createdAt" bson:"createdAt"`
 	ModifiedAt  time.Time           `json:"modifiedAt" bson:"modifiedAt"`
+	Version     int64               `json:"version" bson:"version"`
 }
 
 func CreateNewOrder(number int64, order NewOrder) *Order {
@@ -24,6 +26,19 @@ func CreateNewOrder(number int64, order NewOrder) *Order {
 	return &Order{Id: &objectID, OrderNumber: number, CustomerId: order.CustomerId, Items: order.Items, Status: Requested, CreatedAt: time.Now(), ModifiedAt: time.Now()}
 }
 
+// cloneOrder copies the order so changes of the copy do not reach the original, missing items are kept nil, as they
+// are when read from Mongo.
+func cloneOrder(order *Order) *Order {
+	clone := *order
+	if order.Id != nil {
+		id := *order.Id
+		clone.Id = &id
+	}
+	clone.Items = slices.Clone(order.Items)
+	clone.PackedItems = slices.Clone(order.PackedItems)
+	return &clone
+}
+
 func (o *Order) PackItem(name string, quantity int) bool {
 	if quantity > 0 {
 		if o.PackedItems == nil {
@@ -32,13 +47,20 @@ func (o *Order) PackItem(name string, quantity int) bool {
 		o.PackedItems = append(o.PackedItems, item.Item{Name: name, Quantity: quantity})
 	}
 
+	return o.UpdateStatus()
+}
+
+func (o *Order) UpdateStatus() bool {
 	packedItemsCount := o.GetItemsCount(o.PackedItems)
-	if packedItemsCount == 0 {
+	if packedItemsCount == 0 && o.Status != InProgress {
 		return false
 	}
 
 	var newStatus OrderStatus
 	switch {
+	case packedItemsCount == 0:
+		// every packed item was removed from the order, so nothing of it is in progress anymore
+		newStatus = Requested
 	case packedItemsCount < o.GetItemsCount(o.Items):
 		newStatus = InProgress
 	case packedItemsCount == o.GetItemsCount(o.Items):
@@ -55,6 +77,74 @@ func (o *Order) PackItem(name string, quantity int) bool {
 	return false
 }
 
+func (o *Order) AddItem(name string, quantity int) {
+	for idx, i := range o.Items {
+		if i.Name == name {
+			o.Items[idx].Quantity += quantity
+			return
+		}
+	}
+	o.Items = append(o.Items, item.Item{Name: name, Quantity: quantity})
+}
+
+func (o *Order) RemoveItem(name string, quantity int) (int, error) {
+	idx := -1
+	for i, ii := range o.Items {
+		if ii.Name == name {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		err := fmt.Errorf("could not find item `%v` on the order list", name)
+		return 0, err
+	}
+	if o.Items[idx].Quantity < quantity {
+		err := fmt.Errorf("cannot remove %d of item `%v`, order contains only %d", quantity, name, o.Items[idx].Quantity)
+		return 0, err
+	}
+
+	o.Items[idx].Quantity -= quantity
+	remaining := o.Items[idx].Quantity
+	if remaining == 0 {
+		o.Items = append(o.Items[:idx], o.Items[idx+1:]...)
+	}
+
+	return o.unpackItem(name, remaining), nil
+}
+
+func (o *Order) unpackItem(name string, maxPacked int) int {
+	excess := o.getPackedItemsCount(name) - maxPacked
+	if excess <= 0 {
+		return 0
+	}
+
+	toUnpack := excess
+	for idx := len(o.PackedItems) - 1; idx >= 0 && toUnpack > 0; idx-- {
+		if o.PackedItems[idx].Name != name {
+			continue
+		}
+		if o.PackedItems[idx].Quantity > toUnpack {
+			o.PackedItems[idx].Quantity -= toUnpack
+			toUnpack = 0
+			continue
+		}
+		toUnpack -= o.PackedItems[idx].Quantity
+		o.PackedItems = append(o.PackedItems[:idx], o.PackedItems[idx+1:]...)
+	}
+	return excess
+}
+
+func (o *Order) getPackedItemsCount(name string) int {
+	packed := 0
+	for _, i := range o.PackedItems {
+		if i.Name == name {
+			packed += i.Quantity
+		}
+	}
+	return packed
+}
+
 func (o *Order) GetItemsCount(items []item.Item) int {
 	var count = 0
 
@@ -67,6 +157,9 @@ func (o *Order) GetItemsCount(items []item.Item) int {
 
 func (o *Order) GetMissingItems() []item.Item {
 	i := make([]item.Item, 0)
+	if o.Status == Cancelled {
+		return i
+	}
 
 	for _, ii := range o.Items {
 		missingItemsCount, err := o.GetMissingItemsCount(ii.Name)
@@ -107,6 +200,7 @@ const (
 	InProgress = OrderStatus("IN_PROGRESS")
 	Ready      = OrderStatus("READY")
 	Collected  = OrderStatus("COLLECTED")
+	Cancelled  = OrderStatus("CANCELLED")
 )
 
 type OrderStatus string
@@ -7,7 +7,6 @@ import (
 	"github.com/stretchr/testify/assert"
 	"mc-burger-orders/command"
 	"net/http"
-	"sync"
 	"testing"
 )
 
@@ -16,20 +15,17 @@ func TestOrderCollectedCommand_Execute(t *testing.T) {
 	t.Run("should not emit status update when order is not yet ready", shouldNotEmitStatusUpdateWhenOrderIsNotYetReady)
 	t.Run("should not emit status update when order by number does not exists", shouldNotEmitStatusUpdateWhenOrderByNumberDoesNotExists)
 	t.Run("should not emit status update when order was already collected", shouldNotEmitStatusUpdateWhenOrderWasAlreadyCollected)
+	t.Run("should collect order again when it was modified concurrently", shouldCollectOrderAgainWhenItWasModifiedConcurrently)
+	t.Run("should fail when order keeps being modified concurrently", shouldFailWhenOrderKeepsBeingModifiedConcurrently)
 }
 
 func shouldEmitStatusUpdateWhenClientCollectsReadyOrder(t *testing.T) {
 	// given
 	stubRepository := GivenRepository()
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(1)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Ready})
 
-	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, StatusEmitter: stubStatusEmitter}
+	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository}
 	commandResults := make(chan command.TypedResult)
 
 	// when
@@ -44,18 +40,16 @@ func shouldEmitStatusUpdateWhenClientCollectsReadyOrder(t *testing.T) {
 	assert.Equal(t, Collected, upsertArgs[0].Status)
 
 	// and
-	statusUpdateWg.Wait()
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Collected)))
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Collected)
 }
 
 func shouldNotEmitStatusUpdateWhenOrderIsNotYetReady(t *testing.T) {
 	// given
 	stubRepository := GivenRepository()
-	stubStatusEmitter := NewStubService()
 
 	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: InProgress})
 
-	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, StatusEmitter: stubStatusEmitter}
+	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository}
 	commandResults := make(chan command.TypedResult)
 
 	// when
@@ -68,17 +62,16 @@ func shouldNotEmitStatusUpdateWhenOrderIsNotYetReady(t *testing.T) {
 	assert.Equal(t, http.StatusPreconditionRequired, result.Error.HttpResponse)
 
 	assert.Empty(t, stubRepository.GetUpsertArgs())
-	assert.Empty(t, stubStatusEmitter.GetStatusUpdatedEventArgs())
+	assert.Empty(t, stubRepository.GetUpsertArgs())
 }
 
 func shouldNotEmitStatusUpdateWhenOrderByNumberDoesNotExists(t *testing.T) {
 	// given
 	stubRepository := GivenRepository()
-	stubStatusEmitter := NewStubService()
 
 	stubRepository.ReturnError(fmt.Errorf("error fetching order"))
 
-	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, StatusEmitter: stubStatusEmitter}
+	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository}
 	commandResults := make(chan command.TypedResult)
 
 	// when
@@ -91,17 +84,16 @@ func shouldNotEmitStatusUpdateWhenOrderByNumberDoesNotExists(t *testing.T) {
 	assert.Equal(t, http.StatusNotFound, result.Error.HttpResponse)
 
 	assert.Empty(t, stubRepository.GetUpsertArgs())
-	assert.Empty(t, stubStatusEmitter.GetStatusUpdatedEventArgs())
+	assert.Empty(t, stubRepository.GetUpsertArgs())
 }
 
 func shouldNotEmitStatusUpdateWhenOrderWasAlreadyCollected(t *testing.T) {
 	// given
 	stubRepository := GivenRepository()
-	stubStatusEmitter := NewStubService()
 
 	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Collected})
 
-	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository, StatusEmitter: stubStatusEmitter}
+	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository}
 	commandResults := make(chan command.TypedResult)
 
 	// when
@@ -114,5 +106,51 @@ func shouldNotEmitStatusUpdateWhenOrderWasAlreadyCollected(t *testing.T) {
 	assert.Equal(t, http.StatusPreconditionFailed, result.Error.HttpResponse)
 
 	assert.Empty(t, stubRepository.GetUpsertArgs())
-	assert.Empty(t, stubStatusEmitter.GetStatusUpdatedEventArgs())
+	assert.Empty(t, stubRepository.GetUpsertArgs())
+}
+
+func shouldCollectOrderAgainWhenItWasModifiedConcurrently(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+	stubRepository.ReturnVersionConflicts(1)
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Ready, Version: 3})
+
+	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.True(t, result.Result)
+
+	upsertArgs := stubRepository.GetUpsertArgs()
+	assert.Len(t, upsertArgs, 2)
+	assert.Equal(t, Collected, upsertArgs[1].Status)
+
+	// and
+	assert.Contains(t, stubRepository.GetUpsertStatuses(), Collected)
+}
+
+func shouldFailWhenOrderKeepsBeingModifiedConcurrently(t *testing.T) {
+	// given
+	stubRepository := GivenRepository()
+	stubRepository.ReturnVersionConflicts(maxUpdateAttempts)
+
+	stubRepository.ReturnFetchByOrderNumber(&Order{OrderNumber: expectedOrderNumber, Status: Ready, Version: 3})
+
+	sut := &OrderCollectedCommand{OrderNumber: expectedOrderNumber, Repository: stubRepository}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	result := <-commandResults
+	assert.False(t, result.Result)
+	assert.Equal(t, http.StatusInternalServerError, result.Error.HttpResponse)
+
+	assert.Len(t, stubRepository.GetUpsertArgs(), maxUpdateAttempts)
 }
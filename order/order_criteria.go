@@ -0,0 +1,134 @@
+package order
+
+import (
+	"errors"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageLimit = int64(50)
+	maxPageLimit     = int64(500)
+)
+
+var ErrUnknownCursor = errors.New("unknown pagination cursor")
+
+var sortableFields = map[string]struct{}{
+	"orderNumber": {},
+	"createdAt":   {},
+	"modifiedAt":  {},
+}
+
+type OrderCriteria struct {
+	Statuses     []OrderStatus
+	CustomerId   *int
+	CreatedFrom  *time.Time
+	CreatedTo    *time.Time
+	ModifiedFrom *time.Time
+	ModifiedTo   *time.Time
+	SortBy       string
+	Descending   bool
+	After        *primitive.ObjectID
+	Limit        int64
+}
+
+func DefaultOrderCriteria() OrderCriteria {
+	return OrderCriteria{
+		Statuses: []OrderStatus{Requested, InProgress, Ready},
+		SortBy:   "orderNumber",
+		Limit:    defaultPageLimit,
+	}
+}
+
+// ParseOrderCriteria reads the GET /order query parameters. The `after` cursor is the id of the last order of the
+// previous page, as order numbers are reused, `sort` takes a field name optionally prefixed with `-` for descending order.
+func ParseOrderCriteria(query url.Values) (OrderCriteria, error) {
+	criteria := DefaultOrderCriteria()
+	var errs []error
+
+	if statuses := query["status"]; len(statuses) > 0 {
+		criteria.Statuses = make([]OrderStatus, 0)
+		for _, value := range statuses {
+			for _, status := range strings.Split(value, ",") {
+				orderStatus, err := parseOrderStatus(status)
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				criteria.Statuses = append(criteria.Statuses, orderStatus)
+			}
+		}
+	}
+
+	if value := query.Get("customerId"); len(value) > 0 {
+		customerId, err := strconv.Atoi(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid customerId %q", value))
+		} else {
+			criteria.CustomerId = &customerId
+		}
+	}
+
+	criteria.CreatedFrom = parseTimeParam(query, "createdFrom", &errs)
+	criteria.CreatedTo = parseTimeParam(query, "createdTo", &errs)
+	criteria.ModifiedFrom = parseTimeParam(query, "modifiedFrom", &errs)
+	criteria.ModifiedTo = parseTimeParam(query, "modifiedTo", &errs)
+
+	if value := query.Get("sort"); len(value) > 0 {
+		field := strings.TrimPrefix(value, "-")
+		if _, ok := sortableFields[field]; !ok {
+			errs = append(errs, fmt.Errorf("cannot sort orders by %q", field))
+		} else {
+			criteria.SortBy = field
+			criteria.Descending = strings.HasPrefix(value, "-")
+		}
+	}
+
+	if value := query.Get("after"); len(value) > 0 {
+		after, err := primitive.ObjectIDFromHex(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid cursor %q", value))
+		} else {
+			criteria.After = &after
+		}
+	}
+
+	if value := query.Get("limit"); len(value) > 0 {
+		limit, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || limit <= 0 || limit > maxPageLimit {
+			errs = append(errs, fmt.Errorf("limit needs to be a number between 1 and %d", maxPageLimit))
+		} else {
+			criteria.Limit = limit
+		}
+	}
+
+	return criteria, errors.Join(errs...)
+}
+
+func parseOrderStatus(value string) (OrderStatus, error) {
+	status := OrderStatus(strings.ToUpper(strings.TrimSpace(value)))
+	switch status {
+	case Requested, InProgress, Ready, Collected, Cancelled:
+		return status, nil
+	default:
+		return "", fmt.Errorf("unknown order status %q", value)
+	}
+}
+
+func parseTimeParam(query url.Values, name string, errs *[]error) *time.Time {
+	value := query.Get(name)
+	if len(value) == 0 {
+		return nil
+	}
+
+	parsed, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		*errs = append(*errs, fmt.Errorf("invalid %v %q, expected RFC3339 format", name, value))
+		return nil
+	}
+	return &parsed
+}
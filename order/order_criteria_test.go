@@ -0,0 +1,50 @@
+package order
+
+import (
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestParseOrderCriteria_DefaultsWhenNoParametersGiven(t *testing.T) {
+	// when
+	criteria, err := ParseOrderCriteria(url.Values{})
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, DefaultOrderCriteria(), criteria)
+}
+
+func TestParseOrderCriteria_ReadsAllParameters(t *testing.T) {
+	// given
+	query, _ := url.ParseQuery("status=collected,READY&customerId=10&createdFrom=2023-11-01T10:00:00Z&modifiedTo=2023-11-01T12:00:00Z&sort=-createdAt&after=6560a1f0c3f1f2a4b5c6d7e8&limit=20")
+
+	// when
+	criteria, err := ParseOrderCriteria(query)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, []OrderStatus{Collected, Ready}, criteria.Statuses)
+	assert.Equal(t, 10, *criteria.CustomerId)
+	assert.Equal(t, time.Date(2023, 11, 1, 10, 0, 0, 0, time.UTC), *criteria.CreatedFrom)
+	assert.Nil(t, criteria.CreatedTo)
+	assert.Nil(t, criteria.ModifiedFrom)
+	assert.Equal(t, time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC), *criteria.ModifiedTo)
+	assert.Equal(t, "createdAt", criteria.SortBy)
+	assert.True(t, criteria.Descending)
+	assert.Equal(t, "6560a1f0c3f1f2a4b5c6d7e8", criteria.After.Hex())
+	assert.Equal(t, int64(20), criteria.Limit)
+}
+
+func TestParseOrderCriteria_Error_When_ParametersAreInvalid(t *testing.T) {
+	// given
+	query, _ := url.ParseQuery("status=EATEN&sort=customerId&limit=1000")
+
+	// when
+	_, err := ParseOrderCriteria(query)
+
+	// then
+	assert.NotNil(t, err)
+	assert.Equal(t, "unknown order status \"EATEN\"\ncannot sort orders by \"customerId\"\nlimit needs to be a number between 1 and 500", err.Error())
+}
@@ -0,0 +1,39 @@
+package order
+
+import (
+	"mc-burger-orders/kitchen/item"
+)
+
+type OrderDetails struct {
+	Order
+	Lines []OrderLine `json:"lines"`
+}
+
+type OrderLine struct {
+	Name         string `json:"name"`
+	Ordered      int    `json:"ordered"`
+	Packed       int    `json:"packed"`
+	Missing      int    `json:"missing"`
+	InstantReady bool   `json:"instantReady"`
+}
+
+func NewOrderDetails(o *Order) OrderDetails {
+	lines := make([]OrderLine, 0)
+	for _, i := range o.Items {
+		missing, err := o.GetMissingItemsCount(i.Name)
+		if err != nil {
+			missing = 0
+		}
+		instantReady, _ := item.IsItemReady(i.Name)
+
+		lines = append(lines, OrderLine{
+			Name:         i.Name,
+			Ordered:      i.Quantity,
+			Packed:       o.getPackedItemsCount(i.Name),
+			Missing:      missing,
+			InstantReady: instantReady,
+		})
+	}
+
+	return OrderDetails{Order: *o, Lines: lines}
+}
@@ -0,0 +1,47 @@
+package order
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/event"
+	"mc-burger-orders/outbox"
+)
+
+// OrderEvents builds the outbox messages announcing a stored order, they are published by the outbox relay once
+// the order update is committed.
+type OrderEvents struct {
+	streamTopic string
+	statusTopic string
+}
+
+func NewOrderEvents(streamTopicConfigs *event.TopicConfigs, statusTopicConfigs *event.TopicConfigs) *OrderEvents {
+	return &OrderEvents{streamTopic: streamTopicConfigs.Topic, statusTopic: statusTopicConfigs.Topic}
+}
+
+// Messages returns the order-updated event of the order, followed by the order-status-updated event when the order
+// is new or its status differs from the previously stored one.
+func (e *OrderEvents) Messages(ctx context.Context, previousStatus OrderStatus, order Order) ([]outbox.Message, error) {
+	messages := make([]outbox.Message, 0)
+
+	message, err := newOrderMessage(ctx, order, OrderUpdatedEvent, order)
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, outbox.NewMessage(e.streamTopic, message))
+
+	if previousStatus == order.Status {
+		return messages, nil
+	}
+
+	message, err = newOrderMessage(ctx, order, StatusUpdatedEvent, StatusUpdatedPayload{Status: order.Status})
+	if err != nil {
+		return nil, err
+	}
+	messages = append(messages, outbox.NewMessage(e.statusTopic, message))
+	return messages, nil
+}
+
+func newOrderMessage(ctx context.Context, order Order, eventType string, payload any) (kafka.Message, error) {
+	envelope := event.NewEnvelope(ctx, eventType, event.Source("order")).ForOrder(order.OrderNumber)
+	return event.EncodeMessage(ctx, event.OrderKey(order.OrderNumber), envelope, payload)
+}
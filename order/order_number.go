@@ -1,12 +1,73 @@
 package order
 
-import "time"
+import (
+	"github.com/spf13/cast"
+	"mc-burger-orders/log"
+	"os"
+	"strings"
+	"time"
+)
 
-type OrderNumber struct {
-	Number    int64     `bson:"number"`
-	CreatedAt time.Time `bson:"createdAt"`
+const orderNumberSequenceId = "order-number"
+
+type ResetPolicy string
+
+const (
+	NeverReset  = ResetPolicy("never")
+	DailyReset  = ResetPolicy("daily")
+	WrapAtReset = ResetPolicy("wrap")
+)
+
+type OrderNumberSequence struct {
+	Id      string    `bson:"_id"`
+	Number  int64     `bson:"number"`
+	ResetAt time.Time `bson:"resetAt"`
+}
+
+type OrderNumberConfig struct {
+	Policy    ResetPolicy
+	StoreOpen time.Duration
+	WrapAt    int64
+}
+
+func OrderNumberConfigFromEnv() OrderNumberConfig {
+	config := OrderNumberConfig{Policy: NeverReset, StoreOpen: 6 * time.Hour, WrapAt: 999}
+
+	policyVal := os.Getenv("ORDER_NUMBER_RESET_POLICY")
+	switch ResetPolicy(strings.ToLower(policyVal)) {
+	case "", NeverReset:
+		config.Policy = NeverReset
+	case DailyReset:
+		config.Policy = DailyReset
+	case WrapAtReset:
+		config.Policy = WrapAtReset
+	default:
+		log.Error.Panicf("Unknown order number reset policy `%v`", policyVal)
+	}
+
+	if storeOpenVal := os.Getenv("ORDER_NUMBER_STORE_OPEN"); len(storeOpenVal) > 0 {
+		storeOpen, err := time.Parse("15:04", storeOpenVal)
+		if err != nil {
+			log.Error.Panicf("Order number store open time `%v` is not in HH:MM format", storeOpenVal)
+		}
+		config.StoreOpen = time.Duration(storeOpen.Hour())*time.Hour + time.Duration(storeOpen.Minute())*time.Minute
+	}
+
+	if wrapAtVal := os.Getenv("ORDER_NUMBER_WRAP_AT"); len(wrapAtVal) > 0 {
+		config.WrapAt = cast.ToInt64(wrapAtVal)
+	}
+	if config.Policy == WrapAtReset && config.WrapAt <= 0 {
+		log.Error.Panicf("Order number wrap at value needs to be positive, got `%d`", config.WrapAt)
+	}
+	return config
 }
 
-func NewOrderNumber(number int64) OrderNumber {
-	return OrderNumber{Number: number, CreatedAt: time.Now()}
+// LastStoreOpen returns the most recent store opening time that is not after `now`.
+func (c OrderNumberConfig) LastStoreOpen(now time.Time) time.Time {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	storeOpen := midnight.Add(c.StoreOpen)
+	if storeOpen.After(now) {
+		return midnight.AddDate(0, 0, -1).Add(c.StoreOpen)
+	}
+	return storeOpen
 }
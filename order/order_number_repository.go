@@ -2,15 +2,18 @@ package order
 
 import (
 	"context"
+	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"mc-burger-orders/log"
+	"time"
 )
 
 func NewOrderNumberRepository(database *mongo.Database) *OrderNumberRepositoryImpl {
 	collection := database.Collection("order-numbers")
-	return &OrderNumberRepositoryImpl{c: collection}
+	orders := database.Collection("orders")
+	return &OrderNumberRepositoryImpl{c: collection, orders: orders, config: OrderNumberConfigFromEnv()}
 }
 
 type FetchNextOrderNumberRepository interface {
@@ -18,56 +21,98 @@ type FetchNextOrderNumberRepository interface {
 }
 
 type OrderNumberRepositoryImpl struct {
-	c *mongo.Collection
+	c      *mongo.Collection
+	orders *mongo.Collection
+	config OrderNumberConfig
 }
 
 func (r *OrderNumberRepositoryImpl) GetNext(ctx context.Context) (int64, error) {
 	log.Info.Println("Get next order number")
-	limit := int64(1)
-	sortDef := bson.D{{
-		Key:   "number",
-		Value: -1,
-	}}
-
-	opts := &options.FindOptions{
-		Limit: &limit,
-		Sort:  sortDef,
+
+	maxAttempts := int64(1000)
+	if r.config.Policy == WrapAtReset {
+		maxAttempts = r.config.WrapAt
 	}
-	cursor, err := r.c.Find(ctx, bson.D{}, opts)
-	if err != nil {
-		log.Error.Println("Error when fetching current latest order number from db", err)
-		return -1, err
+
+	for attempt := int64(0); attempt < maxAttempts; attempt++ {
+		nextOrderNumber, err := r.increment(ctx)
+		if err != nil {
+			log.Error.Println("Error Determining the next order number", err)
+			return -1, err
+		}
+
+		isActive, err := r.isActiveOrderNumber(ctx, nextOrderNumber)
+		if err != nil {
+			log.Error.Println("Error when checking if order number is still in use", err)
+			return -1, err
+		}
+		if isActive {
+			log.Warning.Printf("Order Number %d is still used by an active order, skipping it", nextOrderNumber)
+			continue
+		}
+
+		log.Info.Println("Next Order Number is", nextOrderNumber)
+		return nextOrderNumber, nil
 	}
-	var numbers []OrderNumber
-	if err = cursor.All(ctx, &numbers); err != nil {
-		log.Error.Println("Error reading cursor data", err)
-		return -1, err
+
+	return -1, fmt.Errorf("all order numbers are used by active orders")
+}
+
+// increment moves the sequence forward in a single find-and-modify, applying the reset policy in the same
+// update pipeline, so concurrent callers never receive the same number.
+func (r *OrderNumberRepositoryImpl) increment(ctx context.Context) (int64, error) {
+	filterDef := bson.D{{Key: "_id", Value: orderNumberSequenceId}}
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+
+	var sequence OrderNumberSequence
+	err := r.c.FindOneAndUpdate(ctx, filterDef, r.incrementPipeline(time.Now()), opts).Decode(&sequence)
+	if mongo.IsDuplicateKeyError(err) {
+		// concurrent upsert of the sequence document, the document exists now
+		err = r.c.FindOneAndUpdate(ctx, filterDef, r.incrementPipeline(time.Now()), opts).Decode(&sequence)
 	}
-	nextOrderNumber, err := r.getAndPersistNext(ctx, numbers)
 	if err != nil {
-		log.Error.Println("Error Determining the next order number", err)
 		return -1, err
 	}
 
-	log.Info.Println("Next Order Number is", nextOrderNumber)
-	return nextOrderNumber, nil
+	return sequence.Number, nil
 }
 
-func (r *OrderNumberRepositoryImpl) getAndPersistNext(ctx context.Context, numbers []OrderNumber) (int64, error) {
-	var latestNumber = NewOrderNumber(1)
-	if len(numbers) > 0 {
-		lastNumber := numbers[0].Number
-		log.Info.Println("Last Order number", latestNumber)
+func (r *OrderNumberRepositoryImpl) incrementPipeline(now time.Time) mongo.Pipeline {
+	currentNumber := bson.D{{Key: "$ifNull", Value: bson.A{"$number", 0}}}
+	currentResetAt := bson.D{{Key: "$ifNull", Value: bson.A{"$resetAt", time.Unix(0, 0)}}}
+	resetAt := interface{}(currentResetAt)
 
-		latestNumber = NewOrderNumber(lastNumber + 1)
+	var resetCondition interface{} = false
+	switch r.config.Policy {
+	case DailyReset:
+		lastStoreOpen := r.config.LastStoreOpen(now)
+		resetCondition = bson.D{{Key: "$lt", Value: bson.A{currentResetAt, lastStoreOpen}}}
+		resetAt = bson.D{{Key: "$cond", Value: bson.A{resetCondition, lastStoreOpen, currentResetAt}}}
+	case WrapAtReset:
+		resetCondition = bson.D{{Key: "$gte", Value: bson.A{currentNumber, r.config.WrapAt}}}
+		resetAt = bson.D{{Key: "$cond", Value: bson.A{resetCondition, now, currentResetAt}}}
 	}
 
-	_, err := r.c.InsertOne(ctx, latestNumber)
-	if err != nil {
-		log.Error.Println("Error Persisting next order number", err)
-		return -1, err
+	return mongo.Pipeline{
+		{{Key: "$set", Value: bson.D{
+			{Key: "number", Value: bson.D{{Key: "$cond", Value: bson.A{
+				resetCondition,
+				1,
+				bson.D{{Key: "$add", Value: bson.A{currentNumber, 1}}},
+			}}}},
+			{Key: "resetAt", Value: resetAt},
+		}}},
 	}
-	log.Info.Println("Next order number persisted")
+}
 
-	return latestNumber.Number, nil
+func (r *OrderNumberRepositoryImpl) isActiveOrderNumber(ctx context.Context, number int64) (bool, error) {
+	filterDef := bson.D{
+		{Key: "orderNumber", Value: number},
+		{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{Requested, InProgress, Ready}}}},
+	}
+	count, err := r.orders.CountDocuments(ctx, filterDef, options.Count().SetLimit(1))
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
@@ -0,0 +1,111 @@
+package order
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/testing/utils"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestIntegrationOrderNumberRepository_GetNext(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+	mongoContainer, database = utils.TestWithMongo(t, ctx)
+
+	collectionDb = database.Collection("orders")
+	orderNumberCollectionDb = database.Collection("order-numbers")
+
+	t.Run("should return unique numbers when requested concurrently", shouldReturnUniqueNumbersWhenRequestedConcurrently)
+	t.Run("should wrap numbers and skip the ones used by active orders", shouldWrapNumbersAndSkipTheOnesUsedByActiveOrders)
+	t.Run("should restart numbers when store opened since last reset", shouldRestartNumbersWhenStoreOpenedSinceLastReset)
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+func shouldReturnUniqueNumbersWhenRequestedConcurrently(t *testing.T) {
+	// given
+	utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+	sut := &OrderNumberRepositoryImpl{c: orderNumberCollectionDb, orders: collectionDb, config: OrderNumberConfig{Policy: NeverReset}}
+
+	numbers := sync.Map{}
+	wg := &sync.WaitGroup{}
+	wg.Add(20)
+
+	// when
+	for i := 0; i < 20; i++ {
+		go func() {
+			defer wg.Done()
+			number, err := sut.GetNext(context.Background())
+			assert.Nil(t, err)
+			_, duplicated := numbers.LoadOrStore(number, struct{}{})
+			assert.False(t, duplicated, "order number %d returned twice", number)
+		}()
+	}
+	wg.Wait()
+
+	// then
+	count, err := orderNumberCollectionDb.CountDocuments(context.Background(), bson.D{})
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), count)
+
+	defer func() {
+		utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+	}()
+}
+
+func shouldWrapNumbersAndSkipTheOnesUsedByActiveOrders(t *testing.T) {
+	// given
+	utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+	utils.DeleteMany(t, collectionDb, bson.D{})
+	utils.InsertMany(t, collectionDb, []interface{}{
+		Order{OrderNumber: 1, CustomerId: 1, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Collected, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+		Order{OrderNumber: 2, CustomerId: 2, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Ready, CreatedAt: time.Now(), ModifiedAt: time.Now()},
+	})
+	sut := &OrderNumberRepositoryImpl{c: orderNumberCollectionDb, orders: collectionDb, config: OrderNumberConfig{Policy: WrapAtReset, WrapAt: 3}}
+	utils.InsertMany(t, orderNumberCollectionDb, []interface{}{OrderNumberSequence{Id: orderNumberSequenceId, Number: 3}})
+
+	// when
+	first, firstErr := sut.GetNext(context.Background())
+	second, secondErr := sut.GetNext(context.Background())
+
+	// then
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, int64(1), first)
+	assert.Equal(t, int64(3), second)
+
+	defer func() {
+		utils.DeleteMany(t, collectionDb, bson.D{})
+		utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+	}()
+}
+
+func shouldRestartNumbersWhenStoreOpenedSinceLastReset(t *testing.T) {
+	// given
+	utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+	config := OrderNumberConfig{Policy: DailyReset, StoreOpen: 6 * time.Hour}
+	sut := &OrderNumberRepositoryImpl{c: orderNumberCollectionDb, orders: collectionDb, config: config}
+	yesterday := config.LastStoreOpen(time.Now()).AddDate(0, 0, -1)
+	utils.InsertMany(t, orderNumberCollectionDb, []interface{}{OrderNumberSequence{Id: orderNumberSequenceId, Number: 120, ResetAt: yesterday}})
+
+	// when
+	first, firstErr := sut.GetNext(context.Background())
+	second, secondErr := sut.GetNext(context.Background())
+
+	// then
+	assert.Nil(t, firstErr)
+	assert.Nil(t, secondErr)
+	assert.Equal(t, int64(1), first)
+	assert.Equal(t, int64(2), second)
+
+	defer func() {
+		utils.DeleteMany(t, orderNumberCollectionDb, bson.D{})
+	}()
+}
@@ -0,0 +1,44 @@
+package order
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestOrderNumberConfig_LastStoreOpenIsTodayWhenStoreAlreadyOpened(t *testing.T) {
+	// given
+	config := OrderNumberConfig{Policy: DailyReset, StoreOpen: 6*time.Hour + 30*time.Minute}
+	now := time.Date(2023, 11, 20, 10, 15, 0, 0, time.UTC)
+
+	// when
+	lastStoreOpen := config.LastStoreOpen(now)
+
+	// then
+	assert.Equal(t, time.Date(2023, 11, 20, 6, 30, 0, 0, time.UTC), lastStoreOpen)
+}
+
+func TestOrderNumberConfig_LastStoreOpenIsYesterdayWhenStoreNotYetOpened(t *testing.T) {
+	// given
+	config := OrderNumberConfig{Policy: DailyReset, StoreOpen: 6*time.Hour + 30*time.Minute}
+	now := time.Date(2023, 11, 20, 5, 59, 0, 0, time.UTC)
+
+	// when
+	lastStoreOpen := config.LastStoreOpen(now)
+
+	// then
+	assert.Equal(t, time.Date(2023, 11, 19, 6, 30, 0, 0, time.UTC), lastStoreOpen)
+}
+
+func TestOrderNumberConfigFromEnv(t *testing.T) {
+	// given
+	t.Setenv("ORDER_NUMBER_RESET_POLICY", "WRAP")
+	t.Setenv("ORDER_NUMBER_STORE_OPEN", "07:45")
+	t.Setenv("ORDER_NUMBER_WRAP_AT", "99")
+
+	// when
+	config := OrderNumberConfigFromEnv()
+
+	// then
+	assert.Equal(t, OrderNumberConfig{Policy: WrapAtReset, StoreOpen: 7*time.Hour + 45*time.Minute, WrapAt: 99}, config)
+}
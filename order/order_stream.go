@@ -0,0 +1,127 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mc-burger-orders/eventstore"
+	"mc-burger-orders/log"
+	"time"
+)
+
+// snapshotEvery is the number of events after which the state of an order is saved, loading an order folds fewer
+// events than that.
+const snapshotEvery = 20
+
+// OrderStream records the changes of orders as domain events in the event store and rebuilds orders by folding them.
+type OrderStream struct {
+	store eventstore.Store
+}
+
+func NewOrderStream(store eventstore.Store) *OrderStream {
+	return &OrderStream{store: store}
+}
+
+func streamIdOf(id primitive.ObjectID) string {
+	return "order-" + id.Hex()
+}
+
+// Load folds the events of the order following its latest snapshot, it returns nil when the order has no events.
+func (s *OrderStream) Load(ctx context.Context, id primitive.ObjectID) (*Order, error) {
+	streamId := streamIdOf(id)
+	snapshot, err := s.store.LoadSnapshot(ctx, streamId)
+	if err != nil {
+		return nil, err
+	}
+
+	var state *Order
+	afterVersion := int64(0)
+	if snapshot != nil {
+		state = &Order{}
+		if err = snapshot.Decode(state); err != nil {
+			return nil, err
+		}
+		afterVersion = snapshot.Version
+	}
+
+	records, err := s.store.Load(ctx, streamId, afterVersion)
+	if err != nil {
+		return nil, err
+	}
+	for _, record := range records {
+		domainEvent, err := decodeDomainEvent(record)
+		if err != nil {
+			return nil, err
+		}
+		state = foldOrder(state, domainEvent)
+		state.ModifiedAt = record.RecordedAt
+	}
+	if state != nil {
+		state.Id = &id
+	}
+	return state, nil
+}
+
+// Adopt starts the stream of an order stored before its changes were recorded from a snapshot of the order.
+func (s *OrderStream) Adopt(ctx context.Context, order Order) error {
+	snapshot, err := eventstore.NewSnapshot(streamIdOf(*order.Id), order.Version, order)
+	if err != nil {
+		return err
+	}
+	return s.store.SaveSnapshot(ctx, snapshot)
+}
+
+// Record appends the changes turning the previous state into the order when the order was read at the current
+// version, and returns the new state of the order.
+func (s *OrderStream) Record(ctx context.Context, previous *Order, order Order) (*Order, error) {
+	version := int64(0)
+	if previous != nil {
+		version = previous.Version
+	}
+	if order.Version != version {
+		return nil, &VersionConflictError{OrderNumber: order.OrderNumber, Version: order.Version}
+	}
+
+	// like Mongo, the state keeps times in UTC with millisecond precision
+	modifiedAt := order.ModifiedAt.Truncate(time.Millisecond).UTC()
+	changes := orderChanges(previous, order)
+	records := make([]eventstore.Record, 0)
+	for _, change := range changes {
+		record, err := eventstore.NewRecord(change.EventType(), change, modifiedAt)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+
+	streamId := streamIdOf(*order.Id)
+	err := s.store.Append(ctx, streamId, version, records...)
+	if errors.Is(err, eventstore.ErrWrongExpectedVersion) {
+		return nil, &VersionConflictError{OrderNumber: order.OrderNumber, Version: order.Version}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	state := foldOrder(previous, changes...)
+	state.Id = order.Id
+	state.CreatedAt = state.CreatedAt.Truncate(time.Millisecond).UTC()
+	state.ModifiedAt = modifiedAt
+
+	if version/snapshotEvery != state.Version/snapshotEvery {
+		s.saveSnapshot(ctx, streamId, *state)
+	}
+	return state, nil
+}
+
+// saveSnapshot only logs failures, the order is rebuilt from its events without a snapshot as well. It runs within the
+// transaction of the order, see eventstore.RepositoryImpl.SaveSnapshot for why a concurrent snapshot does not abort it.
+func (s *OrderStream) saveSnapshot(ctx context.Context, streamId string, state Order) {
+	snapshot, err := eventstore.NewSnapshot(streamId, state.Version, state)
+	if err == nil {
+		err = s.store.SaveSnapshot(ctx, snapshot)
+	}
+	if err != nil {
+		log.Warning.Printf("Snapshot of %v at version %d was not saved. Reason: %v", streamId, state.Version, err)
+	}
+}
@@ -0,0 +1,161 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mc-burger-orders/eventstore"
+	"mc-burger-orders/kitchen/item"
+	"testing"
+	"time"
+)
+
+func TestOrderStream(t *testing.T) {
+	t.Run("should record changes of order as domain events", shouldRecordChangesOfOrderAsDomainEvents)
+	t.Run("should rebuild order by folding its events", shouldRebuildOrderByFoldingItsEvents)
+	t.Run("should amend items when packed items were removed", shouldAmendItemsWhenPackedItemsWereRemoved)
+	t.Run("should rebuild order from snapshot and following events", shouldRebuildOrderFromSnapshotAndFollowingEvents)
+	t.Run("should reject changes of outdated order", shouldRejectChangesOfOutdatedOrder)
+	t.Run("should continue stream of order stored before its changes were recorded", shouldContinueStreamOfOrderStoredBeforeItsChangesWereRecorded)
+}
+
+func shouldRecordChangesOfOrderAsDomainEvents(t *testing.T) {
+	// given
+	requested := givenRequestedOrder(2)
+	packed := *cloneOrder(requested)
+	packed.PackItem("hamburger", 2)
+
+	collected := *cloneOrder(&packed)
+	collected.Status = Collected
+
+	// when
+	requestedChanges := orderChanges(nil, *requested)
+	packedChanges := orderChanges(requested, packed)
+	collectedChanges := orderChanges(&packed, collected)
+
+	// then
+	assert.Equal(t, []DomainEvent{OrderRequested{OrderNumber: 1000, CustomerId: 10, Items: requested.Items, CreatedAt: requested.CreatedAt}}, requestedChanges)
+	assert.Equal(t, []DomainEvent{ItemPacked{ItemName: "hamburger", Quantity: 2}, StatusChanged{Status: Ready}}, packedChanges)
+	assert.Equal(t, []DomainEvent{OrderCollected{}}, collectedChanges)
+}
+
+func shouldRebuildOrderByFoldingItsEvents(t *testing.T) {
+	// given
+	sut := NewOrderStream(eventstore.NewInMemoryRepository())
+	order := givenRequestedOrder(3)
+	_, err := sut.Record(context.Background(), nil, *order)
+	assert.NoError(t, err)
+
+	previous, err := sut.Load(context.Background(), *order.Id)
+	assert.NoError(t, err)
+	changed := *cloneOrder(previous)
+	changed.PackItem("hamburger", 1)
+	changed.ModifiedAt = time.Now()
+	stored, err := sut.Record(context.Background(), previous, changed)
+	assert.NoError(t, err)
+
+	// when
+	rebuilt, err := sut.Load(context.Background(), *order.Id)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, stored, rebuilt)
+	assert.Equal(t, int64(3), rebuilt.Version)
+	assert.Equal(t, InProgress, rebuilt.Status)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}}, rebuilt.PackedItems)
+}
+
+func shouldAmendItemsWhenPackedItemsWereRemoved(t *testing.T) {
+	// given
+	previous := givenRequestedOrder(2)
+	previous.PackItem("hamburger", 2)
+	amended := *cloneOrder(previous)
+	amended.AddItem("fries", 1)
+	_, err := amended.RemoveItem("hamburger", 1)
+	assert.NoError(t, err)
+	amended.UpdateStatus()
+
+	// when
+	changes := orderChanges(previous, amended)
+
+	// then
+	assert.Equal(t, []DomainEvent{ItemsAmended{Items: amended.Items, PackedItems: amended.PackedItems}, StatusChanged{Status: InProgress}}, changes)
+
+	// and
+	folded := foldOrder(previous, changes...)
+	assert.Equal(t, amended.Items, folded.Items)
+	assert.Equal(t, amended.PackedItems, folded.PackedItems)
+	assert.Equal(t, amended.Status, folded.Status)
+}
+
+func shouldRebuildOrderFromSnapshotAndFollowingEvents(t *testing.T) {
+	// given
+	store := eventstore.NewInMemoryRepository()
+	sut := NewOrderStream(store)
+	order := givenRequestedOrder(snapshotEvery + 5)
+	stored, err := sut.Record(context.Background(), nil, *order)
+	assert.NoError(t, err)
+
+	// when
+	for packed := 1; packed <= snapshotEvery+2; packed++ {
+		changed := *cloneOrder(stored)
+		changed.PackItem("hamburger", 1)
+		stored, err = sut.Record(context.Background(), stored, changed)
+		assert.NoError(t, err)
+	}
+
+	// then
+	snapshot, err := store.LoadSnapshot(context.Background(), streamIdOf(*order.Id))
+	assert.NoError(t, err)
+	assert.NotNil(t, snapshot)
+	assert.Equal(t, int64(snapshotEvery), snapshot.Version)
+
+	// and
+	rebuilt, err := sut.Load(context.Background(), *order.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, rebuilt)
+	assert.Len(t, rebuilt.PackedItems, snapshotEvery+2)
+}
+
+func shouldRejectChangesOfOutdatedOrder(t *testing.T) {
+	// given
+	sut := NewOrderStream(eventstore.NewInMemoryRepository())
+	order := givenRequestedOrder(1)
+	stored, err := sut.Record(context.Background(), nil, *order)
+	assert.NoError(t, err)
+
+	// when
+	_, err = sut.Record(context.Background(), nil, *order)
+
+	// then
+	var conflictErr *VersionConflictError
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Equal(t, int64(1), stored.Version)
+}
+
+func shouldContinueStreamOfOrderStoredBeforeItsChangesWereRecorded(t *testing.T) {
+	// given
+	id := primitive.NewObjectID()
+	legacy := Order{Id: &id, OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 2}}, Status: Requested, CreatedAt: time.Now(), Version: 4}
+	sut := GivenInMemoryRepository(legacy)
+
+	// when
+	legacy.PackItem("hamburger", 1)
+	stored, err := sut.InsertOrUpdate(context.Background(), &legacy)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, int64(6), stored.Version)
+
+	// and
+	rebuilt, err := sut.stream.Load(context.Background(), id)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, rebuilt)
+}
+
+func givenRequestedOrder(hamburgers int) *Order {
+	id := primitive.NewObjectID()
+	createdAt := time.Now().Truncate(time.Millisecond).UTC()
+	return &Order{Id: &id, OrderNumber: 1000, CustomerId: 10, Items: []item.Item{{Name: "hamburger", Quantity: hamburgers}}, Status: Requested, CreatedAt: createdAt, ModifiedAt: createdAt}
+}
@@ -306,3 +306,109 @@ func TestOrder_GetMissingItems_WhenOrderHasZeroPackedItems(t *testing.T) {
 	assert.Contains(t, result, item.Item{Name: "some-item", Quantity: 2})
 
 }
+
+func TestOrder_RemoveItem_UnpacksItemsAboveNewQuantity(t *testing.T) {
+	// given
+	order := Order{
+		Items: []item.Item{
+			{
+				Name:     "hamburger",
+				Quantity: 3,
+			},
+		},
+		PackedItems: []item.Item{
+			{
+				Name:     "hamburger",
+				Quantity: 1,
+			},
+			{
+				Name:     "hamburger",
+				Quantity: 2,
+			},
+		},
+	}
+
+	// when
+	unpacked, err := order.RemoveItem("hamburger", 2)
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 2, unpacked)
+
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}}, order.Items)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}}, order.PackedItems)
+}
+
+func TestOrder_RemoveItem_MovesOrderBackToRequestedWhenLastPackedItemIsRemoved(t *testing.T) {
+	// given
+	order := Order{
+		Items: []item.Item{
+			{
+				Name:     "hamburger",
+				Quantity: 1,
+			},
+			{
+				Name:     "fries",
+				Quantity: 2,
+			},
+		},
+		PackedItems: []item.Item{
+			{
+				Name:     "hamburger",
+				Quantity: 1,
+			},
+		},
+		Status: InProgress,
+	}
+
+	// when
+	unpacked, err := order.RemoveItem("hamburger", 1)
+	updated := order.UpdateStatus()
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, 1, unpacked)
+	assert.True(t, updated)
+
+	assert.Empty(t, order.PackedItems)
+	assert.Equal(t, Requested, order.Status)
+}
+
+func TestOrder_RemoveItem_Error_When_RemovingMoreThanOrdered(t *testing.T) {
+	// given
+	order := Order{
+		Items: []item.Item{
+			{
+				Name:     "hamburger",
+				Quantity: 1,
+			},
+		},
+	}
+
+	// when
+	unpacked, err := order.RemoveItem("hamburger", 2)
+
+	// then
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, unpacked)
+	assert.Equal(t, "cannot remove 2 of item `hamburger`, order contains only 1", err.Error())
+}
+
+func TestOrder_AddItem_IncreasesQuantityOfExistingLine(t *testing.T) {
+	// given
+	order := Order{
+		Items: []item.Item{
+			{
+				Name:     "hamburger",
+				Quantity: 1,
+			},
+		},
+	}
+
+	// when
+	order.AddItem("hamburger", 2)
+	order.AddItem("fries", 1)
+
+	// then
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 3}, {Name: "fries", Quantity: 1}}, order.Items)
+}
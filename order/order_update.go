@@ -0,0 +1,135 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	item2 "mc-burger-orders/kitchen/item"
+	"mc-burger-orders/log"
+	"mc-burger-orders/shelf"
+	"time"
+)
+
+const (
+	maxUpdateAttempts   = 5
+	shelfReservationTtl = 30 * time.Second
+)
+
+type packedOrderRepository interface {
+	StoreRepository
+	FetchByIdRepository
+}
+
+func isVersionConflict(err error) bool {
+	var conflict *VersionConflictError
+	return errors.As(err, &conflict)
+}
+
+// storePackedItems persists the order with the items packed by the command. When the order was modified
+// concurrently, it is reloaded and the items are packed again, those the order does not miss anymore go back to
+// the shelf.
+func storePackedItems(ctx context.Context, repository packedOrderRepository, s *shelf.Shelf, order *Order, packedItems []item2.Item) (*Order, error) {
+	result, err := repository.InsertOrUpdate(ctx, order)
+	for attempt := 1; isVersionConflict(err) && attempt < maxUpdateAttempts; attempt++ {
+		if order.Id == nil {
+			return nil, fmt.Errorf("cannot reload order %d without pk id. Reason: %w", order.OrderNumber, err)
+		}
+
+		log.Warning.Printf("Order %d was modified concurrently, packing items %+v again (attempt %d)", order.OrderNumber, packedItems, attempt)
+		order, err = repository.FetchById(ctx, *order.Id)
+		if err != nil {
+			return nil, err
+		}
+		if !isNotInRequiredStatus(order.Status) {
+			log.Warning.Printf("Order %d is %v already, returning items %+v to shelf", order.OrderNumber, order.Status, packedItems)
+			returnItemsToShelf(s, order.OrderNumber, packedItems)
+			return order, nil
+		}
+
+		itemsToReturn := make([]item2.Item, 0)
+		for _, packedItem := range packedItems {
+			missing, err := order.GetMissingItemsCount(packedItem.Name)
+			if err != nil || missing < 0 {
+				missing = 0
+			}
+
+			toPack := min(missing, packedItem.Quantity)
+			if toPack > 0 {
+				order.PackItem(packedItem.Name, toPack)
+			}
+			if excess := packedItem.Quantity - toPack; excess > 0 {
+				itemsToReturn = append(itemsToReturn, item2.Item{Name: packedItem.Name, Quantity: excess})
+			}
+		}
+
+		result, err = repository.InsertOrUpdate(ctx, order)
+		if err == nil {
+			returnItemsToShelf(s, order.OrderNumber, itemsToReturn)
+		}
+	}
+
+	return result, err
+}
+
+// commitReservations makes the items packed into the stored order leave the shelf. Items of a reservation that
+// expired before the order was stored went back on the shelf already, so they are taken from it again. Items other
+// orders took from the shelf in the meantime are unpacked from the order and requested from the kitchen again.
+func commitReservations(ctx context.Context, repository packedOrderRepository, kitchenService KitchenRequestService, s *shelf.Shelf, order *Order, reservations ...*shelf.Reservation) error {
+	shortfall := make([]item2.Item, 0)
+	for _, reservation := range reservations {
+		if err := s.Commit(reservation); err != nil {
+			log.Warning.Printf("Reservation of %d %v for order %d has expired, taking items from shelf again", reservation.Quantity, reservation.Item, reservation.OrderNumber)
+			if succeeded, taken, err := s.Take(reservation.Item, reservation.Quantity); !succeeded {
+				log.Warning.Printf("Could take only %d of %d %v back from shelf for order %d, unpacking the rest. Reason: %v", taken, reservation.Quantity, reservation.Item, reservation.OrderNumber, err)
+				shortfall = append(shortfall, item2.Item{Name: reservation.Item, Quantity: reservation.Quantity - taken})
+			}
+		}
+	}
+	if len(shortfall) == 0 {
+		return nil
+	}
+	return unpackShortfall(ctx, repository, kitchenService, order, shortfall)
+}
+
+// unpackShortfall removes the items missing on the shelf from the packed items of the order, so they are packed
+// again once the kitchen made them.
+func unpackShortfall(ctx context.Context, repository packedOrderRepository, kitchenService KitchenRequestService, order *Order, shortfall []item2.Item) error {
+	var err error
+	for attempt := 1; ; attempt++ {
+		if !isActive(order.Status) {
+			return fmt.Errorf("cannot unpack items %+v missing on shelf from order %d, it is %v already", shortfall, order.OrderNumber, order.Status)
+		}
+		for _, missing := range shortfall {
+			order.unpackItem(missing.Name, max(order.getPackedItemsCount(missing.Name)-missing.Quantity, 0))
+		}
+		order.UpdateStatus()
+
+		_, err = repository.InsertOrUpdate(ctx, order)
+		if !isVersionConflict(err) || attempt >= maxUpdateAttempts {
+			break
+		}
+		log.Warning.Printf("Order %d was modified concurrently, unpacking items %+v again (attempt %d)", order.OrderNumber, shortfall, attempt)
+		if order, err = repository.FetchById(ctx, *order.Id); err != nil {
+			return err
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unpack items %+v missing on shelf from order %d. Reason: %w", shortfall, order.OrderNumber, err)
+	}
+
+	for _, missing := range shortfall {
+		log.Info.Printf("Sending Request to kitchen for %d new %v", missing.Quantity, missing.Name)
+		if err = kitchenService.RequestNew(ctx, missing.Name, missing.Quantity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func releaseReservations(s *shelf.Shelf, reservations ...*shelf.Reservation) {
+	for _, reservation := range reservations {
+		if reservation != nil {
+			s.Release(reservation)
+		}
+	}
+}
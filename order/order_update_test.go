@@ -0,0 +1,81 @@
+package order
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	i "mc-burger-orders/kitchen/item"
+	"mc-burger-orders/shelf"
+	"testing"
+	"time"
+)
+
+func TestCommitReservations(t *testing.T) {
+	t.Run("should take items of expired reservation from shelf again", shouldTakeItemsOfExpiredReservationFromShelfAgain)
+	t.Run("should unpack items taken by other order while reservation expired", shouldUnpackItemsTakenByOtherOrderWhileReservationExpired)
+}
+
+func shouldTakeItemsOfExpiredReservationFromShelfAgain(t *testing.T) {
+	// given
+	s := shelf.NewEmptyShelf()
+	s.AddMany(hamburger, 2)
+	repository := GivenInMemoryRepository()
+	kitchenService := NewStubService()
+	stored, reservation := givenOrderPackedFromExpiredReservation(t, repository, s)
+
+	// when
+	err := commitReservations(context.Background(), repository, kitchenService, s, stored, reservation)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 0, s.GetCurrent(hamburger))
+
+	// and
+	order, err := repository.FetchByOrderNumber(context.Background(), stored.OrderNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, Ready, order.Status)
+	assert.Equal(t, 0, kitchenService.CalledCnt())
+}
+
+func shouldUnpackItemsTakenByOtherOrderWhileReservationExpired(t *testing.T) {
+	// given
+	s := shelf.NewEmptyShelf()
+	s.AddMany(hamburger, 2)
+	repository := GivenInMemoryRepository()
+	kitchenService := NewStubService()
+	stored, reservation := givenOrderPackedFromExpiredReservation(t, repository, s)
+
+	// and
+	_, _, err := s.Take(hamburger, 1)
+	assert.NoError(t, err)
+
+	// when
+	err = commitReservations(context.Background(), repository, kitchenService, s, stored, reservation)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 0, s.GetCurrent(hamburger))
+
+	// and
+	order, err := repository.FetchByOrderNumber(context.Background(), stored.OrderNumber)
+	assert.NoError(t, err)
+	assert.Equal(t, InProgress, order.Status)
+	assert.Equal(t, []i.Item{{Name: hamburger, Quantity: 1}}, order.PackedItems)
+
+	// and
+	assert.True(t, kitchenService.HaveBeenCalledWith(RequestMatchingFnc(hamburger, 1)))
+}
+
+// givenOrderPackedFromExpiredReservation stores an order packed with 2 hamburgers reserved on the shelf, the
+// reservation expires before it is committed, so the hamburgers are back on the shelf.
+func givenOrderPackedFromExpiredReservation(t *testing.T, repository *InMemoryRepository, s *shelf.Shelf) (*Order, *shelf.Reservation) {
+	reservation, err := s.Reserve(hamburger, 2, expectedOrderNumber, time.Millisecond)
+	assert.NoError(t, err)
+
+	order := CreateNewOrder(expectedOrderNumber, NewOrder{CustomerId: 1, Items: []i.Item{{Name: hamburger, Quantity: 2}}})
+	order.PackItem(hamburger, reservation.Quantity)
+	stored, err := repository.InsertOrUpdate(context.Background(), order)
+	assert.NoError(t, err)
+
+	assert.Eventually(t, func() bool { return s.GetCurrent(hamburger) == 2 }, time.Second, time.Millisecond)
+	return stored, reservation
+}
@@ -2,10 +2,10 @@ package order
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
+	"mc-burger-orders/event"
 	"mc-burger-orders/log"
 )
 
@@ -15,9 +15,9 @@ type OrderUpdatedCommand struct {
 }
 
 func (o *OrderUpdatedCommand) Execute(ctx context.Context, message kafka.Message, commandResults chan command.TypedResult) {
-	payloadBody := map[string]OrderStatus{}
-	if err := json.Unmarshal(message.Value, &payloadBody); err != nil {
-		log.Error.Printf("failed to unmarshal payload from message to map of strings. Reason: %v", err.Error())
+	payloadBody := StatusUpdatedPayload{}
+	if err := event.DecodePayload(ctx, message, &payloadBody); err != nil {
+		log.Error.Printf("failed to decode status payload from message. Reason: %v", err.Error())
 		commandResults <- command.NewErrorResult("OrderUpdatedCommand", err)
 		return
 	}
@@ -28,13 +28,12 @@ func (o *OrderUpdatedCommand) Execute(ctx context.Context, message kafka.Message
 		return
 	}
 
-	status, exists := payloadBody["status"]
-	if !exists {
+	if len(payloadBody.Status) == 0 {
 		err := fmt.Errorf("failed to find status property in payload: +%v", payloadBody)
 		commandResults <- command.NewErrorResult("OrderUpdatedCommand", err)
 		return
 	}
 
-	log.Info.Printf("Order %d has been updated to %v", o.OrderNumber, status)
+	log.Info.Printf("Order %d has been updated to %v", o.OrderNumber, payloadBody.Status)
 	commandResults <- command.NewSuccessfulResult("OrderUpdatedCommand")
 }
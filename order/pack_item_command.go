@@ -2,10 +2,11 @@ package order
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
+	"mc-burger-orders/event"
+	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/log"
 	"mc-burger-orders/order/dto"
 	"mc-burger-orders/shelf"
@@ -14,13 +15,12 @@ import (
 type PackItemCommand struct {
 	Repository     PackingOrderItemsRepository
 	KitchenService KitchenRequestService
-	StatusEmitter  StatusEmitter
 	Shelf          *shelf.Shelf
 }
 
 func (p *PackItemCommand) Execute(ctx context.Context, message kafka.Message, commandResults chan command.TypedResult) {
 	stackMessage := make([]dto.StackItemAddedMessage, 0)
-	err := json.Unmarshal(message.Value, &stackMessage)
+	err := event.DecodePayload(ctx, message, &stackMessage)
 	if err != nil {
 		log.Error.Println("could not Unmarshal event message to StackUpdatedMessage", err)
 		commandResults <- command.NewErrorResult("PackItemCommand", err)
@@ -63,28 +63,33 @@ func (p *PackItemCommand) Execute(ctx context.Context, message kafka.Message, co
 				orderQuantity = current
 			}
 
-			succeeded, taken, err := p.Shelf.Take(itemUpdate.ItemName, orderQuantity)
-			if !succeeded {
-				remaining := orderQuantity - taken
+			reservation, err := p.Shelf.Reserve(itemUpdate.ItemName, orderQuantity, order.OrderNumber, shelfReservationTtl)
+			if err == nil && reservation.Quantity < orderQuantity {
+				remaining := orderQuantity - reservation.Quantity
 
 				log.Info.Printf("Sending Request to kitchen for %d new %v", remaining, itemUpdate.ItemName)
 				err = p.KitchenService.RequestNew(ctx, itemUpdate.ItemName, remaining)
 			}
 
 			if err != nil {
+				releaseReservations(p.Shelf, reservation)
 				log.Error.Printf("could not take item `%v` in quantity `%d` from Shelf. Reason: %v", itemUpdate.ItemName, itemUpdate.Quantity, err)
 				continue
 			}
 
-			statusUpdated := order.PackItem(itemUpdate.ItemName, orderQuantity)
-			_, err = p.Repository.InsertOrUpdate(ctx, order)
+			order.PackItem(itemUpdate.ItemName, reservation.Quantity)
+			packedItems := []item.Item{{Name: itemUpdate.ItemName, Quantity: reservation.Quantity}}
+			stored, err := storePackedItems(ctx, p.Repository, p.Shelf, order, packedItems)
 			if err != nil {
+				releaseReservations(p.Shelf, reservation)
 				log.Error.Printf("failed to update order `%d`, reason: %v", order.OrderNumber, err)
 				commandResults <- command.NewErrorResult("PackItemCommand", err)
 				return
 			}
-			if statusUpdated {
-				go p.StatusEmitter.EmitStatusUpdatedEvent(*order)
+			if err = commitReservations(ctx, p.Repository, p.KitchenService, p.Shelf, stored, reservation); err != nil {
+				log.Error.Printf("failed to take items packed into order `%d` from shelf, reason: %v", order.OrderNumber, err)
+				commandResults <- command.NewErrorResult("PackItemCommand", err)
+				return
 			}
 		}
 	}
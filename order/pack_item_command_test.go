@@ -7,10 +7,10 @@ import (
 	"github.com/segmentio/kafka-go"
 	"github.com/spf13/cast"
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"mc-burger-orders/command"
 	i "mc-burger-orders/kitchen/item"
 	"mc-burger-orders/shelf"
-	"sync"
 	"testing"
 	"time"
 )
@@ -36,6 +36,8 @@ func TestPackItemCommand_Execute(t *testing.T) {
 	t.Run("should pack items of other orders when first order already is packed with the item that was added to shelf", shouldPackOtherOrdersWhenTheFirstOneIsAlreadyPackedByItem)
 	t.Run("should pack available items and request new when not all items are available", shouldRequestAdditionalItemWhenMoreAreNeeded)
 	t.Run("should fail when message value is empty", shouldFailWhenMessageValueIsEmpty)
+	t.Run("should return items to shelf when order was packed concurrently", shouldReturnItemsToShelfWhenOrderWasPackedConcurrently)
+	t.Run("should release reserved items when order could not be stored", shouldReleaseReservedItemsWhenOrderCouldNotBeStored)
 }
 
 func shouldPackItemPointedInMessage(t *testing.T) {
@@ -59,10 +61,6 @@ func shouldPackItemPointedInMessage(t *testing.T) {
 	message := givenKafkaMessage(t, messageValue)
 
 	kitchenService := NewStubService()
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(1)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
 
 	repositoryStub := GivenRepository()
 	repositoryStub.ReturnOrders(givenExistingOrder())
@@ -71,7 +69,6 @@ func shouldPackItemPointedInMessage(t *testing.T) {
 		Shelf:          s,
 		Repository:     repositoryStub,
 		KitchenService: kitchenService,
-		StatusEmitter:  stubStatusEmitter,
 	}
 
 	expectedPackedItems := []i.Item{
@@ -113,9 +110,7 @@ func shouldPackItemPointedInMessage(t *testing.T) {
 	assert.Equal(t, 0, kitchenService.CalledCnt())
 
 	// and
-	statusUpdateWg.Wait()
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 1)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(InProgress)))
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), InProgress)
 	close(commandResults)
 }
 
@@ -149,11 +144,6 @@ func shouldFinishPackingOrderWhenLastItemsCameFromKitchen(t *testing.T) {
 
 	kitchenService := NewStubService()
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(2)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	repositoryStub := GivenRepository()
 	repositoryStub.ReturnOrders(givenExistingOrder())
 
@@ -161,7 +151,6 @@ func shouldFinishPackingOrderWhenLastItemsCameFromKitchen(t *testing.T) {
 		Shelf:          s,
 		Repository:     repositoryStub,
 		KitchenService: kitchenService,
-		StatusEmitter:  stubStatusEmitter,
 	}
 
 	expectedPackedItems := []i.Item{
@@ -210,10 +199,8 @@ func shouldFinishPackingOrderWhenLastItemsCameFromKitchen(t *testing.T) {
 	assert.Equal(t, 0, kitchenService.CalledCnt(), "No items have not been requested")
 
 	// and
-	statusUpdateWg.Wait()
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 2)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(InProgress)))
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Ready)))
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), InProgress)
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), Ready)
 	close(commandResults)
 }
 
@@ -269,11 +256,6 @@ func shouldPackMultipleOrdersWhenMultipleItemsAdded(t *testing.T) {
 
 	kitchenService := NewStubService()
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(4)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	repositoryStub := GivenRepository()
 	repositoryStub.ReturnOrders(existingOrders...)
 
@@ -281,7 +263,6 @@ func shouldPackMultipleOrdersWhenMultipleItemsAdded(t *testing.T) {
 		Shelf:          s,
 		Repository:     repositoryStub,
 		KitchenService: kitchenService,
-		StatusEmitter:  stubStatusEmitter,
 	}
 	commandResults := make(chan command.TypedResult)
 
@@ -325,10 +306,8 @@ func shouldPackMultipleOrdersWhenMultipleItemsAdded(t *testing.T) {
 	assert.Equal(t, 0, kitchenService.CalledCnt())
 
 	// and
-	statusUpdateWg.Wait()
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 4)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(InProgress)))
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(Ready)))
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), InProgress)
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), Ready)
 	close(commandResults)
 }
 
@@ -383,11 +362,6 @@ func shouldPackOtherOrdersWhenTheFirstOneIsAlreadyPackedByItem(t *testing.T) {
 
 	kitchenService := NewStubService()
 
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(2)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
-
 	repositoryStub := GivenRepository()
 	repositoryStub.ReturnOrders(existingOrders...)
 
@@ -395,7 +369,6 @@ func shouldPackOtherOrdersWhenTheFirstOneIsAlreadyPackedByItem(t *testing.T) {
 		Shelf:          s,
 		Repository:     repositoryStub,
 		KitchenService: kitchenService,
-		StatusEmitter:  stubStatusEmitter,
 	}
 	commandResults := make(chan command.TypedResult)
 
@@ -431,9 +404,7 @@ func shouldPackOtherOrdersWhenTheFirstOneIsAlreadyPackedByItem(t *testing.T) {
 	assert.True(t, kitchenService.HaveBeenCalledWith(RequestMatchingFnc(spicyStripes, 6)))
 
 	// and
-	statusUpdateWg.Wait()
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 2)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(InProgress)))
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), InProgress)
 	close(commandResults)
 }
 
@@ -452,10 +423,6 @@ func shouldRequestAdditionalItemWhenMoreAreNeeded(t *testing.T) {
 	message := givenKafkaMessage(t, messageValue)
 
 	kitchenService := NewStubService()
-	statusUpdateWg := &sync.WaitGroup{}
-	statusUpdateWg.Add(1)
-	stubStatusEmitter := NewStubService()
-	stubStatusEmitter.WithWaitGroup(statusUpdateWg)
 	repositoryStub := GivenRepository()
 	repositoryStub.ReturnOrders(givenExistingOrder())
 
@@ -463,7 +430,6 @@ func shouldRequestAdditionalItemWhenMoreAreNeeded(t *testing.T) {
 		Shelf:          s,
 		Repository:     repositoryStub,
 		KitchenService: kitchenService,
-		StatusEmitter:  stubStatusEmitter,
 	}
 
 	expectedPackedItems := []i.Item{
@@ -500,9 +466,7 @@ func shouldRequestAdditionalItemWhenMoreAreNeeded(t *testing.T) {
 	assert.True(t, kitchenService.HaveBeenCalledWith(RequestMatchingFnc(spicyStripes, 3)))
 
 	// and
-	statusUpdateWg.Wait()
-	assert.Len(t, stubStatusEmitter.GetStatusUpdatedEventArgs(), 1)
-	assert.True(t, stubStatusEmitter.HaveBeenCalledWith(StatusUpdateMatchingFnc(InProgress)))
+	assert.Contains(t, repositoryStub.GetUpsertStatuses(), InProgress)
 	close(commandResults)
 }
 
@@ -512,14 +476,12 @@ func shouldFailWhenMessageValueIsEmpty(t *testing.T) {
 	message := givenKafkaMessage(t, make([]map[string]any, 0))
 
 	kitchenService := NewStubService()
-	stubStatusEmitter := NewStubService()
 	repositoryStub := GivenRepository()
 	repositoryStub.ReturnOrders(givenExistingOrder())
 
 	sut := &PackItemCommand{
 		Shelf:          s,
 		Repository:     repositoryStub,
-		StatusEmitter:  stubStatusEmitter,
 		KitchenService: kitchenService,
 	}
 	commandResults := make(chan command.TypedResult)
@@ -540,7 +502,7 @@ func shouldFailWhenMessageValueIsEmpty(t *testing.T) {
 	assert.Zero(t, kitchenService.CalledCnt())
 
 	// and
-	assert.Empty(t, stubStatusEmitter.GetStatusUpdatedEventArgs())
+	assert.Empty(t, repositoryStub.GetUpsertArgs())
 	close(commandResults)
 }
 
@@ -593,3 +555,99 @@ func getMissingItemsCount(order Order, itemName string) int {
 	}
 	return -1
 }
+
+func shouldReturnItemsToShelfWhenOrderWasPackedConcurrently(t *testing.T) {
+	// given
+	s := shelf.NewEmptyShelf()
+	s.AddMany(spicyStripes, 10)
+
+	messageValue := make([]map[string]any, 0)
+	messageValue = append(messageValue, map[string]any{
+		"itemName": spicyStripes,
+		"quantity": 10,
+	})
+	message := givenKafkaMessage(t, messageValue)
+
+	orderId := primitive.NewObjectID()
+	existingOrder := givenExistingOrder()
+	existingOrder.Id = &orderId
+
+	concurrentlyPackedOrder := givenExistingOrder()
+	concurrentlyPackedOrder.Id = &orderId
+	concurrentlyPackedOrder.Status = InProgress
+	concurrentlyPackedOrder.Version = 1
+	concurrentlyPackedOrder.PackedItems = []i.Item{
+		{Name: cheeseburger, Quantity: 1},
+		{Name: spicyStripes, Quantity: 8},
+	}
+
+	kitchenService := NewStubService()
+
+	repositoryStub := GivenRepository()
+	repositoryStub.ReturnOrders(existingOrder)
+	repositoryStub.ReturnFetchById(concurrentlyPackedOrder)
+	repositoryStub.ReturnVersionConflicts(1)
+
+	sut := &PackItemCommand{
+		Shelf:          s,
+		Repository:     repositoryStub,
+		KitchenService: kitchenService,
+	}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), message, commandResults)
+
+	// then
+	commandResult := <-commandResults
+	assert.True(t, commandResult.Result)
+
+	// and
+	upsertArgs := repositoryStub.GetUpsertArgs()
+	assert.Len(t, upsertArgs, 2)
+	assert.Equal(t, InProgress, upsertArgs[1].Status)
+	assert.Equal(t, concurrentlyPackedOrder.PackedItems, upsertArgs[1].PackedItems)
+
+	// and
+	assert.Equal(t, 10, s.GetCurrent(spicyStripes))
+	assert.Equal(t, 0, kitchenService.CalledCnt())
+	close(commandResults)
+}
+
+func shouldReleaseReservedItemsWhenOrderCouldNotBeStored(t *testing.T) {
+	// given
+	s := shelf.NewEmptyShelf()
+	s.AddMany(spicyStripes, 10)
+
+	messageValue := make([]map[string]any, 0)
+	messageValue = append(messageValue, map[string]any{
+		"itemName": spicyStripes,
+		"quantity": 10,
+	})
+	message := givenKafkaMessage(t, messageValue)
+
+	kitchenService := NewStubService()
+
+	repositoryStub := GivenRepository()
+	repositoryStub.ReturnOrders(givenExistingOrder())
+	repositoryStub.ReturnVersionConflicts(1)
+
+	sut := &PackItemCommand{
+		Shelf:          s,
+		Repository:     repositoryStub,
+		KitchenService: kitchenService,
+	}
+	commandResults := make(chan command.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), message, commandResults)
+
+	// then
+	commandResult := <-commandResults
+	assert.False(t, commandResult.Result)
+
+	// and
+	assert.Len(t, repositoryStub.GetUpsertArgs(), 1)
+	assert.Equal(t, 10, s.GetCurrent(spicyStripes))
+	close(commandResults)
+}
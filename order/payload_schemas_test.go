@@ -0,0 +1,73 @@
+package order
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/event"
+	"mc-burger-orders/kitchen"
+	"mc-burger-orders/order/dto"
+	"mc-burger-orders/shelf"
+	shelfDto "mc-burger-orders/shelf/dto"
+	"mc-burger-orders/testing/utils"
+	"testing"
+)
+
+func TestPayloadSchemas(t *testing.T) {
+	t.Run("should read order status written with its Avro schema", shouldReadOrderStatusWrittenWithItsAvroSchema)
+	t.Run("should read kitchen request in kitchen written with its Avro schema", shouldReadKitchenRequestInKitchenWrittenWithItsAvroSchema)
+	t.Run("should read items added on shelf written with their Avro schema", shouldReadItemsAddedOnShelfWrittenWithTheirAvroSchema)
+}
+
+func shouldReadOrderStatusWrittenWithItsAvroSchema(t *testing.T) {
+	// given
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+	codec := event.NewAvroCodec(event.NewSchemaRegistryClient(registry.URL)).WithSchema(StatusUpdatedEvent, StatusUpdatedSchema)
+
+	// when
+	data, err := codec.Encode(context.Background(), StatusUpdatedEvent, StatusUpdatedPayload{Status: Ready})
+
+	// then
+	assert.NoError(t, err)
+
+	// and
+	payload := StatusUpdatedPayload{}
+	assert.NoError(t, codec.Decode(context.Background(), data, &payload))
+	assert.Equal(t, Ready, payload.Status)
+}
+
+func shouldReadKitchenRequestInKitchenWrittenWithItsAvroSchema(t *testing.T) {
+	// given
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+	codec := event.NewAvroCodec(event.NewSchemaRegistryClient(registry.URL)).WithSchema(kitchen.RequestItemEvent, kitchen.RequestItemSchema)
+
+	// when
+	data, err := codec.Encode(context.Background(), kitchen.RequestItemEvent, []*dto.KitchenRequestMessage{dto.NewKitchenRequestMessage(hamburger, 2)})
+
+	// then
+	assert.NoError(t, err)
+
+	// and
+	requests := make([]kitchen.ItemRequest, 0)
+	assert.NoError(t, codec.Decode(context.Background(), data, &requests))
+	assert.Equal(t, []kitchen.ItemRequest{{ItemName: hamburger, Quantity: 2}}, requests)
+}
+
+func shouldReadItemsAddedOnShelfWrittenWithTheirAvroSchema(t *testing.T) {
+	// given
+	registry := utils.NewFakeSchemaRegistry()
+	defer registry.Close()
+	codec := event.NewAvroCodec(event.NewSchemaRegistryClient(registry.URL)).WithSchema(shelf.ItemAddedOnShelfEvent, shelf.ItemAddedOnShelfSchema)
+
+	// when
+	data, err := codec.Encode(context.Background(), shelf.ItemAddedOnShelfEvent, []shelfDto.ItemAdded{{ItemName: cheeseburger, Quantity: 3}})
+
+	// then
+	assert.NoError(t, err)
+
+	// and
+	stackMessage := make([]dto.StackItemAddedMessage, 0)
+	assert.NoError(t, codec.Decode(context.Background(), data, &stackMessage))
+	assert.Equal(t, []dto.StackItemAddedMessage{{ItemName: cheeseburger, Quantity: 3}}, stackMessage)
+}
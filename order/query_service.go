@@ -1,20 +1,40 @@
 package order
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
+	"go.mongodb.org/mongo-driver/mongo"
 	"mc-burger-orders/log"
 	"mc-burger-orders/testing/utils"
 	"net/http"
+	"strconv"
 )
 
+type QueryRepository interface {
+	FetchManyRepository
+	FetchByOrderNumberRepository
+}
+
 type OrderQueryService struct {
 	orderNumberRepository FetchNextOrderNumberRepository
-	Repository            FetchManyRepository
+	Repository            QueryRepository
 }
 
 func (s *OrderQueryService) FetchOrders(c *gin.Context) {
-	orders, err := s.Repository.FetchMany(c)
+	criteria, err := ParseOrderCriteria(c.Request.URL.Query())
+	if err != nil {
+		log.Info.Println("Fetch Orders request Error: ", err.Error())
 
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(err.Error()))
+		return
+	}
+
+	orders, err := s.Repository.FetchMany(c, criteria)
+	if errors.Is(err, ErrUnknownCursor) {
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(err.Error()))
+		return
+	}
 	if err != nil {
 		log.Error.Println("Failure when reading data from db.", err.Error())
 
@@ -22,9 +42,40 @@ func (s *OrderQueryService) FetchOrders(c *gin.Context) {
 		return
 	}
 
+	if int64(len(orders)) == criteria.Limit && orders[len(orders)-1].Id != nil {
+		lastOrder := orders[len(orders)-1]
+		c.Header("X-Next-Cursor", lastOrder.Id.Hex())
+	}
 	c.JSON(http.StatusOK, orders)
 }
 
+func (s *OrderQueryService) FetchOrder(c *gin.Context) {
+	param := c.Param("orderNumber")
+	orderNumber, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		log.Error.Println("Unable to parse url parameter to OrderNumber", err.Error())
+
+		errResponse := fmt.Sprintf("unable to parse url parameter to OrderNumber. Reason - %v", err.Error())
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload(errResponse))
+		return
+	}
+
+	order, err := s.Repository.FetchByOrderNumber(c, orderNumber)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		errResponse := fmt.Sprintf("order %d does not exist", orderNumber)
+		c.JSON(http.StatusNotFound, utils.ErrorPayload(errResponse))
+		return
+	}
+	if err != nil {
+		log.Error.Println("Failure when reading data from db.", err.Error())
+
+		c.JSON(http.StatusInternalServerError, utils.ErrorPayload(err.Error()))
+		return
+	}
+
+	c.JSON(http.StatusOK, NewOrderDetails(order))
+}
+
 func (s *OrderQueryService) GetNextOrderNumber(c *gin.Context) int64 {
 	orderNumber, err := s.orderNumberRepository.GetNext(c)
 
@@ -0,0 +1,25 @@
+package order
+
+import (
+	"github.com/gin-gonic/gin"
+	"mc-burger-orders/testing/utils"
+	"net/http"
+	"strconv"
+)
+
+func (e *Endpoints) activeOrdersHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, e.readModels.ActiveOrders.CountByStatus())
+}
+
+func (e *Endpoints) customerHistoryHandler(c *gin.Context) {
+	customerId, err := strconv.Atoi(c.Param("customerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, utils.ErrorPayload("customerId needs to be a number"))
+		return
+	}
+	c.JSON(http.StatusOK, e.readModels.CustomerHistory.History(customerId))
+}
+
+func (e *Endpoints) itemBacklogHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, e.readModels.ItemBacklog.Backlog())
+}
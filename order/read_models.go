@@ -0,0 +1,371 @@
+package order
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mc-burger-orders/event"
+	"mc-burger-orders/projection"
+	"os"
+	"strconv"
+	"sync"
+)
+
+// OrderReadModels are folded from the order-updated events of the order stream topic.
+type OrderReadModels struct {
+	ActiveOrders    *ActiveOrdersReadModel
+	CustomerHistory *CustomerHistoryReadModel
+	ItemBacklog     *ItemBacklogReadModel
+}
+
+func NewOrderReadModels() *OrderReadModels {
+	return &OrderReadModels{
+		ActiveOrders:    &ActiveOrdersReadModel{orders: make(map[string]*Order)},
+		CustomerHistory: &CustomerHistoryReadModel{orders: make(map[int]map[string]*Order)},
+		ItemBacklog:     &ItemBacklogReadModel{orders: make(map[string]backlogEntry)},
+	}
+}
+
+func (m *OrderReadModels) All() []projection.ReadModel {
+	return []projection.ReadModel{m.ActiveOrders, m.CustomerHistory, m.ItemBacklog}
+}
+
+// QueriesFromReadModelsFromEnv tells if order queries are answered by the read models, they lag behind the orders
+// collection by the time the order events take to reach them.
+func QueriesFromReadModelsFromEnv() bool {
+	enabled, err := strconv.ParseBool(os.Getenv("ORDER_QUERIES_FROM_READ_MODELS"))
+	return err == nil && enabled
+}
+
+// orderUpdateOf reads the order announced by an order-updated event, it returns nil for messages of other events.
+func orderUpdateOf(ctx context.Context, message kafka.Message) (*Order, error) {
+	envelope, err := event.ReadEnvelope(message)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", event.ErrMalformedMessage, err)
+	}
+	if envelope.Type != OrderUpdatedEvent {
+		return nil, nil
+	}
+
+	order := &Order{}
+	if err = event.DecodePayload(ctx, message, order); err != nil {
+		return nil, err
+	}
+	if order.Id == nil {
+		return nil, fmt.Errorf("%w: order %d of the event has no id", event.ErrMalformedMessage, order.OrderNumber)
+	}
+	return order, nil
+}
+
+// ActiveOrdersReadModel keeps the latest state of the orders which are neither collected nor cancelled.
+type ActiveOrdersReadModel struct {
+	mu     sync.RWMutex
+	orders map[string]*Order
+}
+
+func (m *ActiveOrdersReadModel) Name() string {
+	return "active-orders"
+}
+
+func (m *ActiveOrdersReadModel) Apply(ctx context.Context, message kafka.Message) error {
+	order, err := orderUpdateOf(ctx, message)
+	if err != nil || order == nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := order.Id.Hex()
+	if known, exists := m.orders[key]; exists && known.Version >= order.Version {
+		return nil
+	}
+	if isActive(order.Status) {
+		m.orders[key] = order
+	} else {
+		delete(m.orders, key)
+	}
+	return nil
+}
+
+// FetchMany answers queries of active orders the way OrderRepositoryImpl does.
+func (m *ActiveOrdersReadModel) FetchMany(_ context.Context, criteria OrderCriteria) ([]*Order, error) {
+	return m.fetchMany(criteria, nil)
+}
+
+func (m *ActiveOrdersReadModel) fetchMany(criteria OrderCriteria, cursorOrder *Order) ([]*Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fetchMany(ordersOf(m.orders), criteria, cursorOrder)
+}
+
+func (m *ActiveOrdersReadModel) CountByStatus() map[OrderStatus]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	counts := map[OrderStatus]int{Requested: 0, InProgress: 0, Ready: 0}
+	for _, order := range m.orders {
+		counts[order.Status]++
+	}
+	return counts
+}
+
+func (m *ActiveOrdersReadModel) State() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return json.Marshal(m.orders)
+}
+
+func (m *ActiveOrdersReadModel) Restore(state []byte) error {
+	orders := make(map[string]*Order)
+	if err := json.Unmarshal(state, &orders); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = orders
+	return nil
+}
+
+func (m *ActiveOrdersReadModel) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = make(map[string]*Order)
+}
+
+// customerHistoryLength is the number of orders the customer history keeps of a customer, so its checkpoint stays
+// far below the size limit of a document.
+const customerHistoryLength = 100
+
+// CustomerHistoryReadModel keeps the latest state of the latest created orders of every customer.
+type CustomerHistoryReadModel struct {
+	mu     sync.RWMutex
+	orders map[int]map[string]*Order
+}
+
+func (m *CustomerHistoryReadModel) Name() string {
+	return "customer-history"
+}
+
+func (m *CustomerHistoryReadModel) Apply(ctx context.Context, message kafka.Message) error {
+	order, err := orderUpdateOf(ctx, message)
+	if err != nil || order == nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	customerOrders, exists := m.orders[order.CustomerId]
+	if !exists {
+		customerOrders = make(map[string]*Order)
+		m.orders[order.CustomerId] = customerOrders
+	}
+	key := order.Id.Hex()
+	if known, exists := customerOrders[key]; !exists || known.Version < order.Version {
+		customerOrders[key] = order
+	}
+	if len(customerOrders) > customerHistoryLength {
+		delete(customerOrders, earliestCreated(customerOrders).Id.Hex())
+	}
+	return nil
+}
+
+// holdsWholeHistoryOf tells if no order of the customer was dropped from the history to keep it short.
+func (m *CustomerHistoryReadModel) holdsWholeHistoryOf(customerId int) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.orders[customerId]) < customerHistoryLength
+}
+
+// History returns the orders of the customer kept in the history, the latest created first.
+func (m *CustomerHistoryReadModel) History(customerId int) []*Order {
+	orders, _ := m.FetchMany(context.Background(), OrderCriteria{CustomerId: &customerId, SortBy: "createdAt", Descending: true})
+	return orders
+}
+
+// FetchMany answers queries of the orders of a customer the way OrderRepositoryImpl does.
+func (m *CustomerHistoryReadModel) FetchMany(_ context.Context, criteria OrderCriteria) ([]*Order, error) {
+	return m.fetchMany(criteria, nil)
+}
+
+func (m *CustomerHistoryReadModel) fetchMany(criteria OrderCriteria, cursorOrder *Order) ([]*Order, error) {
+	if criteria.CustomerId == nil {
+		return nil, fmt.Errorf("customer history is queried by customer")
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return fetchMany(ordersOf(m.orders[*criteria.CustomerId]), criteria, cursorOrder)
+}
+
+func (m *CustomerHistoryReadModel) State() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return json.Marshal(m.orders)
+}
+
+func (m *CustomerHistoryReadModel) Restore(state []byte) error {
+	orders := make(map[int]map[string]*Order)
+	if err := json.Unmarshal(state, &orders); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = orders
+	return nil
+}
+
+func (m *CustomerHistoryReadModel) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = make(map[int]map[string]*Order)
+}
+
+// ItemBacklogReadModel keeps the quantities of items still missing on requested and in progress orders.
+type ItemBacklogReadModel struct {
+	mu     sync.RWMutex
+	orders map[string]backlogEntry
+}
+
+type backlogEntry struct {
+	Version int64          `json:"version"`
+	Missing map[string]int `json:"missing"`
+}
+
+func (m *ItemBacklogReadModel) Name() string {
+	return "item-backlog"
+}
+
+func (m *ItemBacklogReadModel) Apply(ctx context.Context, message kafka.Message) error {
+	order, err := orderUpdateOf(ctx, message)
+	if err != nil || order == nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := order.Id.Hex()
+	if known, exists := m.orders[key]; exists && known.Version >= order.Version {
+		return nil
+	}
+	if !isNotInRequiredStatus(order.Status) {
+		delete(m.orders, key)
+		return nil
+	}
+
+	missing := make(map[string]int)
+	for _, missingItem := range order.GetMissingItems() {
+		missing[missingItem.Name] += missingItem.Quantity
+	}
+	m.orders[key] = backlogEntry{Version: order.Version, Missing: missing}
+	return nil
+}
+
+// Backlog returns the quantity of every item still missing on orders.
+func (m *ItemBacklogReadModel) Backlog() map[string]int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	backlog := make(map[string]int)
+	for _, entry := range m.orders {
+		for itemName, quantity := range entry.Missing {
+			backlog[itemName] += quantity
+		}
+	}
+	return backlog
+}
+
+func (m *ItemBacklogReadModel) State() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return json.Marshal(m.orders)
+}
+
+func (m *ItemBacklogReadModel) Restore(state []byte) error {
+	orders := make(map[string]backlogEntry)
+	if err := json.Unmarshal(state, &orders); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = orders
+	return nil
+}
+
+func (m *ItemBacklogReadModel) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.orders = make(map[string]backlogEntry)
+}
+
+// readModelQueries answers order queries from the read models, queries they cannot answer go to the repository.
+type readModelQueries struct {
+	readModels *OrderReadModels
+	repository readModelQueriesRepository
+}
+
+type readModelQueriesRepository interface {
+	QueryRepository
+	FetchByIdRepository
+}
+
+func (q *readModelQueries) FetchMany(ctx context.Context, criteria OrderCriteria) ([]*Order, error) {
+	onlyActive := len(criteria.Statuses) > 0
+	for _, status := range criteria.Statuses {
+		onlyActive = onlyActive && isActive(status)
+	}
+
+	var fetchMany func(criteria OrderCriteria, cursorOrder *Order) ([]*Order, error)
+	switch {
+	case onlyActive:
+		fetchMany = q.readModels.ActiveOrders.fetchMany
+	case criteria.CustomerId != nil && q.readModels.CustomerHistory.holdsWholeHistoryOf(*criteria.CustomerId):
+		fetchMany = q.readModels.CustomerHistory.fetchMany
+	default:
+		return q.repository.FetchMany(ctx, criteria)
+	}
+
+	orders, err := fetchMany(criteria, nil)
+	if !errors.Is(err, ErrUnknownCursor) {
+		return orders, err
+	}
+
+	// the cursor order was collected or cancelled between the pages, its sort value is still in the repository
+	cursorOrder, err := q.repository.FetchById(ctx, *criteria.After)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUnknownCursor
+	}
+	if err != nil {
+		return nil, err
+	}
+	return fetchMany(criteria, cursorOrder)
+}
+
+func (q *readModelQueries) FetchByOrderNumber(ctx context.Context, orderNumber int64) (*Order, error) {
+	return q.repository.FetchByOrderNumber(ctx, orderNumber)
+}
+
+func earliestCreated(byId map[string]*Order) *Order {
+	var earliest *Order
+	for _, order := range byId {
+		if earliest == nil || order.CreatedAt.Before(earliest.CreatedAt) ||
+			(order.CreatedAt.Equal(earliest.CreatedAt) && bytes.Compare(order.Id[:], earliest.Id[:]) < 0) {
+			earliest = order
+		}
+	}
+	return earliest
+}
+
+func ordersOf(byId map[string]*Order) []*Order {
+	orders := make([]*Order, 0, len(byId))
+	for _, order := range byId {
+		orders = append(orders, order)
+	}
+	return orders
+}
@@ -0,0 +1,276 @@
+package order
+
+import (
+	"context"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mc-burger-orders/kitchen/item"
+	"testing"
+	"time"
+)
+
+func TestOrderReadModels(t *testing.T) {
+	t.Run("should count active orders by status", shouldCountActiveOrdersByStatus)
+	t.Run("should remove collected order from active orders", shouldRemoveCollectedOrderFromActiveOrders)
+	t.Run("should ignore outdated order update", shouldIgnoreOutdatedOrderUpdate)
+	t.Run("should return customer history latest created first", shouldReturnCustomerHistoryLatestCreatedFirst)
+	t.Run("should keep latest created orders of customer in history", shouldKeepLatestCreatedOrdersOfCustomerInHistory)
+	t.Run("should sum items missing on orders", shouldSumItemsMissingOnOrders)
+	t.Run("should restore read models from their state", shouldRestoreReadModelsFromTheirState)
+	t.Run("should answer queries from read models", shouldAnswerQueriesFromReadModels)
+	t.Run("should page after cursor order collected between pages", shouldPageAfterCursorOrderCollectedBetweenPages)
+	t.Run("should reject cursor of unknown order", shouldRejectCursorOfUnknownOrder)
+}
+
+func shouldCountActiveOrdersByStatus(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+
+	// when
+	givenAppliedOrders(t, readModels,
+		givenReadModelOrder(1, Requested, 1),
+		givenReadModelOrder(2, InProgress, 1),
+		givenReadModelOrder(3, InProgress, 1),
+	)
+
+	// then
+	assert.Equal(t, map[OrderStatus]int{Requested: 1, InProgress: 2, Ready: 0}, readModels.ActiveOrders.CountByStatus())
+}
+
+func shouldRemoveCollectedOrderFromActiveOrders(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	order := givenReadModelOrder(1, Ready, 3)
+	givenAppliedOrders(t, readModels, order)
+
+	// when
+	collected := *order
+	collected.Status = Collected
+	collected.Version = 4
+	givenAppliedOrders(t, readModels, &collected)
+
+	// then
+	orders, err := readModels.ActiveOrders.FetchMany(context.Background(), OrderCriteria{})
+	assert.NoError(t, err)
+	assert.Empty(t, orders)
+
+	// and
+	history := readModels.CustomerHistory.History(collected.CustomerId)
+	assert.Len(t, history, 1)
+	assert.Equal(t, Collected, history[0].Status)
+}
+
+func shouldIgnoreOutdatedOrderUpdate(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	order := givenReadModelOrder(1, InProgress, 3)
+	givenAppliedOrders(t, readModels, order)
+
+	// when
+	outdated := *order
+	outdated.Status = Requested
+	outdated.Version = 2
+	givenAppliedOrders(t, readModels, &outdated)
+
+	// then
+	orders, err := readModels.ActiveOrders.FetchMany(context.Background(), OrderCriteria{})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, InProgress, orders[0].Status)
+	assert.Equal(t, int64(3), orders[0].Version)
+}
+
+func shouldReturnCustomerHistoryLatestCreatedFirst(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	first := givenReadModelOrder(1, Collected, 4)
+	second := givenReadModelOrder(2, Requested, 1)
+	second.CreatedAt = first.CreatedAt.Add(time.Minute)
+	otherCustomer := givenReadModelOrder(3, Requested, 1)
+	otherCustomer.CustomerId = 2
+
+	// when
+	givenAppliedOrders(t, readModels, first, second, otherCustomer)
+
+	// then
+	history := readModels.CustomerHistory.History(first.CustomerId)
+	assert.Equal(t, []int64{2, 1}, orderNumbersOf(history))
+
+	// and
+	_, err := readModels.CustomerHistory.FetchMany(context.Background(), OrderCriteria{})
+	assert.Error(t, err)
+}
+
+func shouldKeepLatestCreatedOrdersOfCustomerInHistory(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	createdAt := time.Now().Truncate(time.Millisecond).UTC()
+	orders := make([]*Order, 0)
+	for orderNumber := int64(1); orderNumber <= customerHistoryLength+2; orderNumber++ {
+		order := givenReadModelOrder(orderNumber, Collected, 4)
+		order.CreatedAt = createdAt.Add(time.Duration(orderNumber) * time.Second)
+		orders = append(orders, order)
+	}
+	repository := GivenInMemoryRepository(*orders[0], *orders[1])
+	sut := &readModelQueries{readModels: readModels, repository: repository}
+	customerId := 1
+
+	// when
+	givenAppliedOrders(t, readModels, orders...)
+
+	// then
+	history := readModels.CustomerHistory.History(customerId)
+	assert.Len(t, history, customerHistoryLength)
+	assert.Equal(t, int64(customerHistoryLength+2), history[0].OrderNumber)
+	assert.Equal(t, int64(3), history[len(history)-1].OrderNumber)
+
+	// and
+	fromRepository, err := sut.FetchMany(context.Background(), OrderCriteria{CustomerId: &customerId})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, orderNumbersOf(fromRepository))
+}
+
+func shouldSumItemsMissingOnOrders(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	first := givenReadModelOrder(1, InProgress, 2)
+	first.PackedItems = []item.Item{{Name: "hamburger", Quantity: 1}}
+	second := givenReadModelOrder(2, Requested, 1)
+	ready := givenReadModelOrder(3, Ready, 2)
+
+	// when
+	givenAppliedOrders(t, readModels, first, second, ready)
+
+	// then
+	assert.Equal(t, map[string]int{"hamburger": 3, "fries": 2}, readModels.ItemBacklog.Backlog())
+}
+
+func shouldRestoreReadModelsFromTheirState(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	givenAppliedOrders(t, readModels, givenReadModelOrder(1, Requested, 1), givenReadModelOrder(2, Collected, 4))
+
+	restored := NewOrderReadModels()
+	for index, readModel := range readModels.All() {
+		state, err := readModel.State()
+		assert.NoError(t, err)
+
+		// when
+		err = restored.All()[index].Restore(state)
+
+		// then
+		assert.NoError(t, err)
+	}
+
+	// and
+	assert.Equal(t, readModels.ActiveOrders.CountByStatus(), restored.ActiveOrders.CountByStatus())
+	assert.Equal(t, orderNumbersOf(readModels.CustomerHistory.History(1)), orderNumbersOf(restored.CustomerHistory.History(1)))
+	assert.Equal(t, readModels.ItemBacklog.Backlog(), restored.ItemBacklog.Backlog())
+
+	// and
+	restored.ActiveOrders.Reset()
+	assert.Equal(t, map[OrderStatus]int{Requested: 0, InProgress: 0, Ready: 0}, restored.ActiveOrders.CountByStatus())
+}
+
+func shouldAnswerQueriesFromReadModels(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	givenAppliedOrders(t, readModels, givenReadModelOrder(1, Requested, 1))
+	repository := GivenInMemoryRepository(*givenReadModelOrder(7, Collected, 4))
+	sut := &readModelQueries{readModels: readModels, repository: repository}
+	customerId := 1
+
+	// when
+	active, err := sut.FetchMany(context.Background(), OrderCriteria{Statuses: []OrderStatus{Requested, InProgress}})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, orderNumbersOf(active))
+
+	// and
+	history, err := sut.FetchMany(context.Background(), OrderCriteria{CustomerId: &customerId})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1}, orderNumbersOf(history))
+
+	// and
+	collected, err := sut.FetchMany(context.Background(), OrderCriteria{Statuses: []OrderStatus{Collected}})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{7}, orderNumbersOf(collected))
+}
+
+func shouldPageAfterCursorOrderCollectedBetweenPages(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	first, second, third := givenReadModelOrder(1, Ready, 1), givenReadModelOrder(2, Ready, 1), givenReadModelOrder(3, Ready, 1)
+	givenAppliedOrders(t, readModels, first, second, third)
+	sut := &readModelQueries{readModels: readModels, repository: GivenInMemoryRepository(*first, *second, *third)}
+	criteria := OrderCriteria{Statuses: []OrderStatus{Ready}, Limit: 2}
+
+	firstPage, err := sut.FetchMany(context.Background(), criteria)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 2}, orderNumbersOf(firstPage))
+
+	// and
+	collected := *second
+	collected.Status = Collected
+	collected.Version = 2
+	givenAppliedOrders(t, readModels, &collected)
+
+	// when
+	criteria.After = firstPage[1].Id
+	secondPage, err := sut.FetchMany(context.Background(), criteria)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, orderNumbersOf(secondPage))
+}
+
+func shouldRejectCursorOfUnknownOrder(t *testing.T) {
+	// given
+	readModels := NewOrderReadModels()
+	givenAppliedOrders(t, readModels, givenReadModelOrder(1, Ready, 1))
+	sut := &readModelQueries{readModels: readModels, repository: GivenInMemoryRepository()}
+	unknown := primitive.NewObjectID()
+
+	// when
+	_, err := sut.FetchMany(context.Background(), OrderCriteria{Statuses: []OrderStatus{Ready}, After: &unknown})
+
+	// then
+	assert.ErrorIs(t, err, ErrUnknownCursor)
+}
+
+func givenReadModelOrder(orderNumber int64, status OrderStatus, version int64) *Order {
+	id := primitive.NewObjectID()
+	return &Order{
+		Id:          &id,
+		OrderNumber: orderNumber,
+		CustomerId:  1,
+		Items:       []item.Item{{Name: "hamburger", Quantity: 2}, {Name: "fries", Quantity: 1}},
+		Status:      status,
+		Version:     version,
+		CreatedAt:   time.Now().Truncate(time.Millisecond).UTC(),
+	}
+}
+
+// givenAppliedOrders folds every message announcing the orders, status updates are ignored by the read models.
+func givenAppliedOrders(t *testing.T, readModels *OrderReadModels, orders ...*Order) {
+	for _, order := range orders {
+		for _, message := range givenOrderMessages(t, order) {
+			for _, readModel := range readModels.All() {
+				assert.NoError(t, readModel.Apply(context.Background(), message))
+			}
+		}
+	}
+}
+
+func givenOrderMessages(t *testing.T, order *Order) []kafka.Message {
+	messages, err := contractOrderEvents().Messages(context.Background(), "", *order)
+	assert.NoError(t, err)
+
+	kafkaMessages := make([]kafka.Message, 0, len(messages))
+	for _, message := range messages {
+		kafkaMessages = append(kafkaMessages, message.KafkaMessage())
+	}
+	return kafkaMessages
+}
@@ -2,12 +2,15 @@ package order
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/eventstore"
 	"mc-burger-orders/log"
+	"mc-burger-orders/outbox"
 	"time"
 )
 
@@ -24,7 +27,7 @@ type FetchByOrderNumberRepository interface {
 }
 
 type FetchManyRepository interface {
-	FetchMany(ctx context.Context) ([]*Order, error)
+	FetchMany(ctx context.Context, criteria OrderCriteria) ([]*Order, error)
 }
 
 type StoreRepository interface {
@@ -41,52 +44,167 @@ type OrderRepository interface {
 
 type PackingOrderItemsRepository interface {
 	StoreRepository
+	FetchByIdRepository
 	FetchByMissingItemsOrderByOrderNumber
 }
 
 type OrderRepositoryImpl struct {
-	c *mongo.Collection
-	s OrderStreamService
+	c      *mongo.Collection
+	outbox outbox.AddRepository
+	events *OrderEvents
+	stream *OrderStream
 }
 
-func NewRepository(database *mongo.Database, streamService OrderStreamService) *OrderRepositoryImpl {
+func NewRepository(database *mongo.Database, events *OrderEvents) *OrderRepositoryImpl {
 	collection := database.Collection("orders")
-	return &OrderRepositoryImpl{c: collection, s: streamService}
+	createOrderNumberIndex(collection)
+	stream := NewOrderStream(eventstore.NewRepository(database))
+	return &OrderRepositoryImpl{c: collection, outbox: outbox.NewRepository(database), events: events, stream: stream}
+}
+
+// createOrderNumberIndex makes order numbers unique among active orders, numbers of collected or cancelled
+// orders can be reused once the order number sequence is reset.
+func createOrderNumberIndex(c *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := mongo.IndexModel{
+		Keys: bson.D{{Key: "orderNumber", Value: 1}},
+		Options: options.Index().
+			SetName("unique-active-order-number").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.D{{
+				Key:   "status",
+				Value: bson.D{{Key: "$in", Value: bson.A{Requested, InProgress, Ready}}},
+			}}),
+	}
+	if _, err := c.Indexes().CreateOne(ctx, indexModel); err != nil {
+		log.Error.Println("Error when creating unique order number index", err)
+	}
 }
 
+type VersionConflictError struct {
+	OrderNumber int64
+	Version     int64
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("order %d was modified concurrently, version %d is no longer current", e.OrderNumber, e.Version)
+}
+
+// InsertOrUpdate records the changes of the order and stores its projection together with its events in the outbox
+// within one transaction, so an event is published if and only if the order change it announces was stored.
 func (r *OrderRepositoryImpl) InsertOrUpdate(ctx context.Context, order *Order) (*Order, error) {
 	order.ModifiedAt = time.Now()
 	log.Info.Printf("Updating existing Order Number: %v", order.OrderNumber)
-	filterDef := bson.D{{Key: "orderNumber", Value: order.OrderNumber}}
-	updateDef := bson.D{{Key: "$set", Value: order}}
-	upsertOption := true
-	updateOptions := &options.UpdateOptions{
-		Upsert: &upsertOption,
-	}
 
-	result, err := r.c.UpdateOne(ctx, filterDef, updateDef, updateOptions)
+	stored, err := r.storeInTransaction(ctx, *order)
 	if err != nil {
+		identityDef := orderIdentity(order)
+		if mongo.IsDuplicateKeyError(err) && r.exists(ctx, identityDef) {
+			log.Warning.Printf("Order %d was modified concurrently, version %d is outdated", order.OrderNumber, order.Version)
+			return nil, &VersionConflictError{OrderNumber: order.OrderNumber, Version: order.Version}
+		}
 		log.Error.Println("Error when updating order in db", err)
 		return nil, err
 	}
-	if order.Id == nil && result.UpsertedID == nil {
-		err = fmt.Errorf("missing order pk id for the document")
+
+	order.Version = stored.Version
+	return stored, nil
+}
+
+func (r *OrderRepositoryImpl) storeInTransaction(ctx context.Context, order Order) (*Order, error) {
+	session, err := r.c.Database().Client().StartSession()
+	if err != nil {
 		return nil, err
 	}
-	r.s.EmitUpdatedEvent(*order)
+	defer session.EndSession(ctx)
 
-	var orderId primitive.ObjectID
-	switch {
-	case result.UpsertedID != nil:
-		orderId = result.UpsertedID.(primitive.ObjectID)
-	case order.Id != nil:
-		orderId = *order.Id
-	default:
-		err = fmt.Errorf("cannot determin order pk id")
+	stored, err := session.WithTransaction(ctx, func(sessionCtx mongo.SessionContext) (interface{}, error) {
+		return r.store(sessionCtx, order)
+	})
+	if err != nil {
 		return nil, err
 	}
+	return stored.(*Order), nil
+}
 
-	return r.FetchById(ctx, orderId)
+// store records the changes of the order in its stream when the order was not modified since it was read, then
+// replaces the order in the orders collection, which is the projection of the order streams, and adds its events
+// to the outbox.
+func (r *OrderRepositoryImpl) store(ctx context.Context, order Order) (*Order, error) {
+	previous, err := r.previousState(ctx, &order)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := r.stream.Record(ctx, previous, order)
+	if err != nil {
+		return nil, err
+	}
+
+	filterDef := bson.D{{Key: "_id", Value: *stored.Id}}
+	if _, err = r.c.ReplaceOne(ctx, filterDef, stored, options.Replace().SetUpsert(true)); err != nil {
+		return nil, err
+	}
+
+	var previousStatus OrderStatus
+	if previous != nil {
+		previousStatus = previous.Status
+	}
+	messages, err := r.events.Messages(ctx, previousStatus, *stored)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.outbox.Add(ctx, messages...); err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+// previousState folds the stream of the order, an order identified by its number is found in the projection first.
+// Orders stored before their changes were recorded start their stream from their projection.
+func (r *OrderRepositoryImpl) previousState(ctx context.Context, order *Order) (*Order, error) {
+	filterDef := bson.D{orderIdentity(order)}
+	if order.Id == nil {
+		filterDef = append(filterDef, bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{Requested, InProgress, Ready}}}})
+	}
+
+	projected := Order{}
+	err := r.c.FindOne(ctx, filterDef).Decode(&projected)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		if order.Id == nil {
+			id := primitive.NewObjectID()
+			order.Id = &id
+		}
+		return r.stream.Load(ctx, *order.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	order.Id = projected.Id
+	previous, err := r.stream.Load(ctx, *projected.Id)
+	if err != nil || previous != nil || projected.Version == 0 {
+		return previous, err
+	}
+	return &projected, r.stream.Adopt(ctx, projected)
+}
+
+func orderIdentity(order *Order) bson.E {
+	if order.Id != nil {
+		return bson.E{Key: "_id", Value: *order.Id}
+	}
+	return bson.E{Key: "orderNumber", Value: order.OrderNumber}
+}
+
+func (r *OrderRepositoryImpl) exists(ctx context.Context, identityDef bson.E) bool {
+	filterDef := bson.D{identityDef}
+	if identityDef.Key == "orderNumber" {
+		filterDef = append(filterDef, bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: bson.A{Requested, InProgress, Ready}}}})
+	}
+	count, err := r.c.CountDocuments(ctx, filterDef, options.Count().SetLimit(1))
+	return err == nil && count > 0
 }
 
 func (r *OrderRepositoryImpl) FetchById(ctx context.Context, id interface{}) (*Order, error) {
@@ -108,7 +226,8 @@ func (r *OrderRepositoryImpl) FetchById(ctx context.Context, id interface{}) (*O
 
 func (r *OrderRepositoryImpl) FetchByOrderNumber(ctx context.Context, orderNumber int64) (*Order, error) {
 	filter := bson.D{{Key: "orderNumber", Value: orderNumber}}
-	result := r.c.FindOne(ctx, filter)
+	findOptions := options.FindOne().SetSort(bson.D{{Key: "createdAt", Value: -1}})
+	result := r.c.FindOne(ctx, filter, findOptions)
 	if result.Err() != nil {
 		log.Error.Println("Error when fetching order by orderNumber", orderNumber, result.Err())
 		return nil, result.Err()
@@ -123,21 +242,20 @@ func (r *OrderRepositoryImpl) FetchByOrderNumber(ctx context.Context, orderNumbe
 	return order, nil
 }
 
-func (r *OrderRepositoryImpl) FetchMany(ctx context.Context) ([]*Order, error) {
-	filterDef := bson.D{
-		{
-			Key: "status",
-			Value: bson.D{{
-				Key:   "$in",
-				Value: bson.A{Requested, InProgress, Ready},
-			}},
-		},
+func (r *OrderRepositoryImpl) FetchMany(ctx context.Context, criteria OrderCriteria) ([]*Order, error) {
+	filterDef, err := r.criteriaFilter(ctx, criteria)
+	if err != nil {
+		return nil, err
 	}
+
+	direction := 1
+	if criteria.Descending {
+		direction = -1
+	}
+	sortDef := bson.D{{Key: criteria.SortBy, Value: direction}, {Key: "_id", Value: direction}}
 	findOptions := &options.FindOptions{
-		Sort: bson.D{{
-			Key:   "orderNumber",
-			Value: 1,
-		}},
+		Sort:  sortDef,
+		Limit: &criteria.Limit,
 	}
 	cursor, err := r.c.Find(ctx, filterDef, findOptions)
 	if err != nil {
@@ -145,7 +263,7 @@ func (r *OrderRepositoryImpl) FetchMany(ctx context.Context) ([]*Order, error) {
 		return nil, err
 	}
 
-	var orders []*Order
+	orders := make([]*Order, 0)
 	if err = cursor.All(ctx, &orders); err != nil {
 		log.Error.Println("Error reading cursor data", err)
 		return nil, err
@@ -154,6 +272,62 @@ func (r *OrderRepositoryImpl) FetchMany(ctx context.Context) ([]*Order, error) {
 	return orders, nil
 }
 
+func (r *OrderRepositoryImpl) criteriaFilter(ctx context.Context, criteria OrderCriteria) (bson.D, error) {
+	filterDef := bson.D{}
+	if len(criteria.Statuses) > 0 {
+		filterDef = append(filterDef, bson.E{Key: "status", Value: bson.D{{Key: "$in", Value: criteria.Statuses}}})
+	}
+	if criteria.CustomerId != nil {
+		filterDef = append(filterDef, bson.E{Key: "customerId", Value: *criteria.CustomerId})
+	}
+	if rangeDef := timeRange(criteria.CreatedFrom, criteria.CreatedTo); len(rangeDef) > 0 {
+		filterDef = append(filterDef, bson.E{Key: "createdAt", Value: rangeDef})
+	}
+	if rangeDef := timeRange(criteria.ModifiedFrom, criteria.ModifiedTo); len(rangeDef) > 0 {
+		filterDef = append(filterDef, bson.E{Key: "modifiedAt", Value: rangeDef})
+	}
+	if criteria.After == nil {
+		return filterDef, nil
+	}
+
+	operator := "$gt"
+	if criteria.Descending {
+		operator = "$lt"
+	}
+
+	// keyset pagination, orders sharing the sort value of the cursor are ordered by id as order numbers are reused
+	cursorOrder := bson.M{}
+	findOneOptions := options.FindOne().SetProjection(bson.D{{Key: criteria.SortBy, Value: 1}})
+	err := r.c.FindOne(ctx, bson.D{{Key: "_id", Value: *criteria.After}}, findOneOptions).Decode(&cursorOrder)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrUnknownCursor
+	}
+	if err != nil {
+		log.Error.Println("Error when fetching cursor order from db", err)
+		return nil, err
+	}
+
+	cursorValue := cursorOrder[criteria.SortBy]
+	return append(filterDef, bson.E{Key: "$or", Value: bson.A{
+		bson.D{{Key: criteria.SortBy, Value: bson.D{{Key: operator, Value: cursorValue}}}},
+		bson.D{
+			{Key: criteria.SortBy, Value: cursorValue},
+			{Key: "_id", Value: bson.D{{Key: operator, Value: *criteria.After}}},
+		},
+	}}), nil
+}
+
+func timeRange(from *time.Time, to *time.Time) bson.D {
+	rangeDef := bson.D{}
+	if from != nil {
+		rangeDef = append(rangeDef, bson.E{Key: "$gte", Value: *from})
+	}
+	if to != nil {
+		rangeDef = append(rangeDef, bson.E{Key: "$lte", Value: *to})
+	}
+	return rangeDef
+}
+
 func (r *OrderRepositoryImpl) FetchByMissingItem(ctx context.Context, itemName string) ([]*Order, error) {
 	filterDef := bson.D{
 		{
@@ -0,0 +1,374 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mc-burger-orders/event"
+	"mc-burger-orders/kitchen/item"
+	"mc-burger-orders/outbox"
+	"mc-burger-orders/testing/utils"
+	"testing"
+	"time"
+)
+
+func TestInMemoryRepository_Contract(t *testing.T) {
+	runOrderRepositoryContract(t, func(t *testing.T) OrderRepository {
+		return NewInMemoryRepository(contractOrderEvents(), outbox.GivenRepository())
+	})
+}
+
+func TestIntegrationOrderRepository_Contract(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+
+	mongoContainer, database = utils.TestWithMongo(t, ctx)
+	collectionDb = database.Collection("orders")
+
+	runOrderRepositoryContract(t, func(t *testing.T) OrderRepository {
+		utils.DeleteMany(t, collectionDb, bson.D{})
+		return NewRepository(database, contractOrderEvents())
+	})
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+// runOrderRepositoryContract runs the cases every OrderRepository has to pass, newRepository returns an empty repository.
+func runOrderRepositoryContract(t *testing.T, newRepository func(t *testing.T) OrderRepository) {
+	t.Run("should store new order with first version", func(t *testing.T) {
+		shouldStoreNewOrderWithFirstVersion(t, newRepository(t))
+	})
+	t.Run("should update order when its version is current", func(t *testing.T) {
+		shouldUpdateOrderWhenItsVersionIsCurrent(t, newRepository(t))
+	})
+	t.Run("should reject update of outdated order version", func(t *testing.T) {
+		shouldRejectUpdateOfOutdatedOrderVersion(t, newRepository(t))
+	})
+	t.Run("should update order by its number when it has no id", func(t *testing.T) {
+		shouldUpdateOrderByItsNumberWhenItHasNoId(t, newRepository(t))
+	})
+	t.Run("should reject second active order with the same number", func(t *testing.T) {
+		shouldRejectSecondActiveOrderWithTheSameNumber(t, newRepository(t))
+	})
+	t.Run("should return latest created order of number", func(t *testing.T) {
+		shouldReturnLatestCreatedOrderOfNumber(t, newRepository(t))
+	})
+	t.Run("should return no documents error when order does not exist", func(t *testing.T) {
+		shouldReturnNoDocumentsErrorWhenOrderDoesNotExist(t, newRepository(t))
+	})
+	t.Run("should return orders missing item ordered by number", func(t *testing.T) {
+		shouldReturnOrdersMissingItemOrderedByNumber(t, newRepository(t))
+	})
+	t.Run("should return page of orders matching criteria", func(t *testing.T) {
+		shouldReturnPageOfOrdersMatchingCriteria(t, newRepository(t))
+	})
+	t.Run("should order orders sharing sort value by id", func(t *testing.T) {
+		shouldOrderOrdersSharingSortValueById(t, newRepository(t))
+	})
+	t.Run("should page through orders reusing the same number", func(t *testing.T) {
+		shouldPageThroughOrdersReusingTheSameNumber(t, newRepository(t))
+	})
+	t.Run("should reject unknown cursor", func(t *testing.T) {
+		shouldRejectUnknownCursor(t, newRepository(t))
+	})
+}
+
+func shouldStoreNewOrderWithFirstVersion(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	newOrder := &Order{OrderNumber: 1000, CustomerId: 1, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested, CreatedAt: time.Now()}
+
+	// when
+	stored, err := sut.InsertOrUpdate(ctx, newOrder)
+
+	// then
+	assert.NoError(t, err)
+	assert.NotNil(t, stored.Id)
+	assert.Equal(t, int64(1), stored.Version)
+	assert.Equal(t, int64(1), newOrder.Version)
+	assert.WithinDuration(t, time.Now(), stored.ModifiedAt, 5*time.Second)
+
+	// and
+	byId, err := sut.FetchById(ctx, *stored.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, byId)
+
+	byNumber, err := sut.FetchByOrderNumber(ctx, 1000)
+	assert.NoError(t, err)
+	assert.Equal(t, stored, byNumber)
+}
+
+func shouldUpdateOrderWhenItsVersionIsCurrent(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	stored := givenStoredOrder(t, sut, Order{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested})
+	firstModifiedAt := stored.ModifiedAt
+
+	// when
+	stored.Status = InProgress
+	stored.PackedItems = []item.Item{{Name: "hamburger", Quantity: 1}}
+	updated, err := sut.InsertOrUpdate(ctx, stored)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), updated.Version, "versions of ItemPacked and StatusChanged events")
+	assert.Equal(t, stored.Id, updated.Id)
+	assert.False(t, updated.ModifiedAt.Before(firstModifiedAt))
+
+	// and
+	fetched, err := sut.FetchById(ctx, *stored.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, InProgress, fetched.Status)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 1}}, fetched.PackedItems)
+}
+
+func shouldRejectUpdateOfOutdatedOrderVersion(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	stored := givenStoredOrder(t, sut, Order{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested})
+	outdated := *stored
+
+	stored.Status = InProgress
+	_, err := sut.InsertOrUpdate(ctx, stored)
+	assert.NoError(t, err)
+
+	// when
+	outdated.Status = Cancelled
+	_, err = sut.InsertOrUpdate(ctx, &outdated)
+
+	// then
+	var conflictErr *VersionConflictError
+	assert.True(t, errors.As(err, &conflictErr))
+	assert.Equal(t, int64(1), conflictErr.Version)
+
+	// and
+	fetched, err := sut.FetchById(ctx, *stored.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, InProgress, fetched.Status)
+	assert.Equal(t, int64(2), fetched.Version)
+}
+
+func shouldUpdateOrderByItsNumberWhenItHasNoId(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	stored := givenStoredOrder(t, sut, Order{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested})
+
+	// when
+	updated, err := sut.InsertOrUpdate(ctx, &Order{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 2}}, Status: InProgress, Version: 1})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, stored.Id, updated.Id)
+	assert.Equal(t, int64(3), updated.Version, "versions of ItemsAmended and StatusChanged events")
+
+	// and
+	orders, err := sut.FetchMany(ctx, OrderCriteria{SortBy: "orderNumber"})
+	assert.NoError(t, err)
+	assert.Len(t, orders, 1)
+	assert.Equal(t, []item.Item{{Name: "hamburger", Quantity: 2}}, orders[0].Items)
+}
+
+func shouldRejectSecondActiveOrderWithTheSameNumber(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	givenStoredOrder(t, sut, Order{OrderNumber: 1000, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested})
+	id := primitive.NewObjectID()
+
+	// when
+	_, err := sut.InsertOrUpdate(ctx, &Order{Id: &id, OrderNumber: 1000, Items: []item.Item{{Name: "fries", Quantity: 1}}, Status: Requested})
+
+	// then
+	var conflictErr *VersionConflictError
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &conflictErr))
+
+	// and
+	_, err = sut.FetchById(ctx, id)
+	assert.ErrorIs(t, err, mongo.ErrNoDocuments)
+}
+
+func shouldReturnLatestCreatedOrderOfNumber(t *testing.T, sut OrderRepository) {
+	// given
+	currentTime := time.Now()
+	givenStoredOrder(t, sut, Order{OrderNumber: 1000, CustomerId: 1, Status: Collected, CreatedAt: currentTime.Add(-time.Hour)})
+	latest := givenStoredOrder(t, sut, Order{OrderNumber: 1000, CustomerId: 2, Status: Requested, CreatedAt: currentTime})
+
+	// when
+	fetched, err := sut.FetchByOrderNumber(context.Background(), 1000)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, latest.Id, fetched.Id)
+	assert.Equal(t, 2, fetched.CustomerId)
+}
+
+func shouldReturnNoDocumentsErrorWhenOrderDoesNotExist(t *testing.T, sut OrderRepository) {
+	// when
+	byId, byIdErr := sut.FetchById(context.Background(), primitive.NewObjectID())
+	byNumber, byNumberErr := sut.FetchByOrderNumber(context.Background(), 404)
+
+	// then
+	assert.Nil(t, byId)
+	assert.ErrorIs(t, byIdErr, mongo.ErrNoDocuments)
+	assert.Nil(t, byNumber)
+	assert.ErrorIs(t, byNumberErr, mongo.ErrNoDocuments)
+}
+
+func shouldReturnOrdersMissingItemOrderedByNumber(t *testing.T, sut OrderRepository) {
+	// given
+	givenStoredOrder(t, sut, Order{OrderNumber: 1005, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: InProgress})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1001, Items: []item.Item{{Name: "hamburger", Quantity: 2}}, PackedItems: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Requested})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1002, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, PackedItems: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: InProgress})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1003, Items: []item.Item{{Name: "hamburger", Quantity: 1}}, Status: Ready})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1004, Items: []item.Item{{Name: "fries", Quantity: 1}}, Status: Requested})
+
+	// when
+	orders, err := sut.FetchByMissingItem(context.Background(), "hamburger")
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1001, 1005}, orderNumbersOf(orders))
+}
+
+func shouldReturnPageOfOrdersMatchingCriteria(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	currentTime := time.Now()
+	givenStoredOrder(t, sut, Order{OrderNumber: 1001, CustomerId: 1, Status: Requested, CreatedAt: currentTime.Add(-1 * time.Minute)})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1002, CustomerId: 1, Status: InProgress, CreatedAt: currentTime.Add(-3 * time.Minute)})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1003, CustomerId: 1, Status: Ready, CreatedAt: currentTime.Add(-2 * time.Minute)})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1004, CustomerId: 2, Status: Requested, CreatedAt: currentTime.Add(-4 * time.Minute)})
+	givenStoredOrder(t, sut, Order{OrderNumber: 1005, CustomerId: 1, Status: Collected, CreatedAt: currentTime})
+	customerId := 1
+	criteria := OrderCriteria{
+		Statuses:   []OrderStatus{Requested, InProgress, Ready},
+		CustomerId: &customerId,
+		SortBy:     "createdAt",
+		Descending: true,
+		Limit:      2,
+	}
+
+	// when
+	firstPage, err := sut.FetchMany(ctx, criteria)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1001, 1003}, orderNumbersOf(firstPage))
+
+	// and
+	criteria.After = firstPage[len(firstPage)-1].Id
+	secondPage, err := sut.FetchMany(ctx, criteria)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1002}, orderNumbersOf(secondPage))
+
+	// and
+	createdTo := currentTime.Add(-150 * time.Second)
+	modifiedTo := time.Now().Add(-time.Minute)
+	olderOrders, err := sut.FetchMany(ctx, OrderCriteria{CreatedTo: &createdTo, SortBy: "orderNumber"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1002, 1004}, orderNumbersOf(olderOrders))
+	notModifiedOrders, err := sut.FetchMany(ctx, OrderCriteria{ModifiedTo: &modifiedTo, SortBy: "orderNumber"})
+	assert.NoError(t, err)
+	assert.Empty(t, notModifiedOrders)
+}
+
+func shouldOrderOrdersSharingSortValueById(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	createdAt := time.Now().Add(-time.Minute)
+	stored := make(map[int64]*Order)
+	for _, orderNumber := range []int64{1003, 1001, 1004, 1002} {
+		stored[orderNumber] = givenStoredOrder(t, sut, Order{OrderNumber: orderNumber, Status: Requested, CreatedAt: createdAt})
+	}
+	after := stored[1001].Id
+
+	// when
+	orders, err := sut.FetchMany(ctx, OrderCriteria{SortBy: "createdAt", After: after, Limit: 10})
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1004, 1002}, orderNumbersOf(orders))
+
+	// and
+	descending, err := sut.FetchMany(ctx, OrderCriteria{SortBy: "createdAt", Descending: true, After: after, Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1003}, orderNumbersOf(descending))
+}
+
+func shouldPageThroughOrdersReusingTheSameNumber(t *testing.T, sut OrderRepository) {
+	// given
+	ctx := context.Background()
+	currentTime := time.Now()
+	collected := givenStoredOrder(t, sut, Order{OrderNumber: 1001, Status: Collected, CreatedAt: currentTime.Add(-3 * time.Minute)})
+	other := givenStoredOrder(t, sut, Order{OrderNumber: 1002, Status: Requested, CreatedAt: currentTime.Add(-2 * time.Minute)})
+	reused := givenStoredOrder(t, sut, Order{OrderNumber: 1001, Status: Requested, CreatedAt: currentTime.Add(-1 * time.Minute)})
+
+	for _, sortBy := range []string{"createdAt", "orderNumber"} {
+		// when
+		pages := make([]*Order, 0)
+		criteria := OrderCriteria{SortBy: sortBy, Limit: 1}
+		for page := 0; page < 4; page++ {
+			orders, err := sut.FetchMany(ctx, criteria)
+			assert.NoError(t, err)
+			if len(orders) == 0 {
+				break
+			}
+			pages = append(pages, orders...)
+			criteria.After = orders[0].Id
+		}
+
+		// then
+		expected := []*primitive.ObjectID{collected.Id, other.Id, reused.Id}
+		if sortBy == "orderNumber" {
+			expected = []*primitive.ObjectID{collected.Id, reused.Id, other.Id}
+		}
+		assert.Equal(t, expected, idsOf(pages), "sorted by %v", sortBy)
+	}
+}
+
+func shouldRejectUnknownCursor(t *testing.T, sut OrderRepository) {
+	// given
+	givenStoredOrder(t, sut, Order{OrderNumber: 1000, Status: Requested, CreatedAt: time.Now()})
+	after := primitive.NewObjectID()
+
+	// when
+	orders, err := sut.FetchMany(context.Background(), OrderCriteria{SortBy: "createdAt", After: &after, Limit: 10})
+
+	// then
+	assert.ErrorIs(t, err, ErrUnknownCursor)
+	assert.Nil(t, orders)
+}
+
+func contractOrderEvents() *OrderEvents {
+	return NewOrderEvents(event.TestTopicConfigs("order-stream"), event.TestTopicConfigs("order-status"))
+}
+
+func givenStoredOrder(t *testing.T, repository OrderRepository, order Order) *Order {
+	stored, err := repository.InsertOrUpdate(context.Background(), &order)
+	if err != nil {
+		assert.Failf(t, "failed to store order before the test", err.Error())
+	}
+	return stored
+}
+
+func idsOf(orders []*Order) []*primitive.ObjectID {
+	ids := make([]*primitive.ObjectID, 0)
+	for _, order := range orders {
+		ids = append(ids, order.Id)
+	}
+	return ids
+}
+
+func orderNumbersOf(orders []*Order) []int64 {
+	orderNumbers := make([]int64, 0)
+	for _, order := range orders {
+		orderNumbers = append(orderNumbers, order.OrderNumber)
+	}
+	return orderNumbers
+}
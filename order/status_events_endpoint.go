@@ -2,7 +2,6 @@ package order
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/segmentio/kafka-go"
@@ -11,7 +10,6 @@ import (
 	"mc-burger-orders/event"
 	"mc-burger-orders/log"
 	"mc-burger-orders/middleware"
-	utils2 "mc-burger-orders/utils"
 	"time"
 )
 
@@ -26,8 +24,8 @@ type StatusEventsEndpoints struct {
 	dispatcher   command.Dispatcher
 }
 
-func NewOrderStatusEventsEndpoints(database *mongo.Database, statusEmitterTopicConfigs *event.TopicConfigs, streamService OrderStreamService) middleware.EndpointsSetup {
-	repository := NewRepository(database, streamService)
+func NewOrderStatusEventsEndpoints(database *mongo.Database, statusEmitterTopicConfigs *event.TopicConfigs, orderEvents *OrderEvents) middleware.EndpointsSetup {
+	repository := NewRepository(database, orderEvents)
 	statusReader := event.NewTopicReader(statusEmitterTopicConfigs, nil)
 
 	return &StatusEventsEndpoints{
@@ -69,7 +67,7 @@ func (e *StatusEventsEndpoints) orderStatusHandler(c *gin.Context) {
 }
 
 func (e *StatusEventsEndpoints) validateMessage(ctx context.Context, message kafka.Message) (*StatusEventDto, error) {
-	orderNumber, err := utils2.GetOrderNumber(message)
+	orderNumber, err := event.GetOrderNumber(message)
 
 	if err != nil {
 		return nil, err
@@ -80,17 +78,16 @@ func (e *StatusEventsEndpoints) validateMessage(ctx context.Context, message kaf
 		err := fmt.Errorf("failed to find order by order number in payload: %v", orderNumber)
 		return nil, err
 	}
-	payloadBody := map[string]OrderStatus{}
-	if err := json.Unmarshal(message.Value, &payloadBody); err != nil {
-		log.Error.Printf("failed to unmarshal payload from message to map of strings. Reason: %v", err.Error())
+	payloadBody := StatusUpdatedPayload{}
+	if err := event.DecodePayload(ctx, message, &payloadBody); err != nil {
+		log.Error.Printf("failed to decode status payload from message. Reason: %v", err.Error())
 		return nil, err
 	}
 
-	status, exists := payloadBody["status"]
-	if !exists {
+	if len(payloadBody.Status) == 0 {
 		err := fmt.Errorf("failed to find status property in payload: +%v", payloadBody)
 		return nil, err
 	}
 
-	return &StatusEventDto{OrderNumber: orderNumber, Status: status}, err
+	return &StatusEventDto{OrderNumber: orderNumber, Status: payloadBody.Status}, err
 }
@@ -42,9 +42,8 @@ func shouldGetOrderUpdatesWhenOrderChanges(t *testing.T) {
 	}
 	utils.DeleteMany(t, collectionDb, bson.D{})
 	utils.InsertMany(t, collectionDb, expectedOrders)
-	orderStream := NewStubService()
 
-	endpoints := NewOrderStatusEventsEndpoints(database, orderStatusKafkaConfig, orderStream)
+	endpoints := NewOrderStatusEventsEndpoints(database, orderStatusKafkaConfig, testOrderEvents())
 	engine := utils.SetUpRouter(endpoints.Setup)
 	go runEngine(engine, t)
 
@@ -11,6 +11,7 @@ type StubRepository struct {
 	fetchById          *Order
 	fetchByOrderNumber *Order
 	nextNumber         int64
+	versionConflicts   int
 	err                error
 	methodCalled       []map[string]interface{}
 }
@@ -41,6 +42,10 @@ func (s *StubRepository) ReturnNextNumber(nextNumber int64) {
 	s.nextNumber = nextNumber
 }
 
+func (s *StubRepository) ReturnVersionConflicts(times int) {
+	s.versionConflicts = times
+}
+
 func (s *StubRepository) ReturnError(error error) {
 	s.err = error
 }
@@ -48,6 +53,11 @@ func (s *StubRepository) ReturnError(error error) {
 func (s *StubRepository) InsertOrUpdate(ctx context.Context, order *Order) (*Order, error) {
 	s.methodCalled = append(s.methodCalled, map[string]interface{}{"InsertOrUpdate": *order})
 
+	if s.versionConflicts > 0 {
+		s.versionConflicts--
+		return nil, &VersionConflictError{OrderNumber: order.OrderNumber, Version: order.Version}
+	}
+
 	if s.insertOrUpdate != nil {
 		return s.insertOrUpdate, nil
 	}
@@ -58,7 +68,7 @@ func (s *StubRepository) FetchById(ctx context.Context, id interface{}) (*Order,
 	s.methodCalled = append(s.methodCalled, map[string]interface{}{"FetchById": id})
 
 	if s.fetchById != nil {
-		return s.fetchById, nil
+		return cloneOrder(s.fetchById), nil
 	}
 	return s.o[0], s.err
 }
@@ -66,13 +76,13 @@ func (s *StubRepository) FetchById(ctx context.Context, id interface{}) (*Order,
 func (s *StubRepository) FetchByOrderNumber(ctx context.Context, orderNumber int64) (*Order, error) {
 	s.methodCalled = append(s.methodCalled, map[string]interface{}{"FetchByOrderNumber": orderNumber})
 	if s.fetchByOrderNumber != nil {
-		return s.fetchByOrderNumber, nil
+		return cloneOrder(s.fetchByOrderNumber), nil
 	}
 	return s.o[0], s.err
 }
 
-func (s *StubRepository) FetchMany(ctx context.Context) ([]*Order, error) {
-	s.methodCalled = append(s.methodCalled, map[string]interface{}{"FetchMany": nil})
+func (s *StubRepository) FetchMany(ctx context.Context, criteria OrderCriteria) ([]*Order, error) {
+	s.methodCalled = append(s.methodCalled, map[string]interface{}{"FetchMany": criteria})
 	return s.o, s.err
 }
 
@@ -103,6 +113,26 @@ func (s *StubRepository) GetUpsertArgs() []Order {
 	return u
 }
 
+func (s *StubRepository) GetUpsertStatuses() []OrderStatus {
+	statuses := make([]OrderStatus, 0)
+	for _, o := range s.GetUpsertArgs() {
+		statuses = append(statuses, o.Status)
+	}
+	return statuses
+}
+
+func (s *StubRepository) GetFetchManyArgs() []OrderCriteria {
+	var c []OrderCriteria
+
+	for _, methodInvocation := range s.methodCalled {
+		if v, exists := methodInvocation["FetchMany"]; exists {
+			c = append(c, v.(OrderCriteria))
+		}
+	}
+
+	return c
+}
+
 func (s *StubRepository) CalledCnt() int {
 	return len(s.methodCalled)
 }
@@ -30,16 +30,6 @@ func RequestMatchingFnc(itemName string, quantity int) func(args map[string]any)
 	}
 }
 
-func StatusUpdateMatchingFnc(status OrderStatus) func(args map[string]any) bool {
-	return func(args map[string]any) bool {
-		if updatedStatus, exists := args["StatusUpdatedEvent"]; exists {
-			log.Printf("StubService methodCalled StatusUpdatedEvent() => %+v", updatedStatus)
-			return updatedStatus == status
-		}
-		return false
-	}
-}
-
 func (s *StubService) RequestNew(ctx context.Context, itemName string, quantity int) error {
 	args := map[string]interface{}{
 		"itemName": itemName,
@@ -52,49 +42,3 @@ func (s *StubService) RequestNew(ctx context.Context, itemName string, quantity
 	}
 	return nil
 }
-
-func (s *StubService) EmitStatusUpdatedEvent(o Order) {
-	orderCopy := &o
-	args := map[string]interface{}{
-		"StatusUpdatedEvent": orderCopy.Status,
-	}
-	s.MethodCalled = append(s.MethodCalled, args)
-	if s.wg != nil {
-		s.wg.Done()
-	}
-	log.Printf("StubService.EmitStatusUpdatedEvent() => %+v", args)
-}
-
-func (s *StubService) EmitUpdatedEvent(o Order) {
-	orderCopy := &o
-	args := map[string]interface{}{
-		"EmitUpdatedEvent": orderCopy,
-	}
-	s.MethodCalled = append(s.MethodCalled, args)
-	if s.wg != nil {
-		s.wg.Done()
-	}
-}
-
-func (s *StubService) GetStatusUpdatedEventArgs() []OrderStatus {
-	r := make([]OrderStatus, 0)
-
-	for _, o := range s.MethodCalled {
-		if value, eventExist := o["StatusUpdatedEvent"]; eventExist {
-			r = append(r, value.(OrderStatus))
-		}
-	}
-	return r
-}
-
-func (s *StubService) GetEmitUpdatedEventArgs() []*Order {
-	r := make([]*Order, 0)
-
-	for _, o := range s.MethodCalled {
-		if value, eventExist := o["EmitUpdatedEvent"]; eventExist {
-			order := value.(*Order)
-			r = append(r, order)
-		}
-	}
-	return r
-}
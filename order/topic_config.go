@@ -1,28 +1,21 @@
 package order
 
 import (
-	"github.com/spf13/cast"
 	"mc-burger-orders/event"
 	"mc-burger-orders/log"
 	"os"
 )
 
+// StatusUpdatedEndpointTopicConfigsFromEnv configures the reader streaming status updates to the status board. It
+// consumes the status topic in a consumer group of its own, next to the one handling status updates.
 func StatusUpdatedEndpointTopicConfigsFromEnv() *event.TopicConfigs {
-	partition := 1
-	partitionsVal := os.Getenv("KAFKA_TOPICS__ORDER_STATUS_ENDPOINT_PARTITION")
-	if len(partitionsVal) > 0 {
-		partition = cast.ToInt(partitionsVal)
-	}
-	return defaultStatusTopicConfig(partition)
+	configs := defaultStatusTopicConfig()
+	configs.ConsumerGroup = configs.ConsumerGroup + "-status-endpoint"
+	return configs
 }
 
 func StatusUpdatedTopicConfigsFromEnv() *event.TopicConfigs {
-	partition := 0
-	partitionsVal := os.Getenv("KAFKA_TOPICS__ORDER_STATUS_SERVICE_PARTITION")
-	if len(partitionsVal) > 0 {
-		partition = cast.ToInt(partitionsVal)
-	}
-	return defaultStatusTopicConfig(partition)
+	return defaultStatusTopicConfig()
 }
 
 func StreamTopicConfigsFromEnv() *event.TopicConfigs {
@@ -31,17 +24,12 @@ func StreamTopicConfigsFromEnv() *event.TopicConfigs {
 		log.Error.Panicf("Kafka Topic `order stream` name is missing")
 	}
 
-	partition := 1
-	partitionsVal := os.Getenv("KAFKA_TOPICS__ORDER_STREAM_PARTITION")
-	if len(partitionsVal) > 0 {
-		partition = cast.ToInt(partitionsVal)
-	}
 	numPartitionsVal := os.Getenv("KAFKA_TOPICS__ORDER_STREAM_NUMBER_OF_PARTITIONS")
 	replicationFactorVal := os.Getenv("KAFKA_TOPICS__ORDER_STREAM_REPLICA_FACTOR")
-	return event.NewTopicConfig(topic, partition, numPartitionsVal, replicationFactorVal)
+	return event.NewTopicConfig(topic, numPartitionsVal, replicationFactorVal)
 }
 
-func defaultStatusTopicConfig(partition int) *event.TopicConfigs {
+func defaultStatusTopicConfig() *event.TopicConfigs {
 	topic := os.Getenv("KAFKA_TOPICS__ORDER_STATUS_TOPIC_NAME")
 	if len(topic) <= 0 {
 		log.Error.Panicf("Kafka Topic `order status events handler` name is missing")
@@ -49,5 +37,5 @@ func defaultStatusTopicConfig(partition int) *event.TopicConfigs {
 
 	numPartitionsVal := os.Getenv("KAFKA_TOPICS__ORDER_STATUS_NUMBER_OF_PARTITIONS")
 	replicationFactorVal := os.Getenv("KAFKA_TOPICS__ORDER_STATUS_REPLICA_FACTOR")
-	return event.NewTopicConfig(topic, partition, numPartitionsVal, replicationFactorVal)
+	return event.NewTopicConfig(topic, numPartitionsVal, replicationFactorVal)
 }
@@ -0,0 +1,58 @@
+package outbox
+
+import (
+	"github.com/segmentio/kafka-go"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type Status string
+
+const (
+	Pending = Status("PENDING")
+	Sent    = Status("SENT")
+	// Dead messages are never sent, they are kept for inspection
+	Dead = Status("DEAD")
+)
+
+type Header struct {
+	Key   string `bson:"key"`
+	Value string `bson:"value"`
+}
+
+type Message struct {
+	Id        *primitive.ObjectID `bson:"_id,omitempty"`
+	Topic     string              `bson:"topic"`
+	Key       []byte              `bson:"key"`
+	Value     []byte              `bson:"value"`
+	Headers   []Header            `bson:"headers"`
+	Status    Status              `bson:"status"`
+	Attempts  int                 `bson:"attempts"`
+	LastError string              `bson:"lastError,omitempty"`
+	CreatedAt time.Time           `bson:"createdAt"`
+	SentAt    *time.Time          `bson:"sentAt,omitempty"`
+}
+
+func NewMessage(topic string, message kafka.Message) Message {
+	headers := make([]Header, 0)
+	for _, header := range message.Headers {
+		headers = append(headers, Header{Key: header.Key, Value: string(header.Value)})
+	}
+
+	return Message{
+		Topic:     topic,
+		Key:       message.Key,
+		Value:     message.Value,
+		Headers:   headers,
+		Status:    Pending,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (m Message) KafkaMessage() kafka.Message {
+	headers := make([]kafka.Header, 0)
+	for _, header := range m.Headers {
+		headers = append(headers, kafka.Header{Key: header.Key, Value: []byte(header.Value)})
+	}
+	return kafka.Message{Key: m.Key, Value: m.Value, Headers: headers}
+}
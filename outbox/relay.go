@@ -0,0 +1,125 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"mc-burger-orders/event"
+	"mc-burger-orders/log"
+	"os"
+	"time"
+)
+
+const (
+	defaultRelayInterval  = 500 * time.Millisecond
+	defaultRelayBatchSize = int64(100)
+	// defaultRelayLeaseTtl outlasts sending a message with all attempts of the writer
+	defaultRelayLeaseTtl = time.Minute
+	// maxUnknownTopicAttempts is the number of runs a message of a topic the relay has no writer of is tried in
+	maxUnknownTopicAttempts = 5
+)
+
+// Relay publishes pending outbox messages and marks them sent. Only the relay holding the lease publishes, so with
+// several instances of the service running, messages are still published by one relay at a time. Sending stops at
+// the first failing message and is retried on the next run, so messages are never published out of the order they
+// were stored in. Messages of an unknown topic are marked dead after maxUnknownTopicAttempts runs and skipped.
+type Relay struct {
+	repository Repository
+	writers    map[string]event.Writer
+	interval   time.Duration
+	batchSize  int64
+	owner      string
+	leaseTtl   time.Duration
+}
+
+func NewRelay(repository Repository, topics ...*event.TopicConfigs) *Relay {
+	writers := make(map[string]event.Writer)
+	for _, topic := range topics {
+		writers[topic.Topic] = event.NewTopicWriter(topic)
+	}
+	return NewRelayWithWriters(repository, writers)
+}
+
+func NewRelayWithWriters(repository Repository, writers map[string]event.Writer) *Relay {
+	return &Relay{
+		repository: repository,
+		writers:    writers,
+		interval:   defaultRelayInterval,
+		batchSize:  defaultRelayBatchSize,
+		owner:      relayOwner(),
+		leaseTtl:   defaultRelayLeaseTtl,
+	}
+}
+
+// relayOwner names the relay of the instance in the lease.
+func relayOwner() string {
+	instance, err := os.Hostname()
+	if err != nil {
+		instance = "unknown"
+	}
+	return fmt.Sprintf("%v-%v", instance, primitive.NewObjectID().Hex())
+}
+
+func (r *Relay) Run(ctx context.Context) {
+	log.Info.Println("Starting outbox relay")
+	for {
+		sent, err := r.RelayPending(ctx)
+		if err != nil {
+			log.Error.Println("Outbox relay failed to publish pending messages", err)
+		}
+
+		if sent < int(r.batchSize) || err != nil {
+			select {
+			case <-ctx.Done():
+				log.Warning.Println("Stopping outbox relay")
+				return
+			case <-time.After(r.interval):
+			}
+		}
+	}
+}
+
+// RelayPending publishes one batch of pending messages and returns how many of them were sent. The lease is renewed
+// before every message, nothing is published while another relay holds it.
+func (r *Relay) RelayPending(ctx context.Context) (int, error) {
+	messages, err := r.repository.FetchPending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	sent := 0
+	for _, message := range messages {
+		leased, err := r.repository.AcquireLease(ctx, r.owner, r.leaseTtl)
+		if err != nil || !leased {
+			return sent, err
+		}
+
+		writer, ok := r.writers[message.Topic]
+		if !ok {
+			err = fmt.Errorf("outbox message %v has unknown topic %v", message.Id.Hex(), message.Topic)
+			if message.Attempts+1 < maxUnknownTopicAttempts {
+				_ = r.repository.MarkFailed(ctx, *message.Id, err)
+				return sent, err
+			}
+
+			log.Error.Println("Outbox message is dead and skipped.", err)
+			if err = r.repository.MarkDead(ctx, *message.Id, err); err != nil {
+				return sent, err
+			}
+			continue
+		}
+
+		if err = writer.SendMessage(ctx, message.KafkaMessage()); err != nil {
+			if markErr := r.repository.MarkFailed(ctx, *message.Id, err); markErr != nil {
+				log.Error.Println("failed to record outbox message failure", markErr)
+			}
+			return sent, err
+		}
+
+		if err = r.repository.MarkSent(ctx, *message.Id); err != nil {
+			return sent, err
+		}
+		sent++
+	}
+	return sent, nil
+}
@@ -0,0 +1,176 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/event"
+	"testing"
+	"time"
+)
+
+type StubWriter struct {
+	Messages []kafka.Message
+	err      error
+}
+
+func (s *StubWriter) SendMessage(ctx context.Context, messages ...kafka.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.Messages = append(s.Messages, messages...)
+	return nil
+}
+
+func givenOutboxMessage(topic string, key string) Message {
+	headers := []kafka.Header{{Key: "event", Value: []byte("order-updated")}}
+	return NewMessage(topic, kafka.Message{Key: []byte(key), Value: []byte(`{"orderNumber":1000}`), Headers: headers})
+}
+
+func TestRelay_RelayPending(t *testing.T) {
+	t.Run("should publish pending messages and mark them sent", shouldPublishPendingMessagesAndMarkThemSent)
+	t.Run("should stop publishing at first message that failed to be sent", shouldStopPublishingAtFirstMessageThatFailedToBeSent)
+	t.Run("should keep message pending when its topic is unknown", shouldKeepMessagePendingWhenItsTopicIsUnknown)
+	t.Run("should skip message of unknown topic once it is dead", shouldSkipMessageOfUnknownTopicOnceItIsDead)
+	t.Run("should not publish while other relay holds lease", shouldNotPublishWhileOtherRelayHoldsLease)
+}
+
+func shouldPublishPendingMessagesAndMarkThemSent(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	_ = repository.Add(context.Background(), givenOutboxMessage("order-stream", "1"), givenOutboxMessage("order-status", "2"), givenOutboxMessage("order-stream", "3"))
+
+	streamWriter := &StubWriter{}
+	statusWriter := &StubWriter{}
+	sut := NewRelayWithWriters(repository, map[string]event.Writer{"order-stream": streamWriter, "order-status": statusWriter})
+
+	// when
+	sent, err := sut.RelayPending(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sent)
+	assert.Empty(t, repository.GetPending())
+
+	// and
+	assert.Len(t, streamWriter.Messages, 2)
+	assert.Equal(t, []byte("1"), streamWriter.Messages[0].Key)
+	assert.Equal(t, []byte("3"), streamWriter.Messages[1].Key)
+	assert.Equal(t, []kafka.Header{{Key: "event", Value: []byte("order-updated")}}, streamWriter.Messages[0].Headers)
+
+	// and
+	assert.Len(t, statusWriter.Messages, 1)
+	assert.Equal(t, []byte("2"), statusWriter.Messages[0].Key)
+}
+
+func shouldStopPublishingAtFirstMessageThatFailedToBeSent(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	_ = repository.Add(context.Background(), givenOutboxMessage("order-stream", "1"), givenOutboxMessage("order-status", "2"), givenOutboxMessage("order-stream", "3"))
+
+	streamWriter := &StubWriter{}
+	statusWriter := &StubWriter{err: fmt.Errorf("kafka is not available")}
+	sut := NewRelayWithWriters(repository, map[string]event.Writer{"order-stream": streamWriter, "order-status": statusWriter})
+
+	// when
+	sent, err := sut.RelayPending(context.Background())
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Len(t, streamWriter.Messages, 1)
+
+	// and
+	pending := repository.GetPending()
+	assert.Len(t, pending, 2)
+	assert.Equal(t, "kafka is not available", repository.GetFailure(*pending[0].Id))
+
+	// when
+	statusWriter.err = nil
+	sent, err = sut.RelayPending(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, sent)
+	assert.Empty(t, repository.GetPending())
+	assert.Len(t, statusWriter.Messages, 1)
+	assert.Len(t, streamWriter.Messages, 2)
+}
+
+func shouldKeepMessagePendingWhenItsTopicIsUnknown(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	_ = repository.Add(context.Background(), givenOutboxMessage("unknown-topic", "1"))
+
+	sut := NewRelayWithWriters(repository, map[string]event.Writer{})
+
+	// when
+	sent, err := sut.RelayPending(context.Background())
+
+	// then
+	assert.Error(t, err)
+	assert.Equal(t, 0, sent)
+
+	// and
+	pending := repository.GetPending()
+	assert.Len(t, pending, 1)
+	assert.Contains(t, repository.GetFailure(*pending[0].Id), "unknown topic unknown-topic")
+}
+
+func shouldSkipMessageOfUnknownTopicOnceItIsDead(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	_ = repository.Add(context.Background(), givenOutboxMessage("unknown-topic", "1"), givenOutboxMessage("order-stream", "2"))
+
+	streamWriter := &StubWriter{}
+	sut := NewRelayWithWriters(repository, map[string]event.Writer{"order-stream": streamWriter})
+	for attempt := 1; attempt < maxUnknownTopicAttempts; attempt++ {
+		_, err := sut.RelayPending(context.Background())
+		assert.Error(t, err)
+	}
+	dead := repository.GetPending()[0]
+
+	// when
+	sent, err := sut.RelayPending(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Empty(t, repository.GetPending())
+	assert.Len(t, streamWriter.Messages, 1)
+
+	// and
+	assert.Equal(t, Dead, repository.GetMessage(*dead.Id).Status)
+	assert.Equal(t, maxUnknownTopicAttempts, repository.GetMessage(*dead.Id).Attempts)
+}
+
+func shouldNotPublishWhileOtherRelayHoldsLease(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	_ = repository.Add(context.Background(), givenOutboxMessage("order-stream", "1"))
+
+	streamWriter := &StubWriter{}
+	writers := map[string]event.Writer{"order-stream": streamWriter}
+	leaseHolder := NewRelayWithWriters(repository, writers)
+	sut := NewRelayWithWriters(repository, writers)
+	leased, _ := repository.AcquireLease(context.Background(), leaseHolder.owner, time.Minute)
+	assert.True(t, leased)
+
+	// when
+	sent, err := sut.RelayPending(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 0, sent)
+	assert.Empty(t, streamWriter.Messages)
+	assert.Len(t, repository.GetPending(), 1)
+
+	// when
+	sent, err = leaseHolder.RelayPending(context.Background())
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 1, sent)
+	assert.Empty(t, repository.GetPending())
+}
@@ -0,0 +1,145 @@
+package outbox
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/log"
+	"time"
+)
+
+type AddRepository interface {
+	Add(ctx context.Context, messages ...Message) error
+}
+
+type Repository interface {
+	AddRepository
+	FetchPending(ctx context.Context, limit int64) ([]Message, error)
+	MarkSent(ctx context.Context, id primitive.ObjectID) error
+	MarkFailed(ctx context.Context, id primitive.ObjectID, reason error) error
+	MarkDead(ctx context.Context, id primitive.ObjectID, reason error) error
+	// AcquireLease takes or renews the lease of the relay for the owner, it returns false while another owner holds it
+	AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error)
+}
+
+const relayLeaseId = "relay"
+
+type RepositoryImpl struct {
+	c      *mongo.Collection
+	leases *mongo.Collection
+}
+
+func NewRepository(database *mongo.Database) *RepositoryImpl {
+	collection := database.Collection("outbox")
+	createPendingIndex(collection)
+	return &RepositoryImpl{c: collection, leases: database.Collection("outbox-leases")}
+}
+
+func createPendingIndex(c *mongo.Collection) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	indexModel := mongo.IndexModel{
+		Keys:    bson.D{{Key: "status", Value: 1}, {Key: "_id", Value: 1}},
+		Options: options.Index().SetName("outbox-pending"),
+	}
+	if _, err := c.Indexes().CreateOne(ctx, indexModel); err != nil {
+		log.Error.Println("Error when creating outbox index", err)
+	}
+}
+
+// Add stores the messages, called with the session context of a transaction the messages are stored only when
+// the transaction commits.
+func (r *RepositoryImpl) Add(ctx context.Context, messages ...Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	documents := make([]interface{}, 0)
+	for _, message := range messages {
+		id := primitive.NewObjectID()
+		message.Id = &id
+		documents = append(documents, message)
+	}
+
+	if _, err := r.c.InsertMany(ctx, documents); err != nil {
+		log.Error.Println("Error when storing outbox messages in db", err)
+		return err
+	}
+	return nil
+}
+
+// FetchPending returns the messages not yet sent in the order they were added in.
+func (r *RepositoryImpl) FetchPending(ctx context.Context, limit int64) ([]Message, error) {
+	filterDef := bson.D{{Key: "status", Value: Pending}}
+	findOptions := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetLimit(limit)
+
+	cursor, err := r.c.Find(ctx, filterDef, findOptions)
+	if err != nil {
+		log.Error.Println("Error when fetching pending outbox messages from db", err)
+		return nil, err
+	}
+
+	messages := make([]Message, 0)
+	if err = cursor.All(ctx, &messages); err != nil {
+		log.Error.Println("Error when reading pending outbox messages from db", err)
+		return nil, err
+	}
+	return messages, nil
+}
+
+func (r *RepositoryImpl) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	filterDef := bson.D{{Key: "_id", Value: id}}
+	updateDef := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: Sent}, {Key: "sentAt", Value: time.Now()}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef)
+	return err
+}
+
+func (r *RepositoryImpl) MarkFailed(ctx context.Context, id primitive.ObjectID, reason error) error {
+	filterDef := bson.D{{Key: "_id", Value: id}}
+	updateDef := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "lastError", Value: reason.Error()}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef)
+	return err
+}
+
+func (r *RepositoryImpl) MarkDead(ctx context.Context, id primitive.ObjectID, reason error) error {
+	filterDef := bson.D{{Key: "_id", Value: id}}
+	updateDef := bson.D{
+		{Key: "$set", Value: bson.D{{Key: "status", Value: Dead}, {Key: "lastError", Value: reason.Error()}}},
+		{Key: "$inc", Value: bson.D{{Key: "attempts", Value: 1}}},
+	}
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef)
+	return err
+}
+
+// AcquireLease updates the lease held by the owner or expired, the upsert of a lease held by another owner fails on
+// the duplicate id.
+func (r *RepositoryImpl) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	filterDef := bson.D{
+		{Key: "_id", Value: relayLeaseId},
+		{Key: "$or", Value: bson.A{
+			bson.D{{Key: "owner", Value: owner}},
+			bson.D{{Key: "expiresAt", Value: bson.D{{Key: "$lt", Value: now}}}},
+		}},
+	}
+	updateDef := bson.D{{Key: "$set", Value: bson.D{{Key: "owner", Value: owner}, {Key: "expiresAt", Value: now.Add(ttl)}}}}
+
+	_, err := r.leases.UpdateOne(ctx, filterDef, updateDef, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		log.Error.Println("Error when acquiring outbox relay lease", err)
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,116 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mc-burger-orders/testing/utils"
+	"testing"
+	"time"
+)
+
+var (
+	outboxCollectionDb *mongo.Collection
+	outboxLeasesDb     *mongo.Collection
+)
+
+func TestIntegrationOutboxRepository(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+	mongoContainer, database := utils.TestWithMongo(t, ctx)
+
+	outboxCollectionDb = database.Collection("outbox")
+	outboxLeasesDb = database.Collection("outbox-leases")
+
+	t.Run("should fetch pending messages in order they were added", shouldFetchPendingMessagesInOrderTheyWereAdded)
+	t.Run("should not fetch messages marked as sent", shouldNotFetchMessagesMarkedAsSent)
+	t.Run("should grant lease to one relay until it expires", shouldGrantLeaseToOneRelayUntilItExpires)
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+func shouldFetchPendingMessagesInOrderTheyWereAdded(t *testing.T) {
+	// given
+	utils.DeleteMany(t, outboxCollectionDb, bson.D{})
+	sut := &RepositoryImpl{c: outboxCollectionDb}
+	assert.Nil(t, sut.Add(context.Background(), givenOutboxMessage("order-stream", "1"), givenOutboxMessage("order-status", "2")))
+	assert.Nil(t, sut.Add(context.Background(), givenOutboxMessage("order-stream", "3")))
+
+	// when
+	messages, err := sut.FetchPending(context.Background(), 2)
+
+	// then
+	assert.Nil(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, []byte("1"), messages[0].Key)
+	assert.Equal(t, "order-stream", messages[0].Topic)
+	assert.Equal(t, []byte("2"), messages[1].Key)
+	assert.Equal(t, "order-status", messages[1].Topic)
+	assert.Equal(t, []Header{{Key: "event", Value: "order-updated"}}, messages[0].Headers)
+
+	defer func() {
+		utils.DeleteMany(t, outboxCollectionDb, bson.D{})
+	}()
+}
+
+func shouldNotFetchMessagesMarkedAsSent(t *testing.T) {
+	// given
+	utils.DeleteMany(t, outboxCollectionDb, bson.D{})
+	sut := &RepositoryImpl{c: outboxCollectionDb}
+	assert.Nil(t, sut.Add(context.Background(), givenOutboxMessage("order-stream", "1"), givenOutboxMessage("order-stream", "2")))
+
+	pending, err := sut.FetchPending(context.Background(), 10)
+	assert.Nil(t, err)
+
+	// when
+	assert.Nil(t, sut.MarkSent(context.Background(), *pending[0].Id))
+	assert.Nil(t, sut.MarkFailed(context.Background(), *pending[1].Id, fmt.Errorf("kafka is not available")))
+
+	// then
+	messages, err := sut.FetchPending(context.Background(), 10)
+	assert.Nil(t, err)
+	assert.Len(t, messages, 1)
+	assert.Equal(t, []byte("2"), messages[0].Key)
+	assert.Equal(t, 1, messages[0].Attempts)
+	assert.Equal(t, "kafka is not available", messages[0].LastError)
+
+	defer func() {
+		utils.DeleteMany(t, outboxCollectionDb, bson.D{})
+	}()
+}
+
+func shouldGrantLeaseToOneRelayUntilItExpires(t *testing.T) {
+	// given
+	utils.DeleteMany(t, outboxLeasesDb, bson.D{})
+	sut := &RepositoryImpl{c: outboxCollectionDb, leases: outboxLeasesDb}
+	leased, err := sut.AcquireLease(context.Background(), "relay-1", 200*time.Millisecond)
+	assert.Nil(t, err)
+	assert.True(t, leased)
+
+	// when
+	renewed, renewErr := sut.AcquireLease(context.Background(), "relay-1", 200*time.Millisecond)
+	taken, takeErr := sut.AcquireLease(context.Background(), "relay-2", 200*time.Millisecond)
+
+	// then
+	assert.Nil(t, renewErr)
+	assert.True(t, renewed)
+	assert.Nil(t, takeErr)
+	assert.False(t, taken)
+
+	// when
+	time.Sleep(300 * time.Millisecond)
+	taken, takeErr = sut.AcquireLease(context.Background(), "relay-2", 200*time.Millisecond)
+
+	// then
+	assert.Nil(t, takeErr)
+	assert.True(t, taken)
+
+	defer func() {
+		utils.DeleteMany(t, outboxLeasesDb, bson.D{})
+	}()
+}
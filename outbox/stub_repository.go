@@ -0,0 +1,95 @@
+package outbox
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"time"
+)
+
+type StubRepository struct {
+	messages       []Message
+	failures       map[primitive.ObjectID]string
+	leaseOwner     string
+	leaseExpiresAt time.Time
+}
+
+func GivenRepository() *StubRepository {
+	return &StubRepository{messages: make([]Message, 0), failures: make(map[primitive.ObjectID]string)}
+}
+
+func (s *StubRepository) Add(ctx context.Context, messages ...Message) error {
+	for _, message := range messages {
+		id := primitive.NewObjectID()
+		message.Id = &id
+		s.messages = append(s.messages, message)
+	}
+	return nil
+}
+
+func (s *StubRepository) FetchPending(ctx context.Context, limit int64) ([]Message, error) {
+	pending := make([]Message, 0)
+	for _, message := range s.messages {
+		if message.Status == Pending && int64(len(pending)) < limit {
+			pending = append(pending, message)
+		}
+	}
+	return pending, nil
+}
+
+func (s *StubRepository) MarkSent(ctx context.Context, id primitive.ObjectID) error {
+	for i := range s.messages {
+		if *s.messages[i].Id == id {
+			s.messages[i].Status = Sent
+		}
+	}
+	return nil
+}
+
+func (s *StubRepository) MarkFailed(ctx context.Context, id primitive.ObjectID, reason error) error {
+	for i := range s.messages {
+		if *s.messages[i].Id == id {
+			s.messages[i].Attempts++
+		}
+	}
+	s.failures[id] = reason.Error()
+	return nil
+}
+
+func (s *StubRepository) MarkDead(ctx context.Context, id primitive.ObjectID, reason error) error {
+	for i := range s.messages {
+		if *s.messages[i].Id == id {
+			s.messages[i].Status = Dead
+			s.messages[i].Attempts++
+		}
+	}
+	s.failures[id] = reason.Error()
+	return nil
+}
+
+func (s *StubRepository) AcquireLease(ctx context.Context, owner string, ttl time.Duration) (bool, error) {
+	now := time.Now()
+	if s.leaseOwner != owner && now.Before(s.leaseExpiresAt) {
+		return false, nil
+	}
+	s.leaseOwner = owner
+	s.leaseExpiresAt = now.Add(ttl)
+	return true, nil
+}
+
+func (s *StubRepository) GetMessage(id primitive.ObjectID) Message {
+	for _, message := range s.messages {
+		if *message.Id == id {
+			return message
+		}
+	}
+	return Message{}
+}
+
+func (s *StubRepository) GetPending() []Message {
+	pending, _ := s.FetchPending(context.Background(), int64(len(s.messages)))
+	return pending
+}
+
+func (s *StubRepository) GetFailure(id primitive.ObjectID) string {
+	return s.failures[id]
+}
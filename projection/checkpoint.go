@@ -0,0 +1,91 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Checkpoint is the state of a read model together with the offsets of the next messages it folds, by partition.
+type Checkpoint struct {
+	Name    string           `bson:"_id"`
+	Offsets map[string]int64 `bson:"offsets"`
+	State   []byte           `bson:"state"`
+	SavedAt time.Time        `bson:"savedAt"`
+}
+
+func (c Checkpoint) offsetOf(partition int) int64 {
+	offset, exists := c.Offsets[strconv.Itoa(partition)]
+	if !exists {
+		return fromEarliest
+	}
+	return offset
+}
+
+type CheckpointRepository interface {
+	Save(ctx context.Context, checkpoint Checkpoint) error
+	// Load returns the checkpoint of the read model, or nil when none was saved.
+	Load(ctx context.Context, name string) (*Checkpoint, error)
+}
+
+type RepositoryImpl struct {
+	c *mongo.Collection
+}
+
+func NewRepository(database *mongo.Database) *RepositoryImpl {
+	return &RepositoryImpl{c: database.Collection("projection-checkpoints")}
+}
+
+func (r *RepositoryImpl) Save(ctx context.Context, checkpoint Checkpoint) error {
+	filterDef := bson.D{{Key: "_id", Value: checkpoint.Name}}
+	if _, err := r.c.ReplaceOne(ctx, filterDef, checkpoint, options.Replace().SetUpsert(true)); err != nil {
+		log.Error.Println("Error when saving checkpoint of read model", checkpoint.Name, err)
+		return err
+	}
+	return nil
+}
+
+func (r *RepositoryImpl) Load(ctx context.Context, name string) (*Checkpoint, error) {
+	checkpoint := &Checkpoint{}
+	err := r.c.FindOne(ctx, bson.D{{Key: "_id", Value: name}}).Decode(checkpoint)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, nil
+	}
+	if err != nil {
+		log.Error.Println("Error when loading checkpoint of read model", name, err)
+		return nil, err
+	}
+	return checkpoint, nil
+}
+
+type InMemoryRepository struct {
+	mu          sync.Mutex
+	checkpoints map[string]Checkpoint
+}
+
+func NewInMemoryRepository() *InMemoryRepository {
+	return &InMemoryRepository{checkpoints: make(map[string]Checkpoint)}
+}
+
+func (r *InMemoryRepository) Save(_ context.Context, checkpoint Checkpoint) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checkpoints[checkpoint.Name] = checkpoint
+	return nil
+}
+
+func (r *InMemoryRepository) Load(_ context.Context, name string) (*Checkpoint, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	checkpoint, exists := r.checkpoints[name]
+	if !exists {
+		return nil, nil
+	}
+	return &checkpoint, nil
+}
@@ -0,0 +1,52 @@
+package projection
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"mc-burger-orders/log"
+	"mc-burger-orders/middleware"
+	"mc-burger-orders/testing/utils"
+	"net/http"
+)
+
+type Endpoints struct {
+	projectors []*Projector
+}
+
+func NewProjectionEndpoints(projectors ...*Projector) middleware.EndpointsSetup {
+	return &Endpoints{projectors: projectors}
+}
+
+func (e *Endpoints) Setup(r *gin.Engine) {
+	r.GET("/admin/projections", e.listProjectionsHandler)
+	r.POST("/admin/projections/:name/rebuild", e.rebuildProjectionHandler)
+}
+
+func (e *Endpoints) listProjectionsHandler(c *gin.Context) {
+	statuses := make([]Status, 0)
+	for _, projector := range e.projectors {
+		statuses = append(statuses, projector.Statuses()...)
+	}
+	c.JSON(http.StatusOK, statuses)
+}
+
+func (e *Endpoints) rebuildProjectionHandler(c *gin.Context) {
+	name := c.Param("name")
+	for _, projector := range e.projectors {
+		err := projector.Rebuild(c, name)
+		if errors.Is(err, ErrUnknownReadModel) {
+			continue
+		}
+		if err != nil {
+			log.Error.Println("failed to rebuild read model", name, err)
+			c.JSON(http.StatusInternalServerError, utils.ErrorPayload(err.Error()))
+			return
+		}
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	errMessage := fmt.Sprintf("read model %v does not exist", name)
+	c.JSON(http.StatusNotFound, utils.ErrorPayload(errMessage))
+}
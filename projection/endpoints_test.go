@@ -0,0 +1,79 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func givenProjectionEndpoints(projector *Projector) *gin.Engine {
+	engine := gin.Default()
+	NewProjectionEndpoints(projector).Setup(engine)
+	return engine
+}
+
+func TestProjectionEndpoints(t *testing.T) {
+	t.Run("should list read models with their offsets", shouldListReadModelsWithTheirOffsets)
+	t.Run("should rebuild read model", shouldRebuildReadModel)
+	t.Run("should return NOT FOUND when read model does not exist", shouldReturnNotFoundWhenReadModelDoesNotExist)
+}
+
+func shouldListReadModelsWithTheirOffsets(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	projector := NewProjector(configuration, NewInMemoryRepository(), &RecordingReadModel{})
+	givenWrittenMessages(t, configuration, "1", "2")
+	projector.CatchUp(context.Background())
+	engine := givenProjectionEndpoints(projector)
+
+	req, _ := http.NewRequest("GET", "/admin/projections", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusOK, resp.Code)
+
+	var statuses []Status
+	assert.NoError(t, json.Unmarshal(resp.Body.Bytes(), &statuses))
+	assert.Equal(t, []Status{{Name: "recording", Topic: "order-stream", Offsets: map[int]int64{0: 2}}}, statuses)
+}
+
+func shouldRebuildReadModel(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	readModel := &RecordingReadModel{}
+	projector := NewProjector(configuration, NewInMemoryRepository(), readModel)
+	givenWrittenMessages(t, configuration, "1")
+	projector.CatchUp(context.Background())
+	engine := givenProjectionEndpoints(projector)
+
+	req, _ := http.NewRequest("POST", "/admin/projections/recording/rebuild", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusAccepted, resp.Code)
+	assert.Empty(t, readModel.GetValues())
+}
+
+func shouldReturnNotFoundWhenReadModelDoesNotExist(t *testing.T) {
+	// given
+	engine := givenProjectionEndpoints(NewProjector(givenTopic(1), NewInMemoryRepository(), &RecordingReadModel{}))
+
+	req, _ := http.NewRequest("POST", "/admin/projections/unknown/rebuild", nil)
+	resp := httptest.NewRecorder()
+
+	// when
+	engine.ServeHTTP(resp, req)
+
+	// then
+	assert.Equal(t, http.StatusNotFound, resp.Code)
+}
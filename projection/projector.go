@@ -0,0 +1,221 @@
+package projection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"mc-burger-orders/event"
+	"mc-burger-orders/log"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// fromEarliest reads partitions from their first message still kept by the broker
+	fromEarliest = int64(-1)
+	batchSize    = 100
+)
+
+var ErrUnknownReadModel = errors.New("unknown read model")
+
+// ReadModel folds the messages of a topic into the state queries are answered from.
+type ReadModel interface {
+	Name() string
+	// Apply folds the message into the read model, it skips messages of events the read model does not follow. Errors
+	// of messages which never fold wrap event.ErrMalformedMessage, the message is skipped then. On other errors the
+	// read model stays at the message and folds it again on the next catch up.
+	Apply(ctx context.Context, message kafka.Message) error
+	// State encodes the read model saved with its checkpoint, Restore continues from it.
+	State() ([]byte, error)
+	Restore(state []byte) error
+	Reset()
+}
+
+// Status tells how far a read model has read the partitions of its topic.
+type Status struct {
+	Name    string        `json:"name"`
+	Topic   string        `json:"topic"`
+	Offsets map[int]int64 `json:"offsets"`
+}
+
+// Projector folds the messages of a topic into read models, every read model reads the topic from its own
+// checkpoint, so a read model is rebuilt from the beginning of the topic while the others stay current.
+type Projector struct {
+	mu            sync.Mutex
+	configuration *event.TopicConfigs
+	checkpoints   CheckpointRepository
+	projections   []*projection
+}
+
+type projection struct {
+	readModel ReadModel
+	offsets   map[int]int64
+}
+
+func NewProjector(configuration *event.TopicConfigs, checkpoints CheckpointRepository, readModels ...ReadModel) *Projector {
+	projections := make([]*projection, 0)
+	for _, readModel := range readModels {
+		projections = append(projections, &projection{readModel: readModel, offsets: make(map[int]int64)})
+	}
+	return &Projector{configuration: configuration, checkpoints: checkpoints, projections: projections}
+}
+
+// Run restores the read models from their checkpoints and keeps folding new messages until the context is done.
+func (p *Projector) Run(ctx context.Context) {
+	if err := p.Restore(ctx); err != nil {
+		log.Error.Println("Read models of topic", p.configuration.Topic, "are rebuilt, restoring them failed.", err)
+	}
+
+	for ctx.Err() == nil {
+		if folded := p.CatchUp(ctx); folded > 0 {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(p.configuration.AwaitBetweenReadsTime):
+		}
+	}
+}
+
+// Restore loads the read models and their offsets from the checkpoints, read models without one start from scratch.
+func (p *Projector) Restore(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for _, current := range p.projections {
+		current.readModel.Reset()
+		current.offsets = make(map[int]int64)
+
+		checkpoint, err := p.checkpoints.Load(ctx, current.readModel.Name())
+		if err == nil && checkpoint != nil {
+			err = current.readModel.Restore(checkpoint.State)
+			if err != nil {
+				current.readModel.Reset()
+			} else {
+				for partition := 0; partition < p.partitions(); partition++ {
+					current.offsets[partition] = checkpoint.offsetOf(partition)
+				}
+			}
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("read model %v: %w", current.readModel.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// CatchUp folds the messages written since the last call into every read model and saves the checkpoints of the
+// read models that moved on. It returns the number of messages folded.
+func (p *Projector) CatchUp(ctx context.Context) int {
+	folded := 0
+	for _, current := range p.projections {
+		foldedByModel := 0
+		for partition := 0; partition < p.partitions(); partition++ {
+			foldedByModel += p.foldPartition(ctx, current, partition)
+		}
+		if foldedByModel > 0 {
+			p.mu.Lock()
+			p.saveCheckpoint(ctx, current)
+			p.mu.Unlock()
+		}
+		folded += foldedByModel
+	}
+	return folded
+}
+
+// foldPartition reads the partition without holding the projector, so statuses are served while the broker is slow.
+// Messages read are dropped when the read model was restored or rebuilt in the meantime.
+func (p *Projector) foldPartition(ctx context.Context, current *projection, partition int) int {
+	p.mu.Lock()
+	offset, exists := current.offsets[partition]
+	p.mu.Unlock()
+	readFrom := offset
+	if !exists {
+		readFrom = fromEarliest
+	}
+
+	messages, err := event.ReadPartition(ctx, p.configuration, partition, readFrom, batchSize)
+	if err != nil {
+		log.Error.Printf("Failed to read partition %d of %v for read model %v. Reason: %v", partition, p.configuration.Topic, current.readModel.Name(), err)
+		return 0
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if stillAt, stillExists := current.offsets[partition]; stillAt != offset || stillExists != exists {
+		return 0
+	}
+
+	folded := 0
+	for _, message := range messages {
+		err = current.readModel.Apply(ctx, message)
+		if err != nil && !errors.Is(err, event.ErrMalformedMessage) {
+			log.Error.Printf("Read model %v stopped at message %d/%d of %v, it is folded again on the next catch up. Reason: %v", current.readModel.Name(), message.Partition, message.Offset, p.configuration.Topic, err)
+			break
+		}
+		// a malformed message is skipped, so it does not hold back the messages following it
+		if err != nil {
+			log.Warning.Printf("Read model %v skipped message %d/%d of %v. Reason: %v", current.readModel.Name(), message.Partition, message.Offset, p.configuration.Topic, err)
+		}
+		current.offsets[partition] = message.Offset + 1
+		folded++
+	}
+	return folded
+}
+
+func (p *Projector) saveCheckpoint(ctx context.Context, current *projection) {
+	state, err := current.readModel.State()
+	if err != nil {
+		log.Error.Println("Failed to encode state of read model", current.readModel.Name(), err)
+		return
+	}
+
+	offsets := make(map[string]int64)
+	for partition, offset := range current.offsets {
+		offsets[strconv.Itoa(partition)] = offset
+	}
+	checkpoint := Checkpoint{Name: current.readModel.Name(), Offsets: offsets, State: state, SavedAt: time.Now()}
+	if err = p.checkpoints.Save(ctx, checkpoint); err != nil {
+		log.Error.Println("Failed to save checkpoint of read model", current.readModel.Name(), err)
+	}
+}
+
+// Rebuild resets the read model, it folds the topic from its beginning again on the next catch up.
+func (p *Projector) Rebuild(ctx context.Context, name string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, current := range p.projections {
+		if current.readModel.Name() != name {
+			continue
+		}
+		log.Warning.Println("Rebuilding read model", name, "from the beginning of", p.configuration.Topic)
+		current.readModel.Reset()
+		current.offsets = make(map[int]int64)
+		p.saveCheckpoint(ctx, current)
+		return nil
+	}
+	return fmt.Errorf("%w `%v`", ErrUnknownReadModel, name)
+}
+
+func (p *Projector) Statuses() []Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]Status, 0)
+	for _, current := range p.projections {
+		offsets := make(map[int]int64)
+		for partition, offset := range current.offsets {
+			offsets[partition] = offset
+		}
+		statuses = append(statuses, Status{Name: current.readModel.Name(), Topic: p.configuration.Topic, Offsets: offsets})
+	}
+	return statuses
+}
+
+func (p *Projector) partitions() int {
+	return max(p.configuration.NumPartitions, 1)
+}
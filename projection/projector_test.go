@@ -0,0 +1,203 @@
+package projection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+	"mc-burger-orders/event"
+	"sync"
+	"testing"
+	"time"
+)
+
+type RecordingReadModel struct {
+	mu     sync.Mutex
+	values []string
+	// failures is the number of times folding a `flaky` message fails
+	failures int
+}
+
+func (m *RecordingReadModel) Name() string {
+	return "recording"
+}
+
+func (m *RecordingReadModel) Apply(ctx context.Context, message kafka.Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if string(message.Value) == "poison" {
+		return fmt.Errorf("%w: cannot fold poison", event.ErrMalformedMessage)
+	}
+	if string(message.Value) == "flaky" && m.failures > 0 {
+		m.failures--
+		return fmt.Errorf("cannot fold flaky yet")
+	}
+	m.values = append(m.values, string(message.Value))
+	return nil
+}
+
+func (m *RecordingReadModel) State() ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Marshal(m.values)
+}
+
+func (m *RecordingReadModel) Restore(state []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return json.Unmarshal(state, &m.values)
+}
+
+func (m *RecordingReadModel) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.values = nil
+}
+
+func (m *RecordingReadModel) GetValues() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]string{}, m.values...)
+}
+
+func TestProjector(t *testing.T) {
+	t.Run("should fold messages of every partition and save checkpoint", shouldFoldMessagesOfEveryPartitionAndSaveCheckpoint)
+	t.Run("should continue from checkpoint once restored", shouldContinueFromCheckpointOnceRestored)
+	t.Run("should rebuild read model from beginning of topic", shouldRebuildReadModelFromBeginningOfTopic)
+	t.Run("should skip message read model cannot fold", shouldSkipMessageReadModelCannotFold)
+	t.Run("should fold message again on next catch up when folding fails", shouldFoldMessageAgainOnNextCatchUpWhenFoldingFails)
+	t.Run("should keep read models up to date while running", shouldKeepReadModelsUpToDateWhileRunning)
+}
+
+func shouldFoldMessagesOfEveryPartitionAndSaveCheckpoint(t *testing.T) {
+	// given
+	configuration := givenTopic(2)
+	checkpoints := NewInMemoryRepository()
+	readModel := &RecordingReadModel{}
+	sut := NewProjector(configuration, checkpoints, readModel)
+	givenWrittenMessages(t, configuration, "1", "2", "3", "4")
+
+	// when
+	folded := sut.CatchUp(context.Background())
+
+	// then
+	assert.Equal(t, 4, folded)
+	assert.ElementsMatch(t, []string{"1", "2", "3", "4"}, readModel.GetValues())
+
+	// and
+	checkpoint, err := checkpoints.Load(context.Background(), "recording")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), checkpoint.offsetOf(0)+checkpoint.offsetOf(1))
+	assert.Equal(t, 0, sut.CatchUp(context.Background()))
+}
+
+func shouldContinueFromCheckpointOnceRestored(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	checkpoints := NewInMemoryRepository()
+	givenWrittenMessages(t, configuration, "1", "2")
+	NewProjector(configuration, checkpoints, &RecordingReadModel{}).CatchUp(context.Background())
+	givenWrittenMessages(t, configuration, "3")
+
+	readModel := &RecordingReadModel{}
+	sut := NewProjector(configuration, checkpoints, readModel)
+
+	// when
+	assert.NoError(t, sut.Restore(context.Background()))
+	folded := sut.CatchUp(context.Background())
+
+	// then
+	assert.Equal(t, 1, folded)
+	assert.Equal(t, []string{"1", "2", "3"}, readModel.GetValues())
+}
+
+func shouldRebuildReadModelFromBeginningOfTopic(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	readModel := &RecordingReadModel{}
+	sut := NewProjector(configuration, NewInMemoryRepository(), readModel)
+	givenWrittenMessages(t, configuration, "1", "2")
+	sut.CatchUp(context.Background())
+
+	// when
+	err := sut.Rebuild(context.Background(), "recording")
+
+	// then
+	assert.NoError(t, err)
+	assert.Empty(t, readModel.GetValues())
+
+	// and
+	assert.Equal(t, 2, sut.CatchUp(context.Background()))
+	assert.Equal(t, []string{"1", "2"}, readModel.GetValues())
+	assert.ErrorIs(t, sut.Rebuild(context.Background(), "unknown"), ErrUnknownReadModel)
+}
+
+func shouldSkipMessageReadModelCannotFold(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	readModel := &RecordingReadModel{}
+	sut := NewProjector(configuration, NewInMemoryRepository(), readModel)
+	givenWrittenMessages(t, configuration, "1", "poison", "3")
+
+	// when
+	folded := sut.CatchUp(context.Background())
+
+	// then
+	assert.Equal(t, 3, folded)
+	assert.Equal(t, []string{"1", "3"}, readModel.GetValues())
+	assert.Equal(t, map[int]int64{0: 3}, sut.Statuses()[0].Offsets)
+}
+
+func shouldFoldMessageAgainOnNextCatchUpWhenFoldingFails(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	readModel := &RecordingReadModel{failures: 1}
+	sut := NewProjector(configuration, NewInMemoryRepository(), readModel)
+	givenWrittenMessages(t, configuration, "1", "flaky", "3")
+
+	// when
+	folded := sut.CatchUp(context.Background())
+
+	// then
+	assert.Equal(t, 1, folded)
+	assert.Equal(t, []string{"1"}, readModel.GetValues())
+	assert.Equal(t, map[int]int64{0: 1}, sut.Statuses()[0].Offsets)
+
+	// and
+	assert.Equal(t, 2, sut.CatchUp(context.Background()))
+	assert.Equal(t, []string{"1", "flaky", "3"}, readModel.GetValues())
+	assert.Equal(t, map[int]int64{0: 3}, sut.Statuses()[0].Offsets)
+}
+
+func shouldKeepReadModelsUpToDateWhileRunning(t *testing.T) {
+	// given
+	configuration := givenTopic(1)
+	readModel := &RecordingReadModel{}
+	sut := NewProjector(configuration, NewInMemoryRepository(), readModel)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go sut.Run(ctx)
+
+	// when
+	givenWrittenMessages(t, configuration, "1")
+
+	// then
+	assert.Eventually(t, func() bool { return len(readModel.GetValues()) == 1 }, time.Second, 10*time.Millisecond)
+}
+
+func givenTopic(partitions int) *event.TopicConfigs {
+	configuration := event.TestTopicConfigs("order-stream")
+	configuration.NumPartitions = partitions
+	configuration.AwaitBetweenReadsTime = 10 * time.Millisecond
+	configuration.Transport = event.NewMemoryTransport()
+	return configuration
+}
+
+func givenWrittenMessages(t *testing.T, configuration *event.TopicConfigs, values ...string) {
+	producer := configuration.Transport.Producer(configuration)
+	for i, value := range values {
+		message := kafka.Message{Key: []byte(fmt.Sprint(i)), Value: []byte(value)}
+		assert.NoError(t, producer.WriteMessages(context.Background(), message))
+	}
+}
@@ -1,6 +1,14 @@
 package dto
 
 type ItemAdded struct {
-	ItemName string `json:"itemName"`
-	Quantity int    `json:"quantity"`
+	ItemName string `json:"itemName" avro:"itemName"`
+	Quantity int    `json:"quantity" avro:"quantity"`
+	// RequestedBy tells the requests the items were made for when made at once for several of them
+	RequestedBy []ItemRequester `json:"requestedBy,omitempty" avro:"requestedBy"`
+}
+
+// ItemRequester is a request the added items were made for, CorrelationId is the correlation id of its event.
+type ItemRequester struct {
+	CorrelationId string `json:"correlationId" avro:"correlationId"`
+	Quantity      int    `json:"quantity" avro:"quantity"`
 }
@@ -4,3 +4,29 @@ var (
 	ItemAddedOnShelfEvent      = "item-added-on-shelf"
 	CheckFavoritesOnShelfEvent = "check-favorites-on-shelf"
 )
+
+// ItemAddedOnShelfSchema is the Avro schema of item-added-on-shelf payloads, a list of items added to the shelf
+// and the requests they were made for.
+const ItemAddedOnShelfSchema = `{
+  "type": "array",
+  "items": {
+    "type": "record",
+    "name": "ItemAdded",
+    "namespace": "mcburger.shelf",
+    "fields": [
+      {"name": "itemName", "type": "string"},
+      {"name": "quantity", "type": "int"},
+      {"name": "requestedBy", "default": [], "type": {
+        "type": "array",
+        "items": {
+          "type": "record",
+          "name": "ItemRequester",
+          "fields": [
+            {"name": "correlationId", "type": "string"},
+            {"name": "quantity", "type": "int"}
+          ]
+        }
+      }}
+    ]
+  }
+}`
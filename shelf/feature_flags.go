@@ -0,0 +1,6 @@
+package shelf
+
+import "mc-burger-orders/featureflag"
+
+// FavoritesParFlag is the quantity of every favorite item the shelf is kept stocked with.
+var FavoritesParFlag = featureflag.Int("shelf-favorites-par", 5, "quantity of every favorite item the shelf is kept stocked with")
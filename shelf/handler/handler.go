@@ -1,39 +1,40 @@
 package handler
 
 import (
+	"context"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
 	"mc-burger-orders/event"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/log"
 	"mc-burger-orders/order"
 	"mc-burger-orders/shelf"
-	utils2 "mc-burger-orders/utils"
 )
 
 type Handler struct {
 	defaultHandler command.DefaultCommandHandler
 	KitchenService order.KitchenRequestService
 	Shelf          *shelf.Shelf
+	Flags          *featureflag.Flags
 }
 
-func NewShelfHandler(kitchenTopicConfigs *event.TopicConfigs, s *shelf.Shelf) *Handler {
+func NewShelfHandler(kitchenTopicConfigs *event.TopicConfigs, s *shelf.Shelf, flags *featureflag.Flags) *Handler {
 	return &Handler{
 		Shelf:          s,
+		Flags:          flags,
 		KitchenService: NewKitchenService(kitchenTopicConfigs),
 		defaultHandler: command.DefaultCommandHandler{},
 	}
 }
 
-func (o *Handler) Handle(message kafka.Message, commandResults chan command.TypedResult) {
-	commands, err := o.GetCommands(message)
+func (o *Handler) Handle(ctx context.Context, eventType string, message kafka.Message) command.Results {
+	commands, err := o.GetCommands(eventType, message)
 	if err != nil {
-		commandResults <- command.NewErrorResult("ShelfHandler", err)
-		close(commandResults)
-		return
+		return command.Results{command.NewErrorResult("ShelfHandler", err)}
 	}
 
-	o.defaultHandler.HandleCommands(message, commandResults, commands...)
+	return o.defaultHandler.HandleCommands(ctx, message, commands...)
 }
 
 func (o *Handler) GetHandledEvents() []string {
@@ -44,19 +45,14 @@ func (o *Handler) AddCommands(event string, commands ...command.Command) {
 	o.defaultHandler.AddCommands(event, commands...)
 }
 
-func (o *Handler) GetCommands(message kafka.Message) ([]command.Command, error) {
-	eventType, err := utils2.GetEventType(message)
-	if err != nil {
-		log.Error.Println(err.Error())
-		return nil, err
-	}
-
+func (o *Handler) GetCommands(eventType string, message kafka.Message) ([]command.Command, error) {
 	commands := make([]command.Command, 0)
 	switch eventType {
 	case shelf.CheckFavoritesOnShelfEvent:
 		{
 			commands = append(commands, &RequestMissingItemsOnShelfCommand{
 				Shelf:          o.Shelf,
+				Flags:          o.Flags,
 				KitchenService: o.KitchenService,
 			})
 		}
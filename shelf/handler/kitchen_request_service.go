@@ -2,19 +2,13 @@ package handler
 
 import (
 	"context"
-	"encoding/json"
-	"fmt"
-	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/event"
 	"mc-burger-orders/kitchen"
-	"mc-burger-orders/utils"
-	"strconv"
-	"time"
 )
 
 type NewItemRequestMessage struct {
-	ItemName string `json:"itemName"`
-	Quantity int    `json:"quantity"`
+	ItemName string `json:"itemName" avro:"itemName"`
+	Quantity int    `json:"quantity" avro:"quantity"`
 }
 
 type KitchenService interface {
@@ -31,23 +25,15 @@ func NewKitchenService(config *event.TopicConfigs) *KitchenServiceImpl {
 }
 
 func (s *KitchenServiceImpl) RequestNew(ctx context.Context, itemName string, quantity int) error {
-	headers := make([]kafka.Header, 0)
-	headers = append(headers, utils.EventTypeHeader(kitchen.RequestItemEvent))
-
 	message := make([]*NewItemRequestMessage, 0)
 	message = append(message, &NewItemRequestMessage{itemName, quantity})
-	msgValue, err := json.Marshal(message)
+
+	envelope := event.NewEnvelope(ctx, kitchen.RequestItemEvent, event.Source("shelf"))
+	msg, err := event.EncodeMessage(ctx, event.ItemKey(itemName), envelope, message)
 	if err != nil {
-		err = fmt.Errorf("failed to convert message details to bytes. Reason: %s", err)
 		return err
 	}
 
-	msgKey := []byte(strconv.FormatInt(time.Now().UnixNano(), 10))
-	msg := kafka.Message{
-		Headers: headers,
-		Key:     msgKey,
-		Value:   msgValue,
-	}
 	if err = s.SendMessage(ctx, msg); err != nil {
 		return err
 	}
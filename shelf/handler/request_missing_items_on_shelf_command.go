@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/segmentio/kafka-go"
 	"mc-burger-orders/command"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/log"
 	"mc-burger-orders/shelf"
@@ -12,11 +13,13 @@ import (
 type RequestMissingItemsOnShelfCommand struct {
 	KitchenService KitchenService
 	Shelf          *shelf.Shelf
+	Flags          *featureflag.Flags
 }
 
 func (r *RequestMissingItemsOnShelfCommand) Execute(ctx context.Context, _ kafka.Message, commandResults chan command.TypedResult) {
 
 	log.Info.Printf("Checking the state of Favorites on Shelf....")
+	par := shelf.FavoritesParFlag.Get(r.Flags)
 
 	for favoriteItem := range item.MenuItems {
 		if !item.MenuItems[favoriteItem].Favorite {
@@ -25,9 +28,9 @@ func (r *RequestMissingItemsOnShelfCommand) Execute(ctx context.Context, _ kafka
 
 		current := r.Shelf.GetCurrent(favoriteItem)
 
-		if current < 5 {
-			toRequest := 5 - current
-			log.Info.Printf("Favorite Item %v is bellow the threshold of five on shelf (currently: %d). Requesting %d from kitchen.", favoriteItem, current, toRequest)
+		if current < par {
+			toRequest := par - current
+			log.Info.Printf("Favorite Item %v is bellow the threshold of %d on shelf (currently: %d). Requesting %d from kitchen.", favoriteItem, par, current, toRequest)
 			err := r.KitchenService.RequestNew(ctx, favoriteItem, toRequest)
 
 			if err != nil {
@@ -5,6 +5,7 @@ import (
 	"github.com/segmentio/kafka-go"
 	"github.com/stretchr/testify/assert"
 	command2 "mc-burger-orders/command"
+	"mc-burger-orders/featureflag"
 	"mc-burger-orders/shelf"
 	"testing"
 )
@@ -14,6 +15,7 @@ func TestRequestMissingItemsOnShelfCommand_Execute(t *testing.T) {
 	t.Run("should request required amount when favorite item is fully missing on shelf", shouldRequestItemsWhenMissingOnShelf)
 	t.Run("should request only the needed amount when favorite item is missing on shelf", shouldRequestItemsWhenBellowRequiredLimitOfItemsOnShelf)
 	t.Run("should not request any when all favorite items are on shelf", shouldNotRequestAnyWhenAllFavoriteItemsAreOnShelf)
+	t.Run("should request up to par set by feature flag", shouldRequestUpToParSetByFeatureFlag)
 }
 
 func shouldRequestItemsWhenMissingOnShelf(t *testing.T) {
@@ -108,3 +110,38 @@ func shouldNotRequestAnyWhenAllFavoriteItemsAreOnShelf(t *testing.T) {
 	assert.Zero(t, kitchenStub.CalledCnt())
 	close(commandResults)
 }
+
+func shouldRequestUpToParSetByFeatureFlag(t *testing.T) {
+	// given
+	s := shelf.NewEmptyShelf()
+	s.AddMany("hamburger", 6)
+	s.AddMany("cheeseburger", 8)
+	s.AddMany("spicy-stripes", 8)
+	s.AddMany("hot-wings", 10)
+	s.AddMany("fries", 8)
+
+	flags := featureflag.NewFlags(featureflag.NewInMemoryRepository(), map[string]string{}, shelf.FavoritesParFlag)
+	_, err := flags.Set(context.Background(), "shelf-favorites-par", "8")
+	assert.NoError(t, err)
+
+	kitchenStub := shelf.NewShelfStubService()
+
+	sut := RequestMissingItemsOnShelfCommand{
+		Shelf:          s,
+		KitchenService: kitchenStub,
+		Flags:          flags,
+	}
+	commandResults := make(chan command2.TypedResult)
+
+	// when
+	go sut.Execute(context.Background(), kafka.Message{}, commandResults)
+
+	// then
+	commandResult := <-commandResults
+	assert.True(t, commandResult.Result)
+
+	// and
+	assert.Equal(t, 1, kitchenStub.CalledCnt())
+	assert.True(t, kitchenStub.HaveBeenCalledWith(shelf.RequestMatchingFnc("hamburger", 2)))
+	close(commandResults)
+}
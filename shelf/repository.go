@@ -0,0 +1,61 @@
+package shelf
+
+import (
+	"context"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"mc-burger-orders/log"
+)
+
+type ShelfRepository interface {
+	FetchAll(ctx context.Context) (map[string]int, error)
+	Increment(ctx context.Context, item string, delta int) error
+}
+
+type ShelfItem struct {
+	Name     string `bson:"_id"`
+	Quantity int    `bson:"quantity"`
+}
+
+type ShelfRepositoryImpl struct {
+	c *mongo.Collection
+}
+
+func NewShelfRepository(database *mongo.Database) *ShelfRepositoryImpl {
+	collection := database.Collection("shelf")
+	return &ShelfRepositoryImpl{c: collection}
+}
+
+func (r *ShelfRepositoryImpl) FetchAll(ctx context.Context) (map[string]int, error) {
+	cursor, err := r.c.Find(ctx, bson.D{})
+	if err != nil {
+		log.Error.Println("Error when fetching shelf items from db", err)
+		return nil, err
+	}
+
+	var shelfItems []ShelfItem
+	if err = cursor.All(ctx, &shelfItems); err != nil {
+		log.Error.Println("Error when reading shelf items from db", err)
+		return nil, err
+	}
+
+	quantities := make(map[string]int)
+	for _, shelfItem := range shelfItems {
+		quantities[shelfItem.Name] = shelfItem.Quantity
+	}
+	return quantities, nil
+}
+
+// Increment changes the stored quantity of the item by delta. Deltas are applied with $inc, so concurrent changes
+// do not overwrite each other regardless of the order they reach the db in.
+func (r *ShelfRepositoryImpl) Increment(ctx context.Context, item string, delta int) error {
+	filterDef := bson.D{{Key: "_id", Value: item}}
+	updateDef := bson.D{{Key: "$inc", Value: bson.D{{Key: "quantity", Value: delta}}}}
+
+	_, err := r.c.UpdateOne(ctx, filterDef, updateDef, options.Update().SetUpsert(true))
+	if err != nil {
+		log.Error.Printf("Error when storing %v shelf change %d in db. Reason: %v", item, delta, err)
+	}
+	return err
+}
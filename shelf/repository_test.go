@@ -0,0 +1,81 @@
+package shelf
+
+import (
+	"context"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"mc-burger-orders/testing/utils"
+	"sync"
+	"testing"
+)
+
+var shelfCollectionDb *mongo.Collection
+
+func TestIntegrationShelfRepository(t *testing.T) {
+	utils.IntegrationTest(t)
+	ctx := context.Background()
+	mongoContainer, database := utils.TestWithMongo(t, ctx)
+
+	shelfCollectionDb = database.Collection("shelf")
+
+	t.Run("should fetch stored quantities", shouldFetchStoredQuantities)
+	t.Run("should not lose changes stored concurrently", shouldNotLoseChangesStoredConcurrently)
+
+	t.Cleanup(func() {
+		t.Log("Running Clean UP code")
+		utils.TerminateMongo(t, ctx, mongoContainer)
+	})
+}
+
+func shouldFetchStoredQuantities(t *testing.T) {
+	// given
+	utils.DeleteMany(t, shelfCollectionDb, bson.D{})
+	utils.InsertMany(t, shelfCollectionDb, []interface{}{
+		ShelfItem{Name: "hamburger", Quantity: 3},
+		ShelfItem{Name: "spicy-stripes", Quantity: 8},
+	})
+	sut := &ShelfRepositoryImpl{c: shelfCollectionDb}
+
+	// when
+	quantities, err := sut.FetchAll(context.Background())
+
+	// then
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]int{"hamburger": 3, "spicy-stripes": 8}, quantities)
+
+	defer func() {
+		utils.DeleteMany(t, shelfCollectionDb, bson.D{})
+	}()
+}
+
+func shouldNotLoseChangesStoredConcurrently(t *testing.T) {
+	// given
+	utils.DeleteMany(t, shelfCollectionDb, bson.D{})
+	sut := &ShelfRepositoryImpl{c: shelfCollectionDb}
+
+	wg := &sync.WaitGroup{}
+	wg.Add(20)
+
+	// when
+	for i := 0; i < 20; i++ {
+		delta := 2
+		if i%2 == 0 {
+			delta = -1
+		}
+		go func() {
+			defer wg.Done()
+			assert.Nil(t, sut.Increment(context.Background(), "hamburger", delta))
+		}()
+	}
+	wg.Wait()
+
+	// then
+	quantities, err := sut.FetchAll(context.Background())
+	assert.Nil(t, err)
+	assert.Equal(t, 10, quantities["hamburger"])
+
+	defer func() {
+		utils.DeleteMany(t, shelfCollectionDb, bson.D{})
+	}()
+}
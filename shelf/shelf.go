@@ -2,7 +2,7 @@ package shelf
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/segmentio/kafka-go"
 	"github.com/spf13/cast"
@@ -10,21 +10,50 @@ import (
 	"mc-burger-orders/kitchen/item"
 	"mc-burger-orders/log"
 	"mc-burger-orders/shelf/dto"
-	utils2 "mc-burger-orders/utils"
-	"strconv"
 	"sync"
 	"time"
 )
 
+var ErrReservationNotActive = errors.New("reservation was already committed, released or has expired")
+
 type Shelf struct {
-	writer *event.DefaultWriter
-	data   *sync.Map
+	writer     *event.DefaultWriter
+	repository ShelfRepository
+	mu         sync.Mutex
+	data       *sync.Map
+}
+
+// Reservation holds items taken from the shelf for an order until the order is stored. Items of a reservation
+// that is neither committed nor released within its ttl are put back on the shelf.
+type Reservation struct {
+	Item        string
+	Quantity    int
+	OrderNumber int64
+	ExpiresAt   time.Time
+	settled     bool
+	timer       *time.Timer
 }
 
 func NewEmptyShelf() *Shelf {
 	return &Shelf{data: CleanShelf()}
 }
 
+// NewPersistentShelf restores the shelf from the repository. Every later change of the shelf is stored in it,
+// reserved items are stored as taken only once their reservation is committed.
+func NewPersistentShelf(ctx context.Context, repository ShelfRepository) (*Shelf, error) {
+	quantities, err := repository.FetchAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	data := CleanShelf()
+	for itemName, quantity := range quantities {
+		data.Store(itemName, quantity)
+	}
+	log.Info.Printf("Kitchen Shelf restored with %+v", quantities)
+	return &Shelf{data: data, repository: repository}, nil
+}
+
 func CleanShelf() *sync.Map {
 	syncMap := &sync.Map{}
 	for itemConfig := range item.MenuItems {
@@ -41,29 +70,41 @@ func (s *Shelf) ConfigureWriter(writer *event.DefaultWriter) {
 }
 
 func (s *Shelf) Add(item string) {
-	if value, ok := s.data.Load(item); ok {
-		newVal := cast.ToInt(value) + 1
-		s.data.Store(item, newVal)
-		log.Warning.Printf("Kitchen Shelf | %v +1 => %d", item, newVal)
-	} else {
-		s.data.Store(item, 1)
-		log.Warning.Printf("Kitchen Shelf | %v +1 => %d", item, 1)
-	}
-
-	s.SendUpdateEvent(item, 1)
+	s.AddMany(item, 1)
 }
 
 func (s *Shelf) AddMany(item string, quantity int) {
-	if value, ok := s.data.Load(item); ok {
-		newVal := cast.ToInt(value) + quantity
-		s.data.Store(item, newVal)
-		log.Warning.Printf("Kitchen Shelf | %v + %d => %d", item, quantity, newVal)
-	} else {
-		s.data.Store(item, quantity)
-		log.Warning.Printf("Kitchen Shelf | %v + %d=> %d", item, quantity, quantity)
+	s.AddManyFor(context.Background(), item, quantity)
+}
+
+// AddManyFor adds items made on request of the event ctx carries the envelope of, the shelf event is correlated with
+// that event.
+func (s *Shelf) AddManyFor(ctx context.Context, item string, quantity int) {
+	s.add(item, quantity)
+	s.persist(item, quantity)
+	s.sendUpdateEvent(ctx, item, quantity, nil)
+}
+
+// AddRequested adds the items made at once for several requests, e.g. a batch of the kitchen, they are stored and
+// announced in one go. The shelf event is correlated with the event ctx carries the envelope of and tells the
+// correlation id of every request the items were made for.
+func (s *Shelf) AddRequested(ctx context.Context, item string, requestedBy []dto.ItemRequester) {
+	quantity := 0
+	for _, requester := range requestedBy {
+		quantity += requester.Quantity
 	}
 
-	s.SendUpdateEvent(item, quantity)
+	s.add(item, quantity)
+	s.persist(item, quantity)
+	s.sendUpdateEvent(ctx, item, quantity, requestedBy)
+}
+
+func (s *Shelf) add(item string, quantity int) {
+	s.mu.Lock()
+	newVal := s.GetCurrent(item) + quantity
+	s.data.Store(item, newVal)
+	s.mu.Unlock()
+	log.Warning.Printf("Kitchen Shelf | %v + %d => %d", item, quantity, newVal)
 }
 
 func (s *Shelf) GetCurrent(item string) int {
@@ -74,6 +115,17 @@ func (s *Shelf) GetCurrent(item string) int {
 }
 
 func (s *Shelf) Take(item string, quantity int) (bool, int, error) {
+	succeeded, taken, err := s.take(item, quantity)
+	if taken > 0 {
+		s.persist(item, -taken)
+	}
+	return succeeded, taken, err
+}
+
+func (s *Shelf) take(item string, quantity int) (bool, int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
 	if value, ok := s.data.Load(item); ok {
 		exists := cast.ToInt(value)
 		if exists < quantity {
@@ -90,36 +142,103 @@ func (s *Shelf) Take(item string, quantity int) (bool, int, error) {
 	return false, 0, err
 }
 
-func (s *Shelf) SendUpdateEvent(item string, quantity int) {
-	if s.writer == nil {
-		log.Warning.Printf("Shelf Events emitter not configured yet!")
-		return
+// Reserve takes up to quantity of the item from the shelf on behalf of the order. The returned reservation holds
+// fewer items than requested when the shelf does not have enough of them.
+func (s *Shelf) Reserve(item string, quantity int, orderNumber int64, ttl time.Duration) (*Reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	value, ok := s.data.Load(item)
+	if !ok {
+		return nil, fmt.Errorf("unknown item `%v` requested", item)
 	}
 
-	if kafkaMessage, err := createMessage(item, quantity); err == nil {
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		if err = s.writer.SendMessage(ctx, kafkaMessage); err != nil {
-			log.Error.Println("failed to send message to topic", s.writer.TopicName())
+	exists := cast.ToInt(value)
+	reserved := min(exists, quantity)
+	s.data.Store(item, exists-reserved)
+	log.Warning.Printf("Kitchen Shelf | %v - %d => %d reserved for order %d", item, reserved, exists-reserved, orderNumber)
+
+	reservation := &Reservation{Item: item, Quantity: reserved, OrderNumber: orderNumber, ExpiresAt: time.Now().Add(ttl)}
+	reservation.timer = time.AfterFunc(ttl, func() {
+		if s.settle(reservation) {
+			log.Warning.Printf("Reservation of %d %v for order %d has expired", reservation.Quantity, item, orderNumber)
+			s.restock(reservation)
 		}
+	})
+	return reservation, nil
+}
 
-		defer cancel()
+// Commit makes the reserved items leave the shelf for good.
+func (s *Shelf) Commit(reservation *Reservation) error {
+	if !s.settle(reservation) {
+		return ErrReservationNotActive
+	}
+	if reservation.Quantity > 0 {
+		s.persist(reservation.Item, -reservation.Quantity)
 	}
+	return nil
 }
 
-func createMessage(itemName string, quantity int) (kafka.Message, error) {
-	headers := make([]kafka.Header, 0)
-	headers = append(headers, utils2.EventTypeHeader(ItemAddedOnShelfEvent))
-	items := make([]dto.ItemAdded, 0)
-	items = append(items, dto.ItemAdded{ItemName: itemName, Quantity: quantity})
+// Release puts the reserved items back on the shelf. Releasing a settled reservation does nothing.
+func (s *Shelf) Release(reservation *Reservation) {
+	if s.settle(reservation) {
+		s.restock(reservation)
+	}
+}
+
+func (s *Shelf) settle(reservation *Reservation) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if reservation.settled {
+		return false
+	}
+	reservation.settled = true
+	reservation.timer.Stop()
+	return true
+}
+
+func (s *Shelf) restock(reservation *Reservation) {
+	if reservation.Quantity > 0 {
+		s.add(reservation.Item, reservation.Quantity)
+		s.SendUpdateEvent(reservation.Item, reservation.Quantity)
+	}
+}
+
+func (s *Shelf) persist(item string, delta int) {
+	if s.repository == nil {
+		return
+	}
 
-	b, err := json.Marshal(items)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := s.repository.Increment(ctx, item, delta); err != nil {
+		log.Error.Printf("failed to store shelf change of %v by %d. Reason: %v", item, delta, err)
+	}
+}
+
+func (s *Shelf) SendUpdateEvent(item string, quantity int) {
+	s.sendUpdateEvent(context.Background(), item, quantity, nil)
+}
+
+func (s *Shelf) sendUpdateEvent(ctx context.Context, item string, quantity int, requestedBy []dto.ItemRequester) {
+	if s.writer == nil {
+		log.Warning.Printf("Shelf Events emitter not configured yet!")
+		return
+	}
+
+	kafkaMessage, err := createMessage(ctx, item, quantity, requestedBy)
 	if err != nil {
-		return kafka.Message{}, err
+		log.Error.Printf("failed to create shelf update event of %v. Reason: %v", item, err)
+		return
 	}
+	s.writer.SendMessageAsync(kafkaMessage)
+}
+
+func createMessage(ctx context.Context, itemName string, quantity int, requestedBy []dto.ItemRequester) (kafka.Message, error) {
+	items := make([]dto.ItemAdded, 0)
+	items = append(items, dto.ItemAdded{ItemName: itemName, Quantity: quantity, RequestedBy: requestedBy})
 
-	return kafka.Message{
-		Headers: headers,
-		Key:     []byte(strconv.FormatInt(time.Now().UnixNano(), 10)),
-		Value:   b,
-	}, nil
+	envelope := event.NewEnvelope(ctx, ItemAddedOnShelfEvent, event.Source("shelf"))
+	return event.EncodeMessage(ctx, event.ItemKey(itemName), envelope, items)
 }
@@ -11,9 +11,9 @@ import (
 	"mc-burger-orders/command"
 	"mc-burger-orders/event"
 	"mc-burger-orders/testing/utils"
-	utils2 "mc-burger-orders/utils"
 	"sync"
 	"testing"
+	"time"
 )
 
 var (
@@ -81,7 +81,7 @@ func shouldEmitAddItemEvenWhenOneItemAdded(t *testing.T) {
 	}
 	log.Printf("new event from topic %v, it's the correct topic", newEvent.Topic)
 
-	eventType, err := utils2.GetEventType(newEvent)
+	eventType, err := event.GetEventType(newEvent)
 	if err != nil {
 		assert.Fail(t, err.Error())
 		return
@@ -124,7 +124,7 @@ func shouldEmitAddItemEvenWhenMultipleItemsAdded(t *testing.T) {
 	}
 
 	log.Printf("new event from topic %v, it's the correct topic", newEvent.Topic)
-	eventType, err := utils2.GetEventType(newEvent)
+	eventType, err := event.GetEventType(newEvent)
 	log.Printf("||new event %v read. Body: %v %+v", eventType, string(newEvent.Value), newEvent)
 
 	if err != nil {
@@ -136,3 +136,216 @@ func shouldEmitAddItemEvenWhenMultipleItemsAdded(t *testing.T) {
 
 	assert.Equal(t, "[{\"itemName\":\"cheeseburger\",\"quantity\":10}]", string(newEvent.Value))
 }
+
+func TestShelf_Reserve(t *testing.T) {
+	t.Run("should take reserved items from shelf", shouldTakeReservedItemsFromShelf)
+	t.Run("should reserve only items available on shelf", shouldReserveOnlyItemsAvailableOnShelf)
+	t.Run("should put released items back on shelf", shouldPutReleasedItemsBackOnShelf)
+	t.Run("should put items back on shelf when reservation expires", shouldPutItemsBackOnShelfWhenReservationExpires)
+	t.Run("should not commit expired reservation", shouldNotCommitExpiredReservation)
+	t.Run("should fail when reserving unknown item", shouldFailWhenReservingUnknownItem)
+}
+
+func shouldTakeReservedItemsFromShelf(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+	sut.AddMany("hamburger", 5)
+
+	// when
+	reservation, err := sut.Reserve("hamburger", 3, 1010, time.Minute)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 3, reservation.Quantity)
+	assert.Equal(t, int64(1010), reservation.OrderNumber)
+	assert.Equal(t, 2, sut.GetCurrent("hamburger"))
+
+	// and
+	assert.NoError(t, sut.Commit(reservation))
+	sut.Release(reservation)
+	assert.Equal(t, 2, sut.GetCurrent("hamburger"))
+}
+
+func shouldReserveOnlyItemsAvailableOnShelf(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+	sut.AddMany("hamburger", 2)
+
+	// when
+	reservation, err := sut.Reserve("hamburger", 3, 1010, time.Minute)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 2, reservation.Quantity)
+	assert.Equal(t, 0, sut.GetCurrent("hamburger"))
+}
+
+func shouldPutReleasedItemsBackOnShelf(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+	sut.AddMany("hamburger", 5)
+	reservation, _ := sut.Reserve("hamburger", 3, 1010, time.Minute)
+
+	// when
+	sut.Release(reservation)
+	sut.Release(reservation)
+
+	// then
+	assert.Equal(t, 5, sut.GetCurrent("hamburger"))
+	assert.ErrorIs(t, sut.Commit(reservation), ErrReservationNotActive)
+}
+
+func shouldPutItemsBackOnShelfWhenReservationExpires(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+	sut.AddMany("hamburger", 5)
+
+	// when
+	_, _ = sut.Reserve("hamburger", 3, 1010, 10*time.Millisecond)
+
+	// then
+	assert.Equal(t, 2, sut.GetCurrent("hamburger"))
+	assert.Eventually(t, func() bool { return sut.GetCurrent("hamburger") == 5 }, time.Second, 5*time.Millisecond)
+}
+
+func shouldNotCommitExpiredReservation(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+	sut.AddMany("hamburger", 5)
+	reservation, _ := sut.Reserve("hamburger", 3, 1010, 10*time.Millisecond)
+	assert.Eventually(t, func() bool { return sut.GetCurrent("hamburger") == 5 }, time.Second, 5*time.Millisecond)
+
+	// when
+	err := sut.Commit(reservation)
+
+	// then
+	assert.ErrorIs(t, err, ErrReservationNotActive)
+	assert.Equal(t, 5, sut.GetCurrent("hamburger"))
+}
+
+func shouldFailWhenReservingUnknownItem(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+
+	// when
+	reservation, err := sut.Reserve("unknown", 3, 1010, time.Minute)
+
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, reservation)
+}
+
+func TestShelf_Take(t *testing.T) {
+	t.Run("should not hand out more items than available when taken concurrently", shouldNotHandOutMoreItemsThanAvailableWhenTakenConcurrently)
+}
+
+func shouldNotHandOutMoreItemsThanAvailableWhenTakenConcurrently(t *testing.T) {
+	// given
+	sut := NewEmptyShelf()
+	sut.AddMany("hamburger", 100)
+
+	taken := make(chan int, 150)
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(150)
+
+	// when
+	for i := 0; i < 150; i++ {
+		go func() {
+			defer waitGroup.Done()
+			if _, quantity, _ := sut.Take("hamburger", 1); quantity > 0 {
+				taken <- quantity
+			}
+			sut.AddMany("cheeseburger", 1)
+		}()
+	}
+	waitGroup.Wait()
+	close(taken)
+
+	// then
+	total := 0
+	for quantity := range taken {
+		total += quantity
+	}
+	assert.Equal(t, 100, total)
+	assert.Equal(t, 0, sut.GetCurrent("hamburger"))
+	assert.Equal(t, 150, sut.GetCurrent("cheeseburger"))
+}
+
+func TestNewPersistentShelf(t *testing.T) {
+	t.Run("should restore shelf from repository", shouldRestoreShelfFromRepository)
+	t.Run("should store every shelf change in repository", shouldStoreEveryShelfChangeInRepository)
+	t.Run("should store reserved items only when reservation is committed", shouldStoreReservedItemsOnlyWhenReservationIsCommitted)
+	t.Run("should fail when shelf cannot be restored", shouldFailWhenShelfCannotBeRestored)
+}
+
+func shouldRestoreShelfFromRepository(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	repository.ReturnQuantity("hamburger", 4)
+	repository.ReturnQuantity("spicy-stripes", 2)
+
+	// when
+	sut, err := NewPersistentShelf(context.Background(), repository)
+
+	// then
+	assert.NoError(t, err)
+	assert.Equal(t, 4, sut.GetCurrent("hamburger"))
+	assert.Equal(t, 2, sut.GetCurrent("spicy-stripes"))
+	assert.Equal(t, 0, sut.GetCurrent("cheeseburger"))
+}
+
+func shouldStoreEveryShelfChangeInRepository(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	repository.ReturnQuantity("hamburger", 4)
+	sut, _ := NewPersistentShelf(context.Background(), repository)
+
+	// when
+	sut.AddMany("hamburger", 3)
+	sut.Add("cheeseburger")
+	_, _, _ = sut.Take("hamburger", 5)
+
+	// then
+	assert.Equal(t, 2, repository.GetQuantity("hamburger"))
+	assert.Equal(t, 1, repository.GetQuantity("cheeseburger"))
+
+	// and
+	restored, _ := NewPersistentShelf(context.Background(), repository)
+	assert.Equal(t, 2, restored.GetCurrent("hamburger"))
+	assert.Equal(t, 1, restored.GetCurrent("cheeseburger"))
+}
+
+func shouldStoreReservedItemsOnlyWhenReservationIsCommitted(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	repository.ReturnQuantity("hamburger", 5)
+	sut, _ := NewPersistentShelf(context.Background(), repository)
+
+	// when
+	committed, _ := sut.Reserve("hamburger", 2, 1010, time.Minute)
+	released, _ := sut.Reserve("hamburger", 1, 1011, time.Minute)
+	_, _ = sut.Reserve("hamburger", 1, 1012, time.Minute)
+
+	// then
+	assert.Equal(t, 1, sut.GetCurrent("hamburger"))
+	assert.Equal(t, 5, repository.GetQuantity("hamburger"))
+
+	// and
+	_ = sut.Commit(committed)
+	sut.Release(released)
+	assert.Equal(t, 2, sut.GetCurrent("hamburger"))
+	assert.Equal(t, 3, repository.GetQuantity("hamburger"))
+}
+
+func shouldFailWhenShelfCannotBeRestored(t *testing.T) {
+	// given
+	repository := GivenRepository()
+	repository.ReturnError(fmt.Errorf("db is down"))
+
+	// when
+	sut, err := NewPersistentShelf(context.Background(), repository)
+
+	// then
+	assert.Error(t, err)
+	assert.Nil(t, sut)
+}
@@ -0,0 +1,50 @@
+package shelf
+
+import (
+	"context"
+	"sync"
+)
+
+type StubRepository struct {
+	mu         sync.Mutex
+	quantities map[string]int
+	err        error
+}
+
+func GivenRepository() *StubRepository {
+	return &StubRepository{quantities: make(map[string]int)}
+}
+
+func (s *StubRepository) ReturnQuantity(item string, quantity int) {
+	s.quantities[item] = quantity
+}
+
+func (s *StubRepository) ReturnError(err error) {
+	s.err = err
+}
+
+func (s *StubRepository) FetchAll(ctx context.Context) (map[string]int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quantities := make(map[string]int)
+	for item, quantity := range s.quantities {
+		quantities[item] = quantity
+	}
+	return quantities, s.err
+}
+
+func (s *StubRepository) Increment(ctx context.Context, item string, delta int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.quantities[item] += delta
+	return s.err
+}
+
+func (s *StubRepository) GetQuantity(item string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.quantities[item]
+}
@@ -1,7 +1,6 @@
 package shelf
 
 import (
-	"github.com/spf13/cast"
 	"mc-burger-orders/event"
 	"mc-burger-orders/log"
 	"os"
@@ -13,14 +12,8 @@ func TopicConfigsFromEnv() *event.TopicConfigs {
 		log.Error.Panicf("Kafka Topic `Shelf events handler` name is missing")
 	}
 
-	partition := 0
-	partitionVal := os.Getenv("KAFKA_TOPICS__SHELF_TOPIC_PARTITION")
-	if len(partitionVal) > 0 {
-		partition = cast.ToInt(partitionVal)
-	}
-
 	numPartitionsVal := os.Getenv("KAFKA_TOPICS__SHELF_TOPIC_NUMBER_OF_PARTITIONS")
 	replicationFactorVal := os.Getenv("KAFKA_TOPICS__SHELF_TOPIC_REPLICA_FACTOR")
 
-	return event.NewTopicConfig(topic, partition, numPartitionsVal, replicationFactorVal)
+	return event.NewTopicConfig(topic, numPartitionsVal, replicationFactorVal)
 }
@@ -2,6 +2,7 @@ package utils
 
 import (
 	"context"
+	"fmt"
 	"github.com/stretchr/testify/assert"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/mongodb"
@@ -42,7 +43,7 @@ func InsertMany(t *testing.T, c *mongo.Collection, records []interface{}) {
 }
 
 func startMongoContainer(t *testing.T, ctx context.Context) *mongodb.MongoDBContainer {
-	mongodbContainer, err := mongodb.RunContainer(ctx, testcontainers.WithImage("mongo:6"))
+	mongodbContainer, err := runMongoReplicaSet(ctx)
 	attempt := 1
 	for {
 		if err != nil && attempt < 4 {
@@ -50,7 +51,7 @@ func startMongoContainer(t *testing.T, ctx context.Context) *mongodb.MongoDBCont
 
 			time.Sleep(1 * time.Second)
 			attempt++
-			mongodbContainer, err = mongodb.RunContainer(ctx, testcontainers.WithImage("mongo:6"))
+			mongodbContainer, err = runMongoReplicaSet(ctx)
 		}
 		if err != nil && attempt == 4 {
 			assert.Failf(t, "MONGO SETUP: Unable to Start MongoDB. %v", err.Error())
@@ -62,12 +63,37 @@ func startMongoContainer(t *testing.T, ctx context.Context) *mongodb.MongoDBCont
 	return mongodbContainer
 }
 
+// runMongoReplicaSet starts Mongo as a single-node replica set like in docker-compose, so orders are stored in
+// transactions in tests too. It returns once the node is the primary.
+func runMongoReplicaSet(ctx context.Context) (*mongodb.MongoDBContainer, error) {
+	withReplicaSet := testcontainers.CustomizeRequestOption(func(req *testcontainers.GenericContainerRequest) {
+		req.Cmd = []string{"--replSet", "rs0", "--bind_ip_all"}
+	})
+	mongodbContainer, err := mongodb.RunContainer(ctx, testcontainers.WithImage("mongo:6"), withReplicaSet)
+	if err != nil {
+		return nil, err
+	}
+
+	initiate := "rs.initiate({_id: 'rs0', members: [{_id: 0, host: 'localhost:27017'}]}); while (!db.hello().isWritablePrimary) { sleep(100) }"
+	exitCode, _, err := mongodbContainer.Exec(ctx, []string{"mongosh", "--quiet", "--eval", initiate})
+	if err == nil && exitCode != 0 {
+		err = fmt.Errorf("replica set initiation exited with code %d", exitCode)
+	}
+	if err != nil {
+		_ = mongodbContainer.Terminate(ctx)
+		return nil, err
+	}
+	return mongodbContainer, nil
+}
+
 func getDatabase(t *testing.T, m *mongodb.MongoDBContainer, ctx context.Context) *mongo.Database {
 	endpoint, err := m.ConnectionString(ctx)
 	if err != nil {
 		assert.Failf(t, "MONGO SETUP: Unable to get MongoDB Connection String", err.Error())
 	}
 
+	// the member is known as localhost:27017 inside the container, so the mapped port is connected to directly
+	endpoint += "/?directConnection=true"
 	t.Log("✅✅✅ Mongo Container: ", endpoint, ": ✅✅✅")
 	mongoClient, err := mongo.Connect(ctx, options.Client().ApplyURI(endpoint))
 	if err != nil {
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync"
+)
+
+var (
+	subjectVersionsPath      = regexp.MustCompile(`^/subjects/([^/]+)/versions$`)
+	schemaByIdPath           = regexp.MustCompile(`^/schemas/ids/(\d+)$`)
+	subjectCompatibilityPath = regexp.MustCompile(`^/compatibility/subjects/([^/]+)/versions/latest$`)
+)
+
+type registeredSchema struct {
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
+}
+
+// FakeSchemaRegistry serves the part of the schema registry REST API used by the service. Schema compatibility is
+// stricter than in the real registry: only the schema registered first for a subject is compatible with it.
+type FakeSchemaRegistry struct {
+	*httptest.Server
+	mu       sync.Mutex
+	schemas  []registeredSchema
+	subjects map[string]int
+}
+
+func NewFakeSchemaRegistry() *FakeSchemaRegistry {
+	registry := &FakeSchemaRegistry{subjects: make(map[string]int)}
+	registry.Server = httptest.NewServer(http.HandlerFunc(registry.serve))
+	return registry
+}
+
+// Registered returns how many schemas were registered, registering the same schema again does not count.
+func (r *FakeSchemaRegistry) Registered() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.schemas)
+}
+
+func (r *FakeSchemaRegistry) serve(w http.ResponseWriter, request *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := request.URL.EscapedPath()
+	if match := subjectVersionsPath.FindStringSubmatch(path); match != nil && request.Method == http.MethodPost {
+		r.register(w, request, match[1])
+		return
+	}
+	if match := schemaByIdPath.FindStringSubmatch(path); match != nil && request.Method == http.MethodGet {
+		id, _ := strconv.Atoi(match[1])
+		if id < 1 || id > len(r.schemas) {
+			writeRegistryError(w, http.StatusNotFound, 40403, "Schema not found")
+			return
+		}
+		writeRegistryResponse(w, r.schemas[id-1])
+		return
+	}
+	if match := subjectCompatibilityPath.FindStringSubmatch(path); match != nil && request.Method == http.MethodPost {
+		schema, ok := readSchema(w, request)
+		if !ok {
+			return
+		}
+		id, exists := r.subjects[match[1]]
+		if !exists {
+			writeRegistryError(w, http.StatusNotFound, 40401, "Subject not found")
+			return
+		}
+		writeRegistryResponse(w, map[string]bool{"is_compatible": r.schemas[id-1] == schema})
+		return
+	}
+	writeRegistryError(w, http.StatusNotFound, 404, "Not found")
+}
+
+func (r *FakeSchemaRegistry) register(w http.ResponseWriter, request *http.Request, subject string) {
+	schema, ok := readSchema(w, request)
+	if !ok {
+		return
+	}
+
+	if id, exists := r.subjects[subject]; exists {
+		if r.schemas[id-1] != schema {
+			writeRegistryError(w, http.StatusConflict, 409, "Schema being registered is incompatible with an earlier schema")
+			return
+		}
+		writeRegistryResponse(w, map[string]int{"id": id})
+		return
+	}
+
+	r.schemas = append(r.schemas, schema)
+	r.subjects[subject] = len(r.schemas)
+	writeRegistryResponse(w, map[string]int{"id": len(r.schemas)})
+}
+
+func readSchema(w http.ResponseWriter, request *http.Request) (registeredSchema, bool) {
+	schema := registeredSchema{}
+	if err := json.NewDecoder(request.Body).Decode(&schema); err != nil {
+		writeRegistryError(w, http.StatusUnprocessableEntity, 42201, err.Error())
+		return schema, false
+	}
+	return schema, true
+}
+
+func writeRegistryResponse(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func writeRegistryError(w http.ResponseWriter, status int, errorCode int, message string) {
+	w.Header().Set("Content-Type", "application/vnd.schemaregistry.v1+json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]any{"error_code": errorCode, "message": message})
+}